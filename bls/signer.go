@@ -0,0 +1,145 @@
+package bls
+
+import (
+	"crypto"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// PublicKey is the public key returned by Signer.Public
+type PublicKey struct {
+	BLS  BLS
+	PubK [3]*big.Int
+}
+
+// Equal returns true if x is the same public key
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return pk.BLS.Bn.G1.Equal(pk.PubK, other.PubK)
+}
+
+// Signer implements crypto.Signer with BLS keys. As the BLS signatures hash
+// the message to G2, the digest is signed as the message m. The signatures are
+// encoded with the affine coordinates of the G2 point, x0 || x1 || y0 || y1
+// with 32 bytes each (128 bytes), where x = x0 + x1 u
+type Signer struct {
+	BLS  BLS
+	Keys BLSKeys
+}
+
+// NewSigner returns the Signer of the keys, checking that the public key is the
+// one of the private key
+func NewSigner(bls BLS, keys BLSKeys) (*Signer, error) {
+	if keys.PrivK == nil || keys.PrivK.Sign() <= 0 {
+		return nil, errors.New("invalid private key")
+	}
+	if keys.PubK[0] == nil || !bls.Bn.G1.Equal(bls.Bn.G1.MulScalar(bls.Bn.G1.G, keys.PrivK), keys.PubK) {
+		return nil, errors.New("the public key is not the one of the private key")
+	}
+	return &Signer{BLS: bls, Keys: keys}, nil
+}
+
+// Public returns the public key, a *PublicKey
+func (s *Signer) Public() crypto.PublicKey {
+	return &PublicKey{BLS: s.BLS, PubK: s.Keys.PubK}
+}
+
+// Sign signs the digest as the message m. The BLS signatures are deterministic,
+// so random is not used. When opts has a hash function, the length of the
+// digest must be its size
+func (s *Signer) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 && len(digest) != opts.HashFunc().Size() {
+		return nil, errors.New("the digest length does not match the hash function")
+	}
+	return s.BLS.MarshalSignature(s.BLS.Sign(s.Keys.PrivK, digest)), nil
+}
+
+// MarshalSignature returns the encoding of the signature, the affine
+// coordinates x0 || x1 || y0 || y1 of the G2 point
+func (bls BLS) MarshalSignature(sig [3][2]*big.Int) []byte {
+	b := make([]byte, 128)
+	if bls.Bn.G2.IsZero(sig) {
+		return b
+	}
+	aff := bls.Bn.G2.Affine(sig)
+	for i, c := range []*big.Int{aff[0][0], aff[0][1], aff[1][0], aff[1][1]} {
+		c.FillBytes(b[32*i : 32*(i+1)])
+	}
+	return b
+}
+
+// UnmarshalSignature decodes a signature encoded with MarshalSignature, and
+// checks that the point is on the twist curve y^2 = x^3 + b' over Fq2 and in
+// the subgroup of order R, so the pairing of Verify is not computed over
+// invalid points
+func (bls BLS) UnmarshalSignature(b []byte) ([3][2]*big.Int, error) {
+	if len(b) != 128 {
+		return [3][2]*big.Int{}, errors.New("invalid signature encoding: length")
+	}
+	var c [4]*big.Int
+	for i := range c {
+		c[i] = new(big.Int).SetBytes(b[32*i : 32*(i+1)])
+		if c[i].Cmp(bls.Bn.Q) >= 0 {
+			return [3][2]*big.Int{}, errors.New("invalid signature encoding: coordinate out of the field")
+		}
+	}
+	if c[0].Sign() == 0 && c[1].Sign() == 0 && c[2].Sign() == 0 && c[3].Sign() == 0 {
+		return bls.Bn.G2.Zero(), nil
+	}
+	p := [3][2]*big.Int{{c[0], c[1]}, {c[2], c[3]}, bls.Bn.Fq2.One()}
+	fq2 := bls.Bn.Fq2
+	x3b := fq2.Add(fq2.Mul(fq2.Square(p[0]), p[0]), bls.Bn.TwistCoefB)
+	if !fq2.Equal(fq2.Square(p[1]), x3b) {
+		return [3][2]*big.Int{}, errors.New("invalid signature: the point is not on the curve")
+	}
+	if !bls.Bn.G2.IsZero(bls.Bn.G2.MulScalar(p, bls.Bn.R)) {
+		return [3][2]*big.Int{}, errors.New("invalid signature: the point is not in the subgroup")
+	}
+	return p, nil
+}
+
+// ValidatePubK checks that the public key is a point of G1 on the curve
+// y^2 = x^3 + b, in the subgroup of order R and not the point at infinity, so
+// the pairing of Verify is not computed over invalid points
+func (bls BLS) ValidatePubK(pubK [3]*big.Int) error {
+	if pubK[0] == nil || pubK[1] == nil || pubK[2] == nil {
+		return errors.New("invalid public key")
+	}
+	if bls.Bn.G1.IsZero(pubK) {
+		return errors.New("invalid public key: the point at infinity")
+	}
+	aff := bls.Bn.G1.Affine(pubK)
+	fq := bls.Bn.Fq1
+	x3b := fq.Add(fq.Mul(fq.Square(aff[0]), aff[0]), bls.Bn.CoefB)
+	if !fq.Equal(fq.Square(aff[1]), x3b) {
+		return errors.New("invalid public key: the point is not on the curve")
+	}
+	if !bls.Bn.G1.IsZero(bls.Bn.G1.MulScalar(pubK, bls.Bn.R)) {
+		return errors.New("invalid public key: the point is not in the subgroup")
+	}
+	return nil
+}
+
+// VerifySignature validates the signature of the digest with the public key
+// returned by Signer.Public, checking first the public key with ValidatePubK
+func VerifySignature(pub crypto.PublicKey, digest, sig []byte, opts crypto.SignerOpts) (bool, error) {
+	pk, ok := pub.(*PublicKey)
+	if !ok {
+		return false, errors.New("not a BLS public key")
+	}
+	if opts != nil && opts.HashFunc() != 0 && len(digest) != opts.HashFunc().Size() {
+		return false, errors.New("the digest length does not match the hash function")
+	}
+	if err := pk.BLS.ValidatePubK(pk.PubK); err != nil {
+		return false, err
+	}
+	s, err := pk.BLS.UnmarshalSignature(sig)
+	if err != nil {
+		return false, err
+	}
+	return pk.BLS.Verify(digest, s, pk.PubK), nil
+}
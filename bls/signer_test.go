@@ -0,0 +1,155 @@
+package bls
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	bls, err := NewBLS()
+	assert.Nil(t, err)
+	keys, err := bls.NewKeys()
+	assert.Nil(t, err)
+	signer, err := NewSigner(bls, keys)
+	assert.Nil(t, err)
+	var _ crypto.Signer = signer
+
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	assert.Equal(t, 128, len(sig))
+	// the BLS signatures are deterministic
+	sig2, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	assert.Equal(t, sig, sig2)
+
+	s, err := bls.UnmarshalSignature(sig)
+	assert.Nil(t, err)
+	assert.True(t, bls.Bn.G2.Equal(bls.Sign(keys.PrivK, digest[:]), s))
+
+	verified, err := VerifySignature(signer.Public(), digest[:], sig, crypto.SHA256)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	verified, err = VerifySignature(signer.Public(), []byte("another message"), sig, nil)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	other, err := bls.NewKeys()
+	assert.Nil(t, err)
+	otherSigner, err := NewSigner(bls, other)
+	assert.Nil(t, err)
+	assert.True(t, signer.Public().(*PublicKey).Equal(signer.Public()))
+	assert.False(t, signer.Public().(*PublicKey).Equal(otherSigner.Public()))
+
+	// invalid inputs
+	_, err = signer.Sign(nil, []byte("message"), crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = VerifySignature(signer.Public(), digest[:], sig[1:], crypto.SHA256)
+	assert.NotNil(t, err)
+	bad := append([]byte{}, sig...)
+	bls.Bn.Q.FillBytes(bad[:32])
+	_, err = VerifySignature(signer.Public(), digest[:], bad, crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = VerifySignature(keys.PubK, digest[:], sig, crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = NewSigner(bls, BLSKeys{PrivK: keys.PrivK, PubK: other.PubK})
+	assert.NotNil(t, err)
+	_, err = NewSigner(bls, BLSKeys{PrivK: big.NewInt(int64(0)), PubK: keys.PubK})
+	assert.NotNil(t, err)
+}
+
+func TestUnmarshalSignatureInvalidPoint(t *testing.T) {
+	bls, err := NewBLS()
+	assert.Nil(t, err)
+	fq2 := bls.Bn.Fq2
+	sig := bls.MarshalSignature(bls.Bn.G2.G)
+	_, err = bls.UnmarshalSignature(sig)
+	assert.Nil(t, err)
+
+	// off the curve
+	bad := append([]byte{}, sig...)
+	bad[127] ^= 1
+	_, err = bls.UnmarshalSignature(bad)
+	assert.NotNil(t, err)
+
+	// on the curve, out of the subgroup of order R: the first x with
+	// x^3 + b' square in Fq2
+	x := [2]*big.Int{big.NewInt(int64(1)), big.NewInt(int64(0))}
+	var y [2]*big.Int
+	for {
+		var ok bool
+		if y, ok = fq2Sqrt(bls, fq2.Add(fq2.Mul(fq2.Square(x), x), bls.Bn.TwistCoefB)); ok {
+			break
+		}
+		x[0].Add(x[0], big.NewInt(int64(1)))
+	}
+	p := [3][2]*big.Int{x, y, fq2.One()}
+	assert.False(t, bls.Bn.G2.IsZero(bls.Bn.G2.MulScalar(p, bls.Bn.R)))
+	_, err = bls.UnmarshalSignature(bls.MarshalSignature(p))
+	assert.NotNil(t, err)
+}
+
+// fq2Exp returns a^e in Fq2
+func fq2Exp(bls BLS, a [2]*big.Int, e *big.Int) [2]*big.Int {
+	fq2 := bls.Bn.Fq2
+	r := fq2.One()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = fq2.Square(r)
+		if e.Bit(i) == 1 {
+			r = fq2.Mul(r, a)
+		}
+	}
+	return r
+}
+
+// fq2Sqrt returns a square root of a in Fq2, for q = 3 mod 4 (algorithm 9 of
+// "Square root computation over even extension fields", Adj and
+// Rodriguez-Henriquez)
+func fq2Sqrt(bls BLS, a [2]*big.Int) ([2]*big.Int, bool) {
+	fq2 := bls.Bn.Fq2
+	q := bls.Bn.Q
+	minusOne := fq2.Neg(fq2.One())
+	a1 := fq2Exp(bls, a, new(big.Int).Rsh(new(big.Int).Sub(q, big.NewInt(int64(3))), 2))
+	alpha := fq2.Mul(fq2.Square(a1), a)
+	a0 := fq2.Mul(fq2Exp(bls, alpha, q), alpha)
+	if fq2.Equal(a0, minusOne) {
+		return [2]*big.Int{}, false
+	}
+	x0 := fq2.Mul(a1, a)
+	var x [2]*big.Int
+	if fq2.Equal(alpha, minusOne) {
+		x = fq2.Mul([2]*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1))}, x0)
+	} else {
+		b := fq2Exp(bls, fq2.Add(fq2.One(), alpha), new(big.Int).Rsh(new(big.Int).Sub(q, big.NewInt(int64(1))), 1))
+		x = fq2.Mul(b, x0)
+	}
+	return fq2.Affine(x), fq2.Equal(fq2.Square(x), a)
+}
+
+func TestVerifySignatureInvalidPubK(t *testing.T) {
+	bls, err := NewBLS()
+	assert.Nil(t, err)
+	keys, err := bls.NewKeys()
+	assert.Nil(t, err)
+	signer, err := NewSigner(bls, keys)
+	assert.Nil(t, err)
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	assert.Nil(t, bls.ValidatePubK(keys.PubK))
+
+	// the point at infinity, a point off the curve and a nil point
+	aff := bls.Bn.G1.Affine(keys.PubK)
+	offCurve := [3]*big.Int{aff[0], new(big.Int).Add(aff[1], big.NewInt(int64(1))), big.NewInt(int64(1))}
+	for _, pubK := range [][3]*big.Int{bls.Bn.G1.MulScalar(bls.Bn.G1.G, bls.Bn.R), offCurve, {}} {
+		assert.NotNil(t, bls.ValidatePubK(pubK))
+		verified, err := VerifySignature(&PublicKey{BLS: bls, PubK: pubK}, digest[:], sig, crypto.SHA256)
+		assert.NotNil(t, err)
+		assert.False(t, verified)
+	}
+}
@@ -0,0 +1,368 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/gf2m"
+)
+
+// BinaryEC is an elliptic curve over the binary field GF(2^m), in the non
+// supersingular form y^2 + xy = x^3 + ax^2 + b, with b != 0. As (0, 0) is not
+// on the curve, ZeroPoint is also used for the point at infinity
+type BinaryEC struct {
+	F *gf2m.Field
+	A *big.Int
+	B *big.Int
+}
+
+// NewBinaryEC returns the curve y^2 + xy = x^3 + ax^2 + b over the binary field f
+func NewBinaryEC(f *gf2m.Field, a, b *big.Int) (BinaryEC, error) {
+	if !f.Contains(a) || !f.Contains(b) {
+		return BinaryEC{}, errors.New("the curve coefficients must be elements of the field")
+	}
+	if b.Sign() == 0 {
+		return BinaryEC{}, errors.New("b = 0 gives a singular curve")
+	}
+	return BinaryEC{F: f, A: a, B: b}, nil
+}
+
+// BinaryCurve is a named elliptic curve over a binary field, with its generator
+// point G, the order N of G and the cofactor H
+type BinaryCurve struct {
+	Name string
+	EC   BinaryEC
+	G    Point
+	N    *big.Int
+	H    *big.Int
+}
+
+// Sect163k1 returns the Koblitz curve sect163k1 (NIST K-163, SEC 2 section 3.2.1)
+func Sect163k1() BinaryCurve {
+	return newBinaryCurve("sect163k1", []int{163, 7, 6, 3},
+		"1",
+		"1",
+		"2fe13c0537bbc11acaa07d793de4e6d5e5c94eee8",
+		"289070fb05d38ff58321f2e800536d538ccdaa3d9",
+		"4000000000000000000020108a2e0cc0d99f8a5ef",
+		"2")
+}
+
+// Sect163r2 returns the curve sect163r2 (NIST B-163, SEC 2 section 3.2.3)
+func Sect163r2() BinaryCurve {
+	return newBinaryCurve("sect163r2", []int{163, 7, 6, 3},
+		"1",
+		"20a601907b8c953ca1481eb10512f78744a3205fd",
+		"3f0eba16286a2d57ea0991168d4994637e8343e36",
+		"0d51fbc6c71a0094fa2cdd545b11c5c0c797324f1",
+		"40000000000000000000292fe77e70c12a4234c33",
+		"2")
+}
+
+// Sect233k1 returns the Koblitz curve sect233k1 (NIST K-233, SEC 2 section 3.3.1)
+func Sect233k1() BinaryCurve {
+	return newBinaryCurve("sect233k1", []int{233, 74},
+		"0",
+		"1",
+		"17232ba853a7e731af129f22ff4149563a419c26bf50a4c9d6eefad6126",
+		"1db537dece819b7f70f555a67c427a8cd9bf18aeb9b56e0c11056fae6a3",
+		"8000000000000000000000000000069d5bb915bcd46efb1ad5f173abdf",
+		"4")
+}
+
+// Sect233r1 returns the curve sect233r1 (NIST B-233, SEC 2 section 3.3.2)
+func Sect233r1() BinaryCurve {
+	return newBinaryCurve("sect233r1", []int{233, 74},
+		"1",
+		"66647ede6c332c7f8c0923bb58213b333b20e9ce4281fe115f7d8f90ad",
+		"fac9dfcbac8313bb2139f1bb755fef65bc391f8b36f8f8eb7371fd558b",
+		"1006a08a41903350678e58528bebf8a0beff867a7ca36716f7e01f81052",
+		"1000000000000000000000000000013e974e72f8a6922031d2603cfe0d7",
+		"2")
+}
+
+// newBinaryCurve builds a BinaryCurve from the exponents of the reduction
+// polynomial and the hex encoded parameters
+func newBinaryCurve(name string, poly []int, a, b, gx, gy, n, h string) BinaryCurve {
+	f, err := gf2m.NewField(poly[0], poly[1:]...)
+	if err != nil {
+		panic("ecc: invalid binary field of " + name)
+	}
+	ec, err := NewBinaryEC(f, hexToInt(a), hexToInt(b))
+	if err != nil {
+		panic("ecc: invalid binary curve " + name)
+	}
+	return BinaryCurve{
+		Name: name,
+		EC:   ec,
+		G:    Point{hexToInt(gx), hexToInt(gy)},
+		N:    hexToInt(n),
+		H:    hexToInt(h),
+	}
+}
+
+// IsOnCurve returns true if the point p satisfies the curve equation and its
+// coordinates are elements of the field. The point at infinity is considered on
+// the curve
+func (ec *BinaryEC) IsOnCurve(p Point) bool {
+	if p.X == nil || p.Y == nil {
+		return false
+	}
+	if p.Equal(ZeroPoint) {
+		return true
+	}
+	if !ec.F.Contains(p.X) || !ec.F.Contains(p.Y) {
+		return false
+	}
+	f := ec.F
+	// y^2 + xy == x^3 + ax^2 + b
+	lhs := f.Add(f.Square(p.Y), f.Mul(p.X, p.Y))
+	x2 := f.Square(p.X)
+	rhs := f.Add(f.Mul(x2, f.Add(p.X, ec.A)), ec.B)
+	return lhs.Cmp(rhs) == 0
+}
+
+// Validate returns an error if the point p is not a point of the curve
+func (ec *BinaryEC) Validate(p Point) error {
+	if p.X == nil || p.Y == nil {
+		return errors.New("invalid point: nil coordinate")
+	}
+	if !ec.IsOnCurve(p) {
+		return errors.New("invalid point: " + p.String() + " not on the curve")
+	}
+	return nil
+}
+
+// At gets the two points of the curve with the given x, (x, xz) and
+// (x, xz + x) where z^2 + z = x + a + b/x^2, or an error when there is no
+// point at x. For x = 0 both points are (0, sqrt(b))
+func (ec *BinaryEC) At(x *big.Int) (Point, Point, error) {
+	f := ec.F
+	if !f.Contains(x) {
+		return Point{}, Point{}, errors.New("x out of the field")
+	}
+	if x.Sign() == 0 {
+		p := Point{big.NewInt(int64(0)), f.Sqrt(ec.B)}
+		return p, p, nil
+	}
+	x2Inv, err := f.Inverse(f.Square(x))
+	if err != nil {
+		return Point{}, Point{}, err
+	}
+	c := f.Add(f.Add(x, ec.A), f.Mul(ec.B, x2Inv))
+	z, err := f.SolveQuadratic(c)
+	if err != nil {
+		return Point{}, Point{}, errors.New("no point at x = " + x.String())
+	}
+	y := f.Mul(x, z)
+	return Point{x, y}, Point{x, f.Add(y, x)}, nil
+}
+
+// Neg returns the inverse of the point p, -(x, y) = (x, x + y)
+func (ec *BinaryEC) Neg(p Point) (Point, error) {
+	if err := ec.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.Equal(ZeroPoint) {
+		return ZeroPoint, nil
+	}
+	return Point{p.X, ec.F.Add(p.X, p.Y)}, nil
+}
+
+// Add adds two points p1 and p2 and gets q
+func (ec *BinaryEC) Add(p1, p2 Point) (Point, error) {
+	if err := ec.Validate(p1); err != nil {
+		return Point{}, err
+	}
+	if err := ec.Validate(p2); err != nil {
+		return Point{}, err
+	}
+	return ec.add(p1, p2), nil
+}
+
+// add returns p1 + p2 in affine coordinates, with one field inversion
+func (ec *BinaryEC) add(p1, p2 Point) Point {
+	if p1.Equal(ZeroPoint) {
+		return p2
+	}
+	if p2.Equal(ZeroPoint) {
+		return p1
+	}
+	if p1.X.Cmp(p2.X) == 0 {
+		if p1.Y.Cmp(p2.Y) == 0 {
+			return ec.double(p1)
+		}
+		// p2 = -p1
+		return ZeroPoint
+	}
+	f := ec.F
+	// l = (y1 + y2) / (x1 + x2)
+	l, _ := f.Div(f.Add(p1.Y, p2.Y), f.Add(p1.X, p2.X))
+	// x3 = l^2 + l + x1 + x2 + a
+	x3 := f.Add(f.Add(f.Square(l), l), f.Add(f.Add(p1.X, p2.X), ec.A))
+	// y3 = l (x1 + x3) + x3 + y1
+	y3 := f.Add(f.Add(f.Mul(l, f.Add(p1.X, x3)), x3), p1.Y)
+	return Point{x3, y3}
+}
+
+// double returns 2p in affine coordinates
+func (ec *BinaryEC) double(p Point) Point {
+	if p.Equal(ZeroPoint) || p.X.Sign() == 0 {
+		// the points with x = 0 have order 2
+		return ZeroPoint
+	}
+	f := ec.F
+	// l = x + y / x
+	yx, _ := f.Div(p.Y, p.X)
+	l := f.Add(p.X, yx)
+	// x3 = l^2 + l + a
+	x3 := f.Add(f.Add(f.Square(l), l), ec.A)
+	// y3 = x^2 + (l + 1) x3
+	y3 := f.Add(f.Square(p.X), f.Mul(f.Add(l, big.NewInt(int64(1))), x3))
+	return Point{x3, y3}
+}
+
+// Mul multiplies a point n times on the curve, using the Montgomery ladder as
+// EC.Mul. Neither p nor n are modified
+func (ec *BinaryEC) Mul(p Point, n *big.Int) (Point, error) {
+	if err := ec.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if n.Sign() < 0 {
+		pNeg, err := ec.Neg(p)
+		if err != nil {
+			return Point{}, err
+		}
+		return ec.Mul(pNeg, new(big.Int).Neg(n))
+	}
+	bits := ec.F.M + 1
+	if n.BitLen() > bits {
+		bits = n.BitLen()
+	}
+	r := [2]Point{ZeroPoint, p}
+	for i := bits - 1; i >= 0; i-- {
+		b := n.Bit(i)
+		r[1-b] = ec.add(r[0], r[1])
+		r[b] = ec.double(r[b])
+	}
+	return r[0], nil
+}
+
+// IsKoblitz returns true if the curve is a Koblitz (anomalous binary) curve,
+// with a in {0, 1} and b = 1
+func (ec *BinaryEC) IsKoblitz() bool {
+	return ec.B.Cmp(BigOne) == 0 && (ec.A.Sign() == 0 || ec.A.Cmp(BigOne) == 0)
+}
+
+// Frobenius returns the Frobenius endomorphism of the point, tau(x, y) =
+// (x^2, y^2), which on the Koblitz curves satisfies tau^2 - mu tau + 2 = 0,
+// with mu = 1 when a = 1 and mu = -1 when a = 0
+func (ec *BinaryEC) Frobenius(p Point) Point {
+	if p.Equal(ZeroPoint) {
+		return ZeroPoint
+	}
+	return Point{ec.F.Square(p.X), ec.F.Square(p.Y)}
+}
+
+// MulTau multiplies a point n times on a Koblitz curve with the tau-adic
+// method: n is reduced modulo tau^m - 1 in Z[tau] and written in tau-adic non
+// adjacent form (TNAF), so the multiplication only needs Frobenius maps, which
+// are two squarings, and about m/3 additions, instead of the m doublings
+func (ec *BinaryEC) MulTau(p Point, n *big.Int) (Point, error) {
+	if !ec.IsKoblitz() {
+		return Point{}, errors.New("the tau-adic multiplication needs a Koblitz curve")
+	}
+	if err := ec.Validate(p); err != nil {
+		return Point{}, err
+	}
+	pNeg := Point{p.X, ec.F.Add(p.X, p.Y)}
+	digits := TNAF(ec.reduceTau(n), ec.mu())
+	q := ZeroPoint
+	for i := len(digits) - 1; i >= 0; i-- {
+		q = ec.Frobenius(q)
+		switch digits[i] {
+		case 1:
+			q = ec.add(q, p)
+		case -1:
+			q = ec.add(q, pNeg)
+		}
+	}
+	return q, nil
+}
+
+// mu returns the mu of the Koblitz curve, 1 when a = 1 and -1 when a = 0
+func (ec *BinaryEC) mu() int64 {
+	if ec.A.Sign() == 0 {
+		return -1
+	}
+	return 1
+}
+
+// TauElement is an element r0 + r1 tau of Z[tau], where tau is a root of
+// tau^2 - mu tau + 2 = 0
+type TauElement [2]*big.Int
+
+// tauMul returns a b in Z[tau], using tau^2 = mu tau - 2
+func tauMul(a, b TauElement, mu int64) TauElement {
+	ac := new(big.Int).Mul(a[0], b[0])
+	bd := new(big.Int).Mul(a[1], b[1])
+	// (a0 + a1 tau)(b0 + b1 tau) = a0 b0 - 2 a1 b1 + (a0 b1 + a1 b0 + mu a1 b1) tau
+	r0 := new(big.Int).Sub(ac, new(big.Int).Lsh(bd, 1))
+	r1 := new(big.Int).Mul(a[0], b[1])
+	r1.Add(r1, new(big.Int).Mul(a[1], b[0]))
+	r1.Add(r1, bd.Mul(bd, big.NewInt(mu)))
+	return TauElement{r0, r1}
+}
+
+// reduceTau returns an element of Z[tau] congruent to n modulo tau^m - 1, which
+// annihilates all the points of the curve, as tau^m(x, y) = (x, y). The
+// quotient is the rounding of n / (tau^m - 1) = n conj(d) / N(d)
+func (ec *BinaryEC) reduceTau(n *big.Int) TauElement {
+	mu := ec.mu()
+	// d = tau^m - 1
+	d := TauElement{big.NewInt(int64(1)), big.NewInt(int64(0))}
+	tau := TauElement{big.NewInt(int64(0)), big.NewInt(int64(1))}
+	for i := 0; i < ec.F.M; i++ {
+		d = tauMul(d, tau, mu)
+	}
+	d[0].Sub(d[0], BigOne)
+	// conj(d0 + d1 tau) = d0 + mu d1 - d1 tau, N(d) = d conj(d) = d0^2 + mu d0 d1 + 2 d1^2
+	conj := TauElement{new(big.Int).Add(d[0], new(big.Int).Mul(d[1], big.NewInt(mu))), new(big.Int).Neg(d[1])}
+	norm := tauMul(d, conj, mu)[0]
+	num := tauMul(TauElement{n, big.NewInt(int64(0))}, conj, mu)
+	// q = round(num / norm), as floor((2 num + norm) / (2 norm))
+	norm2 := new(big.Int).Lsh(norm, 1)
+	var q TauElement
+	for i := range q {
+		q[i] = new(big.Int).Lsh(num[i], 1)
+		q[i].Add(q[i], norm)
+		q[i].Div(q[i], norm2)
+	}
+	qd := tauMul(q, d, mu)
+	return TauElement{new(big.Int).Sub(n, qd[0]), new(big.Int).Neg(qd[1])}
+}
+
+// TNAF returns the tau-adic non adjacent form of r = r0 + r1 tau, the digits
+// u_i in {-1, 0, 1} with r = sum u_i tau^i from the least significant, where
+// of any two consecutive digits at least one is zero
+func TNAF(r TauElement, mu int64) []int {
+	r0, r1 := new(big.Int).Set(r[0]), new(big.Int).Set(r[1])
+	four := big.NewInt(int64(4))
+	var digits []int
+	for r0.Sign() != 0 || r1.Sign() != 0 {
+		u := 0
+		if r0.Bit(0) == 1 {
+			// u = 2 - ((r0 - 2 r1) mod 4)
+			t := new(big.Int).Lsh(r1, 1)
+			t.Sub(r0, t)
+			u = 2 - int(t.Mod(t, four).Int64())
+			r0.Sub(r0, big.NewInt(int64(u)))
+		}
+		digits = append(digits, u)
+		// (r0, r1) = (r1 + mu r0 / 2, -r0 / 2)
+		half := new(big.Int).Quo(r0, big.NewInt(int64(2)))
+		r0.Add(r1, new(big.Int).Mul(half, big.NewInt(mu)))
+		r1.Neg(half)
+	}
+	return digits
+}
@@ -0,0 +1,168 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/gf2m"
+	"github.com/stretchr/testify/assert"
+)
+
+// toyKoblitz returns the curve y^2 + xy = x^3 + x^2 + 1 over GF(2^5), which has
+// 2^5 + 1 - V_5 = 22 points, where V is the Lucas sequence V_k = V_(k-1) - 2 V_(k-2)
+func toyKoblitz(t *testing.T) BinaryEC {
+	f, err := gf2m.NewField(5, 2)
+	assert.Nil(t, err)
+	ec, err := NewBinaryEC(f, big.NewInt(int64(1)), big.NewInt(int64(1)))
+	assert.Nil(t, err)
+	return ec
+}
+
+// binaryPoints returns all the points of a small binary curve
+func binaryPoints(t *testing.T, ec BinaryEC) []Point {
+	points := []Point{ZeroPoint}
+	for x := int64(0); x < 1<<uint(ec.F.M); x++ {
+		p, pNeg, err := ec.At(big.NewInt(x))
+		if err != nil {
+			continue
+		}
+		assert.True(t, ec.IsOnCurve(p))
+		assert.True(t, ec.IsOnCurve(pNeg))
+		points = append(points, p)
+		if !p.Equal(pNeg) {
+			points = append(points, pNeg)
+		}
+	}
+	return points
+}
+
+func TestNewBinaryEC(t *testing.T) {
+	f, err := gf2m.NewField(4, 1)
+	assert.Nil(t, err)
+	_, err = NewBinaryEC(f, big.NewInt(int64(1)), big.NewInt(int64(0)))
+	assert.NotNil(t, err)
+	_, err = NewBinaryEC(f, big.NewInt(int64(16)), big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+}
+
+func TestBinaryGroupLaw(t *testing.T) {
+	ec := toyKoblitz(t)
+	points := binaryPoints(t, ec)
+	assert.Equal(t, 22, len(points))
+	for _, p := range points {
+		// p + (-p) = O
+		pNeg, err := ec.Neg(p)
+		assert.Nil(t, err)
+		o, err := ec.Add(p, pNeg)
+		assert.Nil(t, err)
+		assert.True(t, o.Equal(ZeroPoint))
+		// 22p = O
+		o, err = ec.Mul(p, big.NewInt(int64(22)))
+		assert.Nil(t, err)
+		assert.True(t, o.Equal(ZeroPoint))
+		// the addition is commutative and associative, and closed
+		for _, q := range points {
+			pq, err := ec.Add(p, q)
+			assert.Nil(t, err)
+			qp, err := ec.Add(q, p)
+			assert.Nil(t, err)
+			assert.True(t, pq.Equal(qp))
+			assert.True(t, ec.IsOnCurve(pq))
+			pqq, err := ec.Add(pq, q)
+			assert.Nil(t, err)
+			q2, err := ec.Mul(q, big.NewInt(int64(2)))
+			assert.Nil(t, err)
+			pq2, err := ec.Add(p, q2)
+			assert.Nil(t, err)
+			assert.True(t, pqq.Equal(pq2))
+		}
+	}
+
+	_, err := ec.Add(Point{big.NewInt(int64(1)), big.NewInt(int64(1))}, ZeroPoint)
+	assert.NotNil(t, err)
+}
+
+func TestBinaryMulTauToy(t *testing.T) {
+	ec := toyKoblitz(t)
+	assert.True(t, ec.IsKoblitz())
+	for _, p := range binaryPoints(t, ec) {
+		for k := int64(-20); k < 40; k++ {
+			expected, err := ec.Mul(p, big.NewInt(k))
+			assert.Nil(t, err)
+			q, err := ec.MulTau(p, big.NewInt(k))
+			assert.Nil(t, err)
+			assert.True(t, expected.Equal(q), "k = %d", k)
+		}
+	}
+}
+
+func TestBinaryCurves(t *testing.T) {
+	for _, c := range []BinaryCurve{Sect163k1(), Sect163r2(), Sect233k1(), Sect233r1()} {
+		assert.True(t, c.EC.IsOnCurve(c.G), c.Name)
+		assert.True(t, c.N.ProbablyPrime(20), c.Name)
+		nG, err := c.EC.Mul(c.G, c.N)
+		assert.Nil(t, err)
+		assert.True(t, nG.Equal(ZeroPoint), c.Name)
+
+		// At recovers the generator
+		p, pNeg, err := c.EC.At(c.G.X)
+		assert.Nil(t, err)
+		assert.True(t, c.G.Equal(p) || c.G.Equal(pNeg))
+
+		if !c.EC.IsKoblitz() {
+			_, err = c.EC.MulTau(c.G, c.N)
+			assert.NotNil(t, err)
+			continue
+		}
+		for i := 0; i < 5; i++ {
+			k, err := rand.Int(rand.Reader, c.N)
+			assert.Nil(t, err)
+			expected, err := c.EC.Mul(c.G, k)
+			assert.Nil(t, err)
+			q, err := c.EC.MulTau(c.G, k)
+			assert.Nil(t, err)
+			assert.True(t, expected.Equal(q), c.Name)
+		}
+	}
+}
+
+func TestTNAF(t *testing.T) {
+	for _, mu := range []int64{-1, 1} {
+		for i := 0; i < 20; i++ {
+			r0, err := rand.Int(rand.Reader, big.NewInt(int64(1)<<40))
+			assert.Nil(t, err)
+			r1, err := rand.Int(rand.Reader, big.NewInt(int64(1)<<40))
+			assert.Nil(t, err)
+			r1.Neg(r1)
+			digits := TNAF(TauElement{r0, r1}, mu)
+			// sum u_i tau^i == r, and no adjacent non zero digits
+			sum := TauElement{big.NewInt(int64(0)), big.NewInt(int64(0))}
+			tau := TauElement{big.NewInt(int64(0)), big.NewInt(int64(1))}
+			for j := len(digits) - 1; j >= 0; j-- {
+				sum = tauMul(sum, tau, mu)
+				sum[0].Add(sum[0], big.NewInt(int64(digits[j])))
+				if j > 0 {
+					assert.False(t, digits[j] != 0 && digits[j-1] != 0)
+				}
+			}
+			assert.Equal(t, 0, sum[0].Cmp(r0))
+			assert.Equal(t, 0, sum[1].Cmp(r1))
+		}
+	}
+}
+
+func BenchmarkBinaryMul(b *testing.B) {
+	c := Sect233k1()
+	k, _ := rand.Int(rand.Reader, c.N)
+	b.Run("ladder", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.EC.Mul(c.G, k)
+		}
+	})
+	b.Run("tau-adic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.EC.MulTau(c.G, k)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package ecc
+
+import (
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/field"
+)
+
+// homogeneousPoint is a point in homogeneous projective coordinates, where
+// (X, Y, Z) represents the affine point (X/Z, Y/Z) and (0, 1, 0) the point at
+// infinity. It is used with the complete formulas of Renes, Costello and Batina
+// ("Complete addition formulas for prime order elliptic curves", 2015), which
+// have no branches: the same field operations are done for any pair of points,
+// including the point at infinity and the addition of equal or opposite points.
+//
+// The formulas are complete over the curves without points of order 2 (as the
+// curves of prime order). Otherwise the only exceptional pairs are P1, P2 with
+// P1 - P2 of order 2, for which the result is (0, 0, 0)
+type homogeneousPoint struct {
+	X field.Element
+	Y field.Element
+	Z field.Element
+}
+
+// homogeneousInfinity returns the point at infinity in homogeneous coordinates
+func (c *curveArith) homogeneousInfinity() homogeneousPoint {
+	return homogeneousPoint{*c.f.Zero(), *c.f.One(), *c.f.Zero()}
+}
+
+// toHomogeneous converts an affine point to homogeneous coordinates
+func (c *curveArith) toHomogeneous(p Point) homogeneousPoint {
+	if p.Equal(ZeroPoint) {
+		return c.homogeneousInfinity()
+	}
+	return homogeneousPoint{*c.f.NewElement(p.X), *c.f.NewElement(p.Y), *c.f.One()}
+}
+
+// homogeneousToAffine converts a point in homogeneous coordinates to affine
+// coordinates. When secret is true (the results of the multiplications by
+// secret scalars) the inverse of Z is Z^(q-2), as the operations of the
+// exponentiation do not depend on the value of Z, otherwise it is the faster
+// variable time inversion
+func (c *curveArith) homogeneousToAffine(p homogeneousPoint, secret bool) Point {
+	if p.Z.IsZero() {
+		return ZeroPoint
+	}
+	var zInv, x, y field.Element
+	if secret {
+		zInv.Exp(&p.Z, new(big.Int).Sub(c.f.P, big.NewInt(int64(2))))
+	} else {
+		zInv.Inverse(&p.Z)
+	}
+	x.Mul(&p.X, &zInv)
+	y.Mul(&p.Y, &zInv)
+	return Point{x.BigInt(), y.BigInt()}
+}
+
+// isExceptional returns true if p is (0, 0, 0), the result of the complete
+// formulas for the exceptional pairs of the curves with points of order 2
+func (p *homogeneousPoint) isExceptional() bool {
+	return p.X.IsZero() && p.Y.IsZero() && p.Z.IsZero()
+}
+
+// condSwap swaps p1 and p2 if b = 1, and leaves them unchanged if b = 0,
+// without branches on b
+func (c *curveArith) condSwap(p1, p2 *homogeneousPoint, b uint64) {
+	field.CondSwap(&p1.X, &p2.X, b)
+	field.CondSwap(&p1.Y, &p2.Y, b)
+	field.CondSwap(&p1.Z, &p2.Z, b)
+}
+
+// completeAdd returns p1 + p2, with the complete addition formulas for any a
+// (algorithm 1 of Renes, Costello and Batina), 12 multiplications, 3
+// multiplications by a and 2 by 3b
+func (c *curveArith) completeAdd(p1, p2 homogeneousPoint) homogeneousPoint {
+	var t0, t1, t2, t3, t4, t5, x3, y3, z3 field.Element
+	t0.Mul(&p1.X, &p2.X)
+	t1.Mul(&p1.Y, &p2.Y)
+	t2.Mul(&p1.Z, &p2.Z)
+	t3.Add(&p1.X, &p1.Y)
+	t4.Add(&p2.X, &p2.Y)
+	t3.Mul(&t3, &t4)
+	t4.Add(&t0, &t1)
+	t3.Sub(&t3, &t4)
+	t4.Add(&p1.X, &p1.Z)
+	t5.Add(&p2.X, &p2.Z)
+	t4.Mul(&t4, &t5)
+	t5.Add(&t0, &t2)
+	t4.Sub(&t4, &t5)
+	t5.Add(&p1.Y, &p1.Z)
+	x3.Add(&p2.Y, &p2.Z)
+	t5.Mul(&t5, &x3)
+	x3.Add(&t1, &t2)
+	t5.Sub(&t5, &x3)
+	z3.Mul(&c.a, &t4)
+	x3.Mul(&c.b3, &t2)
+	z3.Add(&x3, &z3)
+	x3.Sub(&t1, &z3)
+	z3.Add(&t1, &z3)
+	y3.Mul(&x3, &z3)
+	t1.Add(&t0, &t0)
+	t1.Add(&t1, &t0)
+	t2.Mul(&c.a, &t2)
+	t4.Mul(&c.b3, &t4)
+	t1.Add(&t1, &t2)
+	t2.Sub(&t0, &t2)
+	t2.Mul(&c.a, &t2)
+	t4.Add(&t4, &t2)
+	t0.Mul(&t1, &t4)
+	y3.Add(&y3, &t0)
+	t0.Mul(&t5, &t4)
+	x3.Mul(&t3, &x3)
+	x3.Sub(&x3, &t0)
+	t0.Mul(&t3, &t1)
+	z3.Mul(&t5, &z3)
+	z3.Add(&z3, &t0)
+	return homogeneousPoint{x3, y3, z3}
+}
+
+// completeDouble returns 2p, with the complete doubling formulas for any a
+// (algorithm 3 of Renes, Costello and Batina), which have no exceptions
+func (c *curveArith) completeDouble(p homogeneousPoint) homogeneousPoint {
+	var t0, t1, t2, t3, x3, y3, z3 field.Element
+	t0.Mul(&p.X, &p.X)
+	t1.Mul(&p.Y, &p.Y)
+	t2.Mul(&p.Z, &p.Z)
+	t3.Mul(&p.X, &p.Y)
+	t3.Add(&t3, &t3)
+	z3.Mul(&p.X, &p.Z)
+	z3.Add(&z3, &z3)
+	x3.Mul(&c.a, &z3)
+	y3.Mul(&c.b3, &t2)
+	y3.Add(&x3, &y3)
+	x3.Sub(&t1, &y3)
+	y3.Add(&t1, &y3)
+	y3.Mul(&x3, &y3)
+	x3.Mul(&t3, &x3)
+	z3.Mul(&c.b3, &z3)
+	t2.Mul(&c.a, &t2)
+	t3.Sub(&t0, &t2)
+	t3.Mul(&c.a, &t3)
+	t3.Add(&t3, &z3)
+	z3.Add(&t0, &t0)
+	t0.Add(&z3, &t0)
+	t0.Add(&t0, &t2)
+	t0.Mul(&t0, &t3)
+	y3.Add(&y3, &t0)
+	t2.Mul(&p.Y, &p.Z)
+	t2.Add(&t2, &t2)
+	t0.Mul(&t2, &t3)
+	x3.Sub(&x3, &t0)
+	z3.Mul(&t2, &t1)
+	z3.Add(&z3, &z3)
+	z3.Add(&z3, &z3)
+	return homogeneousPoint{x3, y3, z3}
+}
@@ -0,0 +1,159 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// Curve is the data structure for a named elliptic curve, containing the curve
+// parameters together with its generator point G, the order N of the subgroup
+// generated by G, and the cofactor H (number of points of the curve = N * H).
+// When GLV is set, MultiMul (used with public scalars) uses the GLV
+// endomorphism, and Mul uses it only when PublicMul is also set, otherwise Mul
+// uses the ladder of EC.Mul
+type Curve struct {
+	Name string
+	EC   EC
+	G    Point
+	N    *big.Int
+	H    *big.Int
+	GLV  *GLV
+	// PublicMul declares that the scalars of Mul are public (for example in
+	// verifications or benchmarks), so Mul can use the variable time MulGLV
+	PublicMul bool
+}
+
+// curves contains the constructors of the named curves, each call returns
+// fresh big.Int values, so the returned Curve can be modified by the caller
+var curves = map[string]func() Curve{
+	"secp256k1":       Secp256k1,
+	"P-256":           P256,
+	"P-384":           P384,
+	"P-521":           P521,
+	"brainpoolP256r1": BrainpoolP256r1,
+	"toy11":           Toy11,
+	"toy19":           Toy19,
+	"toy29":           Toy29,
+}
+
+// CurveByName returns the named curve with the given name
+func CurveByName(name string) (Curve, error) {
+	c, ok := curves[name]
+	if !ok {
+		return Curve{}, errors.New("unknown curve " + name)
+	}
+	return c(), nil
+}
+
+// CurveNames returns the sorted list of names of the available named curves
+func CurveNames() []string {
+	var names []string
+	for name := range curves {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Secp256k1 returns the secp256k1 curve (SEC 2, section 2.4.1)
+func Secp256k1() Curve {
+	c := newCurve("secp256k1",
+		"0",
+		"7",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		"483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8",
+		"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+		"1")
+	// the cube roots of unity with phi(G) = lambda G
+	c.GLV = newGLV(
+		hexToInt("7ae96a2b657c07106e64479eac3434e99cf0497512f58995c1396c28719501ee"),
+		hexToInt("5363ad4cc05c30e0a5261c028812645a122e22ea20816678df02967c1b23bd72"),
+		c.N)
+	return c
+}
+
+// P256 returns the NIST P-256 curve (FIPS 186-4, section D.1.2.3)
+func P256() Curve {
+	return newCurve("P-256",
+		"ffffffff00000001000000000000000000000000fffffffffffffffffffffffc",
+		"5ac635d8aa3a93e7b3ebbd55769886bc651d06b0cc53b0f63bce3c3e27d2604b",
+		"ffffffff00000001000000000000000000000000ffffffffffffffffffffffff",
+		"6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296",
+		"4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5",
+		"ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551",
+		"1")
+}
+
+// P384 returns the NIST P-384 curve (FIPS 186-4, section D.1.2.4)
+func P384() Curve {
+	return newCurve("P-384",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffff0000000000000000fffffffc",
+		"b3312fa7e23ee7e4988e056be3f82d19181d9c6efe8141120314088f5013875ac656398d8a2ed19d2a85c8edd3ec2aef",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffff0000000000000000ffffffff",
+		"aa87ca22be8b05378eb1c71ef320ad746e1d3b628ba79b9859f741e082542a385502f25dbf55296c3a545e3872760ab7",
+		"3617de4a96262c6f5d9e98bf9292dc29f8f41dbd289a147ce9da3113b5f0b8c00a60b1ce1d7e819d7a431d7c90ea0e5f",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffc7634d81f4372ddf581a0db248b0a77aecec196accc52973",
+		"1")
+}
+
+// P521 returns the NIST P-521 curve (FIPS 186-4, section D.1.2.5)
+func P521() Curve {
+	return newCurve("P-521",
+		"1fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffc",
+		"51953eb9618e1c9a1f929a21a0b68540eea2da725b99b315f3b8b489918ef109e156193951ec7e937b1652c0bd3bb1bf073573df883d2c34f1ef451fd46b503f00",
+		"1ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"c6858e06b70404e9cd9e3ecb662395b4429c648139053fb521f828af606b4d3dbaa14b5e77efe75928fe1dc127a2ffa8de3348b3c1856a429bf97e7e31c2e5bd66",
+		"11839296a789a3bc0045c8a5fb42c7d1bd998f54449579b446817afbd17273e662c97ee72995ef42640c550b9013fad0761353c7086a272c24088be94769fd16650",
+		"1fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffa51868783bf2f966b7fcc0148f709a5d03bb5c9b8899c47aebb6fb71e91386409",
+		"1")
+}
+
+// BrainpoolP256r1 returns the brainpoolP256r1 curve (RFC 5639, section 3.4)
+func BrainpoolP256r1() Curve {
+	return newCurve("brainpoolP256r1",
+		"7d5a0975fc2c3057eef67530417affe7fb8055c126dc5c6ce94a4b44f330b5d9",
+		"26dc5c6ce94a4b44f330b5d9bbd77cbf958416295cf7e1ce6bccdc18ff8c07b6",
+		"a9fb57dba1eea9bc3e660a909d838d726e3bf623d52620282013481d1f6e5377",
+		"8bd2aeb9cb7e57cb2c4b482ffc81b7afb9de27e1e3bd23c23a4453bd9ace3262",
+		"547ef835c3dac4fd97f8461a14611dc9c27745132ded8e545c1d54c72f046997",
+		"a9fb57dba1eea9bc3e660a909d838d718c397aa3b561a6f7901e0e82974856a7",
+		"1")
+}
+
+// Toy11 returns the toy curve y^2 = x^3 + 7 mod 11, with 12 points, and the
+// generator (3, 1) of the subgroup of prime order 3 (cofactor 4)
+func Toy11() Curve {
+	return newCurve("toy11", "0", "7", "b", "3", "1", "3", "4")
+}
+
+// Toy19 returns the toy curve y^2 = x^3 + x + 18 mod 19, with 19 points
+func Toy19() Curve {
+	return newCurve("toy19", "1", "12", "13", "7", "b", "13", "1")
+}
+
+// Toy29 returns the toy curve y^2 = x^3 + 7 mod 29, with 30 points, and the
+// generator (6, 7) of the subgroup of prime order 5 (cofactor 6)
+func Toy29() Curve {
+	return newCurve("toy29", "0", "7", "1d", "6", "7", "5", "6")
+}
+
+// newCurve builds a Curve from the hex encoded parameters
+func newCurve(name, a, b, q, gx, gy, n, h string) Curve {
+	return Curve{
+		Name: name,
+		EC:   NewEC(hexToInt(a), hexToInt(b), hexToInt(q)),
+		G:    Point{hexToInt(gx), hexToInt(gy)},
+		N:    hexToInt(n),
+		H:    hexToInt(h),
+	}
+}
+
+func hexToInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("ecc: invalid hex constant " + s)
+	}
+	return n
+}
@@ -0,0 +1,127 @@
+package ecc
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurveByName(t *testing.T) {
+	for _, name := range CurveNames() {
+		c, err := CurveByName(name)
+		assert.Nil(t, err)
+		assert.Equal(t, name, c.Name)
+
+		// G is on the curve: y^2 == x^3 + ax + b mod q
+		y2 := new(big.Int).Mul(c.G.Y, c.G.Y)
+		y2.Mod(y2, c.EC.Q)
+		rhs := new(big.Int).Exp(c.G.X, big.NewInt(int64(3)), nil)
+		rhs.Add(rhs, new(big.Int).Mul(c.EC.A, c.G.X))
+		rhs.Add(rhs, c.EC.B)
+		rhs.Mod(rhs, c.EC.Q)
+		assert.Equal(t, rhs, y2, name)
+
+		// N x G == O
+		nG, err := c.EC.Mul(c.G, c.N)
+		assert.Nil(t, err)
+		assert.True(t, nG.Equal(ZeroPoint), name)
+	}
+
+	_, err := CurveByName("secp256r2")
+	assert.NotNil(t, err)
+}
+
+func TestCurveReturnsFreshValues(t *testing.T) {
+	c := Secp256k1()
+	c.N.SetInt64(int64(0))
+	c.G.X.SetInt64(int64(0))
+	c2 := Secp256k1()
+	assert.NotEqual(t, int64(0), c2.N.Int64())
+	assert.NotEqual(t, int64(0), c2.G.X.Int64())
+}
+
+func TestToyCurvesOrder(t *testing.T) {
+	for _, c := range []Curve{Toy11(), Toy19(), Toy29()} {
+		order, err := c.EC.Order(c.G)
+		assert.Nil(t, err)
+		assert.Equal(t, c.N, order, c.Name)
+
+		// count the points of the curve, including the point at infinity
+		q := c.EC.Q.Int64()
+		count := int64(1)
+		for x := int64(0); x < q; x++ {
+			for y := int64(0); y < q; y++ {
+				if (y*y-(x*x*x+c.EC.A.Int64()*x+c.EC.B.Int64()))%q == 0 {
+					count++
+				}
+			}
+		}
+		assert.Equal(t, count, new(big.Int).Mul(c.N, c.H).Int64(), c.Name)
+	}
+}
+
+func TestNISTCurvesParams(t *testing.T) {
+	for _, ec := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		params := ec.Params()
+		c, err := CurveByName(params.Name)
+		assert.Nil(t, err)
+		assert.Equal(t, params.P, c.EC.Q)
+		assert.Equal(t, params.B, c.EC.B)
+		assert.Equal(t, new(big.Int).Sub(params.P, big.NewInt(int64(3))), c.EC.A)
+		assert.Equal(t, params.Gx, c.G.X)
+		assert.Equal(t, params.Gy, c.G.Y)
+		assert.Equal(t, params.N, c.N)
+	}
+}
+
+func TestCurveGroup(t *testing.T) {
+	c := P256()
+	a, err := c.Mul(c.G, big.NewInt(int64(3)))
+	assert.Nil(t, err)
+	b, err := c.Add(c.G, c.G)
+	assert.Nil(t, err)
+	b, err = c.Add(b, c.G)
+	assert.Nil(t, err)
+	assert.True(t, c.Equal(a, b))
+
+	// the scalar is reduced mod N
+	a2, err := c.Mul(c.G, new(big.Int).Add(c.N, big.NewInt(int64(3))))
+	assert.Nil(t, err)
+	assert.True(t, c.Equal(a, a2))
+
+	aNeg, err := c.Neg(a)
+	assert.Nil(t, err)
+	id, err := c.Add(a, aNeg)
+	assert.Nil(t, err)
+	assert.True(t, c.Equal(id, c.Identity()))
+
+	x, err := c.Int(a)
+	assert.Nil(t, err)
+	assert.Equal(t, a.(Point).X, x)
+	_, err = c.Int(c.Identity())
+	assert.NotNil(t, err)
+
+	enc, err := c.Marshal(a)
+	assert.Nil(t, err)
+	a3, err := c.Unmarshal(enc)
+	assert.Nil(t, err)
+	assert.True(t, c.Equal(a, a3))
+
+	assert.NotNil(t, c.Validate(big.NewInt(int64(3))))
+	_, err = c.Add(a, big.NewInt(int64(3)))
+	assert.NotNil(t, err)
+	assert.False(t, c.Equal(a, big.NewInt(int64(3))))
+
+	// with unknown cofactor, the subgroup membership is checked: the subgroup
+	// of order 15 of toy29 does not contain the generator (5, 4) of the full
+	// group
+	t29 := Toy29()
+	g30 := Point{X: big.NewInt(int64(5)), Y: big.NewInt(int64(4))}
+	g2, err := t29.EC.Mul(g30, big.NewInt(int64(2)))
+	assert.Nil(t, err)
+	sub := Curve{EC: t29.EC, G: g2, N: big.NewInt(int64(15))}
+	assert.Nil(t, sub.Validate(g2))
+	assert.NotNil(t, sub.Validate(g30))
+}
@@ -1,7 +1,6 @@
 package ecc
 
 import (
-	"bytes"
 	"errors"
 	"math/big"
 )
@@ -21,132 +20,169 @@ func NewEC(a, b, q *big.Int) (ec EC) {
 	return ec
 }
 
-// At gets a point x in the curve
+// At gets the two points of the curve with the given x, (x, y) and (x, q - y),
+// it returns an error when x is out of the field or when there is no point at x
+// (x^3 + ax + b is not a square mod q). When y = 0 both points are the same
 func (ec *EC) At(x *big.Int) (Point, Point, error) {
-	if x.Cmp(ec.Q) > 0 {
-		return Point{}, Point{}, errors.New("x<ec.Q")
+	if x.Sign() < 0 || x.Cmp(ec.Q) >= 0 {
+		return Point{}, Point{}, errors.New("x out of the field: x must be in [0, q)")
 	}
-	// y^2 = (x^3 + ax + b) mod q
 	// y = sqrt (x^3 + ax + b) mod q
-	// x^3
-	x3 := new(big.Int).Exp(x, big.NewInt(int64(3)), nil)
-	// a^x
-	aX := new(big.Int).Mul(ec.A, x)
-	// x^3 + a^x
-	x3aX := new(big.Int).Add(x3, aX)
-	// x^3 + a^x + b
-	x3aXb := new(big.Int).Add(x3aX, ec.B)
-	// y = sqrt (x^3 + ax + b) mod q
-	y := new(big.Int).ModSqrt(x3aXb, ec.Q)
-	return Point{x, y}, Point{x, new(big.Int).Sub(ec.Q, y)}, nil
+	y, err := ModSqrt(curveRHS(*ec, x), ec.Q)
+	if err != nil {
+		return Point{}, Point{}, errors.New("no point at x = " + x.String())
+	}
+	yNeg := new(big.Int).Sub(ec.Q, y)
+	return Point{x, y}, Point{x, yNeg.Mod(yNeg, ec.Q)}, nil
 }
 
-// TODO add valid checker point function Valid()
+// IsOnCurve returns true if the point p satisfies the curve equation and its
+// coordinates are in [0, q). The point at infinity is considered on the curve
+func (ec *EC) IsOnCurve(p Point) bool {
+	if p.X == nil || p.Y == nil {
+		return false
+	}
+	if p.Equal(ZeroPoint) {
+		return true
+	}
+	if p.X.Sign() < 0 || p.X.Cmp(ec.Q) >= 0 || p.Y.Sign() < 0 || p.Y.Cmp(ec.Q) >= 0 {
+		return false
+	}
+	// y^2 == x^3 + ax + b mod q
+	y2 := new(big.Int).Mul(p.Y, p.Y)
+	y2.Mod(y2, ec.Q)
+	f := new(big.Int).Mul(p.X, p.X)
+	f.Add(f, ec.A)
+	f.Mul(f, p.X)
+	f.Add(f, ec.B)
+	f.Mod(f, ec.Q)
+	return y2.Cmp(f) == 0
+}
 
-// Neg returns the inverse of the P point on the elliptic curve
-func (ec *EC) Neg(p Point) Point {
-	// TODO get error when point not found on the ec
-	return Point{p.X, new(big.Int).Sub(ec.Q, p.Y)}
+// Validate returns an error if the point p is not a point of the elliptic curve
+func (ec *EC) Validate(p Point) error {
+	if p.X == nil || p.Y == nil {
+		return errors.New("invalid point: nil coordinate")
+	}
+	if !ec.IsOnCurve(p) {
+		return errors.New("invalid point: " + p.String() + " not on the curve")
+	}
+	return nil
 }
 
-// Add adds two points p1 and p2 and gets q, returns the negate of q
-func (ec *EC) Add(p1, p2 Point) (Point, error) {
-	if p1.Equal(ZeroPoint) {
-		return p2, nil
+// InSubgroup returns true if the point p is on the elliptic curve and belongs
+// to the subgroup of order n, that is nP = O
+func (ec *EC) InSubgroup(p Point, n *big.Int) bool {
+	if !ec.IsOnCurve(p) {
+		return false
 	}
-	if p2.Equal(ZeroPoint) {
-		return p1, nil
+	nP, err := ec.Mul(p, n)
+	if err != nil {
+		return false
 	}
+	return nP.Equal(ZeroPoint)
+}
 
-	var numerator, denominator, sRaw, s *big.Int
-	if bytes.Equal(p1.X.Bytes(), p2.X.Bytes()) && (!bytes.Equal(p1.Y.Bytes(), p2.Y.Bytes()) || bytes.Equal(p1.Y.Bytes(), BigZero.Bytes())) {
+// Neg returns the inverse of the P point on the elliptic curve
+func (ec *EC) Neg(p Point) (Point, error) {
+	if err := ec.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.Equal(ZeroPoint) {
 		return ZeroPoint, nil
-	} else if bytes.Equal(p1.X.Bytes(), p2.X.Bytes()) {
-		// use tangent as slope
-		// x^2
-		x2 := new(big.Int).Mul(p1.X, p1.X)
-		// 3 * x^2
-		x23 := new(big.Int).Mul(big.NewInt(int64(3)), x2)
-		// 3 * x^2 + a
-		numerator = new(big.Int).Add(x23, ec.A)
-		// 2 * y
-		denominator = new(big.Int).Mul(big.NewInt(int64(2)), p1.Y)
-		// s = (3 * x^2 + a) / (2 * y) mod ec.Q
-		denInv := new(big.Int).ModInverse(denominator, ec.Q)
-		sRaw = new(big.Int).Mul(numerator, denInv)
-		s = new(big.Int).Mod(sRaw, ec.Q)
-	} else {
-		// slope
-		// y0-y1
-		numerator = new(big.Int).Sub(p1.Y, p2.Y)
-		// x0-x1
-		denominator = new(big.Int).Sub(p1.X, p2.X)
-		// s = (y0-y1) / (x0-x1) mod ec.Q
-		denInv := new(big.Int).ModInverse(denominator, ec.Q)
-		sRaw = new(big.Int).Mul(numerator, denInv)
-		s = new(big.Int).Mod(sRaw, ec.Q)
 	}
+	y := new(big.Int).Sub(ec.Q, p.Y)
+	return Point{p.X, y.Mod(y, ec.Q)}, nil
+}
 
-	// q: new point
-	var q Point
-	// s^2
-	s2 := new(big.Int).Exp(s, big.NewInt(int64(2)), nil)
-	// s^2 - p1.X
-	x2Xo := new(big.Int).Sub(s2, p1.X)
-	// s^2 - p1.X - p2.X
-	x2XoX2 := new(big.Int).Sub(x2Xo, p2.X)
-	q.X = new(big.Int).Mod(x2XoX2, ec.Q)
-
-	// p1.X - q.X
-	xoX2 := new(big.Int).Sub(p1.X, q.X)
-	// s(p1.X - q.X)
-	sXoX2 := new(big.Int).Mul(s, xoX2)
-	// s(p1.X - q.X) - p1.Y
-	sXoX2Y := new(big.Int).Sub(sXoX2, p1.Y)
-	// q.Y = (s(p1.X - q.X) - p1.Y) mod ec.Q
-	q.Y = new(big.Int).Mod(sXoX2Y, ec.Q)
-
-	// negate q
-	// q = ec.Neg(q)
-	return q, nil
+// Add adds two points p1 and p2 and gets q, with the complete addition formulas
+func (ec *EC) Add(p1, p2 Point) (Point, error) {
+	if err := ec.Validate(p1); err != nil {
+		return Point{}, err
+	}
+	if err := ec.Validate(p2); err != nil {
+		return Point{}, err
+	}
+	c, err := ec.arith()
+	if err != nil {
+		return Point{}, err
+	}
+	r := c.completeAdd(c.toHomogeneous(p1), c.toHomogeneous(p2))
+	if r.isExceptional() {
+		// p1 - p2 has order 2, only over the curves with points of order 2
+		return c.toAffine(c.add(c.toJacobian(p1), c.toJacobian(p2))), nil
+	}
+	return c.homogeneousToAffine(r, false), nil
 }
 
-// Mul multiplies a point n times on the elliptic curve
+// Mul multiplies a point n times on the elliptic curve, n must not be negative.
+// It uses the Montgomery ladder with the complete formulas and conditional
+// swaps: for each bit of n it performs one addition and one doubling, with the
+// same field operations for any value of the bit (there are no branches on the
+// bits of n nor on the point at infinity). The number of iterations is the bit
+// length of q plus one, or the bit length of n when it is bigger. The big.Int
+// conversions of the inputs and of the result are not constant time. Neither p
+// nor n are modified
 func (ec *EC) Mul(p Point, n *big.Int) (Point, error) {
-	var err error
-	p2 := p
-	r := ZeroPoint
-	for BigZero.Cmp(n) == -1 { // 0<n
-		z := new(big.Int).And(n, BigOne)            // n&1
-		if bytes.Equal(z.Bytes(), BigOne.Bytes()) { // n&1==1
-			r, err = ec.Add(r, p2)
-			if err != nil {
-				return p, err
-			}
-		}
-		n = n.Rsh(n, 1) // n = n>>1
-		p2, err = ec.Add(p2, p2)
-		if err != nil {
-			return p, err
+	if err := ec.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if n.Sign() < 0 {
+		return Point{}, errors.New("the scalar must not be negative")
+	}
+	c, err := ec.arith()
+	if err != nil {
+		return Point{}, err
+	}
+	if !p.Equal(ZeroPoint) && p.Y.Sign() == 0 {
+		// p has order 2, the only case where the difference of the points of
+		// the ladder is an exception of the complete formulas
+		if n.Bit(0) == 0 {
+			return ZeroPoint, nil
 		}
+		return p, nil
 	}
-	return r, nil
+	bits := ec.Q.BitLen() + 1
+	if n.BitLen() > bits {
+		bits = n.BitLen()
+	}
+	// r0 = kP, r1 = (k+1)P, where k is the scalar formed by the processed bits
+	r0, r1 := c.homogeneousInfinity(), c.toHomogeneous(p)
+	var swap uint64
+	for i := bits - 1; i >= 0; i-- {
+		b := uint64(n.Bit(i))
+		c.condSwap(&r0, &r1, swap^b)
+		swap = b
+		r1 = c.completeAdd(r0, r1)
+		r0 = c.completeDouble(r0)
+	}
+	c.condSwap(&r0, &r1, swap)
+	return c.homogeneousToAffine(r0, true), nil
 }
 
-// Order returns smallest n where nG = O (point at zero)
+// Order returns smallest n where nG = O (point at zero). A multiple of the order
+// is obtained with a baby-step giant-step search over the Hasse interval (or
+// from the number of points of the curve for big fields), and then it is reduced
+// using its factorization. It takes less than a second up to ~80 bits, about 2s
+// for 128 bits and 30s for 256 bits (see Cardinality), and it fails when the
+// number of points has more than one prime factor of over ~40 bits that is
+// needed by the order
 func (ec *EC) Order(g Point) (*big.Int, error) {
-	// loop from i:=1 to i<ec.Q+1
-	start := big.NewInt(1)
-	end := new(big.Int).Add(ec.Q, BigOne)
-	for i := new(big.Int).Set(start); i.Cmp(end) <= 0; i.Add(i, BigOne) {
-		iCopy := new(big.Int).SetBytes(i.Bytes())
-		mPoint, err := ec.Mul(g, iCopy)
-		if err != nil {
-			return i, err
-		}
-		if mPoint.Equal(ZeroPoint) {
-			return i, nil
-		}
+	if err := ec.Validate(g); err != nil {
+		return BigZero, err
+	}
+	if g.Equal(ZeroPoint) {
+		return big.NewInt(int64(1)), nil
+	}
+	var m *big.Int
+	var err error
+	if ec.Q.BitLen() <= bsgsMaxBits {
+		m, err = ec.orderMultiple(g)
+	} else {
+		m, err = ec.Cardinality()
+	}
+	if err != nil {
+		return BigZero, err
 	}
-	return BigZero, errors.New("invalid order")
+	return ec.reduceOrder(g, m)
 }
@@ -24,7 +24,8 @@ func TestNeg(t *testing.T) {
 	p1, p1i, err := ec.At(big.NewInt(int64(7)))
 	assert.Nil(t, err)
 
-	p1Neg := ec.Neg(p1)
+	p1Neg, err := ec.Neg(p1)
+	assert.Nil(t, err)
 	if !p1Neg.Equal(p1i) {
 		t.Errorf("p1Neg!=p1i")
 	}
@@ -61,14 +62,14 @@ func TestAddSamePoint(t *testing.T) {
 	assert.Nil(t, err)
 
 	if !q.Equal(Point{big.NewInt(int64(6)), big.NewInt(int64(5))}) {
-		t.Errorf(q.String() + " == q != (6, 5)")
+		t.Error(q.String() + " == q != (6, 5)")
 	}
 
 	q_, err := ec.Add(p1i, p1i)
 	assert.Nil(t, err)
 
 	if !q_.Equal(Point{big.NewInt(int64(6)), big.NewInt(int64(6))}) {
-		t.Errorf(q_.String() + " == q_ != (6, 6)")
+		t.Error(q_.String() + " == q_ != (6, 6)")
 	}
 
 }
@@ -81,28 +82,28 @@ func TestMulPoint1(t *testing.T) {
 	assert.Nil(t, err)
 
 	if !q.Equal(Point{big.NewInt(int64(11)), big.NewInt(int64(27))}) {
-		t.Errorf(q.String() + " == q != (11, 27)")
+		t.Error(q.String() + " == q != (11, 27)")
 	}
 
 	q, err = ec.Mul(p, big.NewInt(int64(2)))
 	assert.Nil(t, err)
 
 	if !q.Equal(Point{big.NewInt(int64(12)), big.NewInt(int64(13))}) {
-		t.Errorf(q.String() + " == q != (12, 13)")
+		t.Error(q.String() + " == q != (12, 13)")
 	}
 
 	q, err = ec.Mul(p, big.NewInt(int64(3)))
 	assert.Nil(t, err)
 
 	if !q.Equal(Point{big.NewInt(int64(28)), big.NewInt(int64(8))}) {
-		t.Errorf(q.String() + " == q != (28, 8)")
+		t.Error(q.String() + " == q != (28, 8)")
 	}
 
 	q, err = ec.Mul(p, big.NewInt(int64(4)))
 	assert.Nil(t, err)
 
 	if !q.Equal(Point{big.NewInt(int64(6)), big.NewInt(int64(22))}) {
-		t.Errorf(q.String() + " == q != (6, 22)")
+		t.Error(q.String() + " == q != (6, 22)")
 	}
 }
 
@@ -113,20 +114,20 @@ func TestMulPoint2(t *testing.T) {
 	assert.Nil(t, err)
 
 	if !q3.Equal(Point{big.NewInt(int64(6)), big.NewInt(int64(7))}) {
-		t.Errorf(q3.String() + " == q3 != (6, 7)")
+		t.Error(q3.String() + " == q3 != (6, 7)")
 	}
 	q7, err := ec.Mul(p1, big.NewInt(int64(7)))
 	assert.Nil(t, err)
 
 	if !q7.Equal(Point{big.NewInt(int64(19)), big.NewInt(int64(14))}) {
-		t.Errorf(q7.String() + " == q7 != (19, 14)")
+		t.Error(q7.String() + " == q7 != (19, 14)")
 	}
 
 	q8, err := ec.Mul(p1, big.NewInt(int64(8)))
 	assert.Nil(t, err)
 
 	if !q8.Equal(Point{big.NewInt(int64(19)), big.NewInt(int64(15))}) {
-		t.Errorf(q8.String() + " == q8 != (12, 16)")
+		t.Error(q8.String() + " == q8 != (12, 16)")
 	}
 }
 
@@ -138,13 +139,13 @@ func TestMulPoint3(t *testing.T) {
 	q, err := ec.Mul(p, big.NewInt(int64(100)))
 	assert.Nil(t, err)
 	if !q.Equal(Point{big.NewInt(int64(3)), big.NewInt(int64(1))}) {
-		t.Errorf(q.String() + " == q != (3, 1)")
+		t.Error(q.String() + " == q != (3, 1)")
 	}
 
 	q, err = ec.Mul(p, big.NewInt(int64(100)))
 	assert.Nil(t, err)
 	if !q.Equal(Point{big.NewInt(int64(3)), big.NewInt(int64(1))}) {
-		t.Errorf(q.String() + " == q != (3, 1)")
+		t.Error(q.String() + " == q != (3, 1)")
 	}
 }
 
@@ -163,10 +164,10 @@ func TestMulEqualSelfAdd(t *testing.T) {
 	assert.Nil(t, err)
 
 	if !q.Equal(Point{big.NewInt(int64(28)), big.NewInt(int64(8))}) {
-		t.Errorf(q.String() + " == q != (28, 8)")
+		t.Error(q.String() + " == q != (28, 8)")
 	}
 	if !q.Equal(p1_3) {
-		t.Errorf("p*3 == " + q.String() + ", p+p+p == " + p1_3.String())
+		t.Error("p*3 == " + q.String() + ", p+p+p == " + p1_3.String())
 	}
 
 	// q * 4
@@ -177,10 +178,10 @@ func TestMulEqualSelfAdd(t *testing.T) {
 	assert.Nil(t, err)
 
 	if !q.Equal(Point{big.NewInt(int64(6)), big.NewInt(int64(22))}) {
-		t.Errorf(q.String() + " == q != (6, 22)")
+		t.Error(q.String() + " == q != (6, 22)")
 	}
 	if !q.Equal(p1_4) {
-		t.Errorf("p*4 == " + q.String() + ", p+p+p+p == " + p1_4.String())
+		t.Error("p*4 == " + q.String() + ", p+p+p+p == " + p1_4.String())
 	}
 }
 
@@ -210,3 +211,269 @@ func TestOrder(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, order.Int64(), int64(30))
 }
+
+func TestMulJacobianEqualAffine(t *testing.T) {
+	c := Secp256k1()
+	k, _ := new(big.Int).SetString("123456789012345678901234567890123456789", 10)
+	q, err := c.EC.Mul(c.G, k)
+	assert.Nil(t, err)
+	assert.True(t, q.Equal(mulAffine(&c.EC, c.G, k)))
+
+	// add, double, and add of opposite points
+	p2, err := c.EC.Add(c.G, c.G)
+	assert.Nil(t, err)
+	assert.True(t, p2.Equal(addAffine(&c.EC, c.G, c.G)))
+	p3, err := c.EC.Add(p2, c.G)
+	assert.Nil(t, err)
+	assert.True(t, p3.Equal(addAffine(&c.EC, p2, c.G)))
+	gNeg, err := c.EC.Neg(c.G)
+	assert.Nil(t, err)
+	o, err := c.EC.Add(c.G, gNeg)
+	assert.Nil(t, err)
+	assert.True(t, o.Equal(ZeroPoint))
+}
+
+func TestCompleteFormulas(t *testing.T) {
+	// y^2 = x^3 + 7 mod 11 has 12 points, with a point of order 2, and
+	// y^2 = x^3 + 2x + 3 mod 97 has 100 points
+	for _, ec := range []EC{
+		NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11))),
+		NewEC(big.NewInt(int64(2)), big.NewInt(int64(3)), big.NewInt(int64(97))),
+		NewEC(big.NewInt(int64(-3)), big.NewInt(int64(5)), big.NewInt(int64(101))),
+	} {
+		points := []Point{ZeroPoint}
+		for x := int64(0); x < ec.Q.Int64(); x++ {
+			p1, p2, err := ec.At(big.NewInt(x))
+			if err == nil {
+				points = append(points, p1)
+				if !p1.Equal(p2) {
+					points = append(points, p2)
+				}
+			}
+		}
+		c, err := ec.arith()
+		assert.Nil(t, err)
+		for _, p1 := range points {
+			d := c.homogeneousToAffine(c.completeDouble(c.toHomogeneous(p1)), false)
+			assert.True(t, d.Equal(addAffine(&ec, p1, p1)), "2 %s", p1.String())
+			for _, p2 := range points {
+				expected := addAffine(&ec, p1, p2)
+				q, err := ec.Add(p1, p2)
+				assert.Nil(t, err)
+				assert.True(t, q.Equal(expected), "%s + %s", p1.String(), p2.String())
+				r := c.completeAdd(c.toHomogeneous(p1), c.toHomogeneous(p2))
+				if !r.isExceptional() {
+					q = c.homogeneousToAffine(r, true)
+					assert.True(t, q.Equal(expected))
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	c := Secp256k1()
+	k := new(big.Int).Sub(c.N, big.NewInt(int64(12345)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.EC.Mul(c.G, k)
+	}
+}
+
+func BenchmarkMulAffine(b *testing.B) {
+	c := Secp256k1()
+	k := new(big.Int).Sub(c.N, big.NewInt(int64(12345)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mulAffine(&c.EC, c.G, k)
+	}
+}
+
+// mulAffine is the double-and-add multiplication in affine coordinates, with a
+// modular inversion at each step, used as reference
+func mulAffine(ec *EC, p Point, n *big.Int) Point {
+	r := ZeroPoint
+	for i := n.BitLen() - 1; i >= 0; i-- {
+		r = addAffine(ec, r, r)
+		if n.Bit(i) == 1 {
+			r = addAffine(ec, r, p)
+		}
+	}
+	return r
+}
+
+func addAffine(ec *EC, p1, p2 Point) Point {
+	if p1.Equal(ZeroPoint) {
+		return p2
+	}
+	if p2.Equal(ZeroPoint) {
+		return p1
+	}
+	var s *big.Int
+	if p1.X.Cmp(p2.X) == 0 {
+		if p1.Y.Cmp(p2.Y) != 0 || p1.Y.Sign() == 0 {
+			return ZeroPoint
+		}
+		// s = (3 * x^2 + a) / (2 * y)
+		s = new(big.Int).Mul(p1.X, p1.X)
+		s.Mul(s, big.NewInt(int64(3)))
+		s.Add(s, ec.A)
+		s.Mul(s, new(big.Int).ModInverse(new(big.Int).Lsh(p1.Y, 1), ec.Q))
+	} else {
+		// s = (y1 - y2) / (x1 - x2)
+		s = new(big.Int).Sub(p1.Y, p2.Y)
+		d := new(big.Int).Sub(p1.X, p2.X)
+		s.Mul(s, new(big.Int).ModInverse(d.Mod(d, ec.Q), ec.Q))
+	}
+	s.Mod(s, ec.Q)
+	x := new(big.Int).Mul(s, s)
+	x.Sub(x, p1.X)
+	x.Sub(x, p2.X)
+	x.Mod(x, ec.Q)
+	y := new(big.Int).Sub(p1.X, x)
+	y.Mul(y, s)
+	y.Sub(y, p1.Y)
+	y.Mod(y, ec.Q)
+	return Point{x, y}
+}
+
+func TestMulDoesNotModifyInputs(t *testing.T) {
+	c := P256()
+	k, _ := new(big.Int).SetString("98765432109876543210987654321098765432109876543210", 10)
+	kCopy := new(big.Int).Set(k)
+	gx := new(big.Int).Set(c.G.X)
+	gy := new(big.Int).Set(c.G.Y)
+
+	q1, err := c.EC.Mul(c.G, k)
+	assert.Nil(t, err)
+	q2, err := c.EC.Mul(c.G, k)
+	assert.Nil(t, err)
+	assert.Equal(t, kCopy, k)
+	assert.Equal(t, gx, c.G.X)
+	assert.Equal(t, gy, c.G.Y)
+	assert.True(t, q1.Equal(q2))
+	assert.True(t, q1.Equal(mulAffine(&c.EC, c.G, k)))
+}
+
+func TestMulLadder(t *testing.T) {
+	ec := NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(29)))
+	p := Point{big.NewInt(int64(23)), big.NewInt(int64(9))}
+	// scalars smaller, equal and bigger than the order of p, and bigger than q
+	for k := int64(0); k < 70; k++ {
+		q, err := ec.Mul(p, big.NewInt(k))
+		assert.Nil(t, err)
+		assert.True(t, q.Equal(mulAffine(&ec, p, big.NewInt(k))), "k=%d", k)
+	}
+	k, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	q, err := ec.Mul(p, k)
+	assert.Nil(t, err)
+	assert.True(t, q.Equal(mulAffine(&ec, p, k)))
+
+	_, err = ec.Mul(p, big.NewInt(int64(-3)))
+	assert.NotNil(t, err)
+
+	// (5, 0) has order 2 on y^2 = x^3 + 7 mod 11
+	ec = NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11)))
+	p = Point{big.NewInt(int64(5)), big.NewInt(int64(0))}
+	for k := int64(0); k < 5; k++ {
+		q, err := ec.Mul(p, big.NewInt(k))
+		assert.Nil(t, err)
+		assert.True(t, q.Equal(mulAffine(&ec, p, big.NewInt(k))), "k=%d", k)
+	}
+}
+
+func TestMulArithCacheKey(t *testing.T) {
+	// the bytes of q and a of the two curves are the same when concatenated
+	ec1 := NewEC(big.NewInt(int64(0x112f3105)), big.NewInt(int64(7)), big.NewInt(int64(11)))
+	ec2 := NewEC(big.NewInt(int64(5)), big.NewInt(int64(3)), big.NewInt(int64(187642129)))
+	p1 := Point{big.NewInt(int64(2)), big.NewInt(int64(2))}
+	p2 := Point{big.NewInt(int64(1)), big.NewInt(int64(3))}
+	k := big.NewInt(int64(1234567))
+	q1, err := ec1.Mul(p1, k)
+	assert.Nil(t, err)
+	assert.True(t, q1.Equal(mulAffine(&ec1, p1, k)))
+	q2, err := ec2.Mul(p2, k)
+	assert.Nil(t, err)
+	assert.True(t, q2.Equal(mulAffine(&ec2, p2, k)))
+}
+
+func TestBigFieldCurve(t *testing.T) {
+	// q = 2^640 + 115 is bigger than field.MaxBits, the operations use the
+	// big.Int field elements
+	q := new(big.Int).Lsh(big.NewInt(int64(1)), 640)
+	q.Add(q, big.NewInt(int64(115)))
+	ec := NewEC(big.NewInt(int64(-3)), big.NewInt(int64(5)), q)
+	var p Point
+	for x := int64(1); ; x++ {
+		var err error
+		if p, _, err = ec.At(big.NewInt(x)); err == nil {
+			break
+		}
+	}
+	k, _ := new(big.Int).SetString("123456789012345678901234567890123456789", 10)
+	r, err := ec.Mul(p, k)
+	assert.Nil(t, err)
+	assert.True(t, r.Equal(mulAffine(&ec, p, k)))
+
+	p2, err := ec.Add(p, p)
+	assert.Nil(t, err)
+	assert.True(t, p2.Equal(addAffine(&ec, p, p)))
+	p3, err := ec.Add(p2, p)
+	assert.Nil(t, err)
+	r, err = ec.Mul(p, big.NewInt(int64(3)))
+	assert.Nil(t, err)
+	assert.True(t, r.Equal(p3))
+	r, err = ec.MultiMul([]Point{p, p2}, []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.Nil(t, err)
+	assert.True(t, r.Equal(p3))
+}
+
+func TestValidate(t *testing.T) {
+	ec := NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11)))
+	p := Point{big.NewInt(int64(7)), big.NewInt(int64(8))}
+	assert.True(t, ec.IsOnCurve(p))
+	assert.True(t, ec.IsOnCurve(ZeroPoint))
+	assert.Nil(t, ec.Validate(p))
+
+	invalid := []Point{
+		{big.NewInt(int64(7)), big.NewInt(int64(7))},
+		{big.NewInt(int64(18)), big.NewInt(int64(8))},
+		{big.NewInt(int64(-4)), big.NewInt(int64(8))},
+		{nil, big.NewInt(int64(8))},
+		{},
+	}
+	for _, q := range invalid {
+		assert.False(t, ec.IsOnCurve(q))
+		assert.NotNil(t, ec.Validate(q))
+
+		_, err := ec.Add(p, q)
+		assert.NotNil(t, err)
+		_, err = ec.Add(q, p)
+		assert.NotNil(t, err)
+		_, err = ec.Mul(q, big.NewInt(int64(3)))
+		assert.NotNil(t, err)
+		_, err = ec.Neg(q)
+		assert.NotNil(t, err)
+		_, err = ec.Order(q)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestInSubgroup(t *testing.T) {
+	// toy29 has 30 points, (5, 4) is a generator of the full group and G
+	// generates the subgroup of order 5
+	c := Toy29()
+	g30 := Point{X: big.NewInt(int64(5)), Y: big.NewInt(int64(4))}
+	assert.True(t, c.EC.InSubgroup(c.G, c.N))
+	assert.False(t, c.EC.InSubgroup(c.G, big.NewInt(int64(3))))
+	assert.False(t, c.EC.InSubgroup(g30, big.NewInt(int64(15))))
+	g2, err := c.EC.Mul(g30, big.NewInt(int64(2)))
+	assert.Nil(t, err)
+	assert.True(t, c.EC.InSubgroup(g2, big.NewInt(int64(15))))
+	assert.False(t, c.EC.InSubgroup(Point{big.NewInt(int64(1)), big.NewInt(int64(1))}, c.N))
+
+	// with a cofactor, the Curve validation rejects the points out of the subgroup
+	assert.Nil(t, c.Validate(c.G))
+	assert.NotNil(t, c.Validate(g30))
+	assert.NotNil(t, c.Validate(g2))
+}
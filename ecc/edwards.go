@@ -0,0 +1,357 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+// TwistedEdwards is the data structure for the twisted Edwards curve
+// (a x^2 + y^2 = 1 + d x^2 y^2) mod q. The neutral element is the point (0, 1)
+type TwistedEdwards struct {
+	A *big.Int
+	D *big.Int
+	Q *big.Int
+}
+
+// NewTwistedEdwards defines a new twisted Edwards curve
+// (a x^2 + y^2 = 1 + d x^2 y^2) mod q, where q is a prime number
+func NewTwistedEdwards(a, d, q *big.Int) (te TwistedEdwards) {
+	te.A = a
+	te.D = d
+	te.Q = q
+	return te
+}
+
+// EdwardsCurve is the data structure for a named twisted Edwards curve, with
+// its generator point G, the order N of G and the cofactor H
+type EdwardsCurve struct {
+	Name string
+	TE   TwistedEdwards
+	G    Point
+	N    *big.Int
+	H    *big.Int
+}
+
+// Edwards25519 returns the edwards25519 curve (RFC 8032, section 5.1), with
+// a = -1 and d = -121665/121666
+func Edwards25519() EdwardsCurve {
+	q := hexToInt("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed")
+	return EdwardsCurve{
+		Name: "edwards25519",
+		TE: NewTwistedEdwards(
+			new(big.Int).Sub(q, BigOne),
+			hexToInt("52036cee2b6ffe738cc740797779e89800700a4d4141d8ab75eb4dca135978a3"),
+			q),
+		G: Point{
+			hexToInt("216936d3cd6e53fec0a4e231fdd6dc5c692cc7609525a7b2c9562d608f25d51a"),
+			hexToInt("6666666666666666666666666666666666666666666666666666666666666658"),
+		},
+		N: hexToInt("1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed"),
+		H: big.NewInt(int64(8)),
+	}
+}
+
+// Identity returns the neutral element (0, 1)
+func (te *TwistedEdwards) Identity() Point {
+	return Point{big.NewInt(int64(0)), big.NewInt(int64(1))}
+}
+
+// IsOnCurve returns true if the point p satisfies the curve equation and its
+// coordinates are in [0, q)
+func (te *TwistedEdwards) IsOnCurve(p Point) bool {
+	if p.X == nil || p.Y == nil {
+		return false
+	}
+	if p.X.Sign() < 0 || p.X.Cmp(te.Q) >= 0 || p.Y.Sign() < 0 || p.Y.Cmp(te.Q) >= 0 {
+		return false
+	}
+	// a x^2 + y^2 == 1 + d x^2 y^2 mod q
+	x2 := new(big.Int).Mul(p.X, p.X)
+	y2 := new(big.Int).Mul(p.Y, p.Y)
+	l := new(big.Int).Mul(te.A, x2)
+	l.Add(l, y2)
+	l.Mod(l, te.Q)
+	r := new(big.Int).Mul(te.D, x2)
+	r.Mul(r, y2)
+	r.Add(r, BigOne)
+	r.Mod(r, te.Q)
+	return l.Cmp(r) == 0
+}
+
+// Validate returns an error if the point p is not a point of the curve
+func (te *TwistedEdwards) Validate(p Point) error {
+	if p.X == nil || p.Y == nil {
+		return errors.New("invalid point: nil coordinate")
+	}
+	if !te.IsOnCurve(p) {
+		return errors.New("invalid point: " + p.String() + " not on the curve")
+	}
+	return nil
+}
+
+// Neg returns the inverse of the point p, (-x, y)
+func (te *TwistedEdwards) Neg(p Point) (Point, error) {
+	if err := te.Validate(p); err != nil {
+		return Point{}, err
+	}
+	x := new(big.Int).Sub(te.Q, p.X)
+	return Point{x.Mod(x, te.Q), new(big.Int).Set(p.Y)}, nil
+}
+
+// Add adds two points p1 and p2. The addition law of the twisted Edwards curves
+// is unified, the same formula is used for the doubling, and it is complete
+// (without exceptions) when a is a square and d is not, as in edwards25519
+func (te *TwistedEdwards) Add(p1, p2 Point) (Point, error) {
+	if err := te.Validate(p1); err != nil {
+		return Point{}, err
+	}
+	if err := te.Validate(p2); err != nil {
+		return Point{}, err
+	}
+	q, err := te.toAffine(te.projectiveAdd(te.toProjective(p1), te.toProjective(p2)))
+	if err != nil {
+		return Point{}, err
+	}
+	return q, nil
+}
+
+// Mul multiplies a point n times on the curve, using the Montgomery ladder over
+// the projective coordinates. Neither p nor n are modified
+func (te *TwistedEdwards) Mul(p Point, n *big.Int) (Point, error) {
+	if err := te.Validate(p); err != nil {
+		return Point{}, err
+	}
+	bits := te.Q.BitLen() + 1
+	if n.BitLen() > bits {
+		bits = n.BitLen()
+	}
+	r := [2]projectivePoint{te.toProjective(te.Identity()), te.toProjective(p)}
+	for i := bits - 1; i >= 0; i-- {
+		b := n.Bit(i)
+		r[1-b] = te.projectiveAdd(r[0], r[1])
+		r[b] = te.projectiveAdd(r[b], r[b])
+	}
+	return te.toAffine(r[0])
+}
+
+// projectivePoint is a point of a twisted Edwards curve in projective
+// coordinates
+type projectivePoint struct {
+	X *big.Int
+	Y *big.Int
+	Z *big.Int
+}
+
+// toProjective returns the projective coordinates (X : Y : Z) of the point,
+// where x = X/Z and y = Y/Z
+func (te *TwistedEdwards) toProjective(p Point) projectivePoint {
+	return projectivePoint{new(big.Int).Set(p.X), new(big.Int).Set(p.Y), big.NewInt(int64(1))}
+}
+
+// toAffine converts a point in projective coordinates to affine coordinates
+func (te *TwistedEdwards) toAffine(p projectivePoint) (Point, error) {
+	zInv := new(big.Int).ModInverse(p.Z, te.Q)
+	if zInv == nil {
+		// only possible when the addition law is not complete
+		return Point{}, errors.New("exceptional case of the addition law")
+	}
+	x := new(big.Int).Mul(p.X, zInv)
+	y := new(big.Int).Mul(p.Y, zInv)
+	return Point{x.Mod(x, te.Q), y.Mod(y, te.Q)}, nil
+}
+
+// projectiveAdd returns p1 + p2 with the add-2008-bbjlp formulas
+func (te *TwistedEdwards) projectiveAdd(p1, p2 projectivePoint) projectivePoint {
+	t := new(big.Int)
+	// a = Z1*Z2, b = a^2, c = X1*X2, d = Y1*Y2, e = d_curve*c*d
+	a := new(big.Int).Mul(p1.Z, p2.Z)
+	te.reduce(a, t)
+	b := new(big.Int).Mul(a, a)
+	te.reduce(b, t)
+	c := new(big.Int).Mul(p1.X, p2.X)
+	te.reduce(c, t)
+	d := new(big.Int).Mul(p1.Y, p2.Y)
+	te.reduce(d, t)
+	e := new(big.Int).Mul(te.D, c)
+	te.reduce(e, t)
+	e.Mul(e, d)
+	te.reduce(e, t)
+	// f = b - e, g = b + e
+	f := new(big.Int).Sub(b, e)
+	g := new(big.Int).Add(b, e)
+	// X3 = a * f * ((X1 + Y1) * (X2 + Y2) - c - d)
+	x3 := new(big.Int).Add(p1.X, p1.Y)
+	x3.Mul(x3, t.Add(p2.X, p2.Y))
+	x3.Sub(x3, c)
+	x3.Sub(x3, d)
+	te.reduce(x3, t)
+	x3.Mul(x3, a)
+	te.reduce(x3, t)
+	x3.Mul(x3, f)
+	te.reduce(x3, t)
+	// Y3 = a * g * (d - a_curve * c)
+	y3 := new(big.Int).Mul(te.A, c)
+	y3.Sub(d, y3)
+	te.reduce(y3, t)
+	y3.Mul(y3, a)
+	te.reduce(y3, t)
+	y3.Mul(y3, g)
+	te.reduce(y3, t)
+	// Z3 = f * g
+	z3 := new(big.Int).Mul(f, g)
+	te.reduce(z3, t)
+	return projectivePoint{x3, y3, z3}
+}
+
+// reduce sets z = z mod q, using t as scratch space for the quotient
+func (te *TwistedEdwards) reduce(z, t *big.Int) *big.Int {
+	t.QuoRem(z, te.Q, z)
+	if z.Sign() < 0 {
+		z.Add(z, te.Q)
+	}
+	return z
+}
+
+// byteLen returns the number of bytes of the encoding of a point, the bits of
+// y plus one bit for the sign of x
+func (te *TwistedEdwards) byteLen() int {
+	return (te.Q.BitLen() + 1 + 7) / 8
+}
+
+// Marshal returns the encoding of the point of RFC 8032 (section 5.1.2): the
+// little endian y coordinate, with the least significant bit of x in the most
+// significant bit of the last byte
+func (te *TwistedEdwards) Marshal(p Point) ([]byte, error) {
+	if err := te.Validate(p); err != nil {
+		return nil, err
+	}
+	l := te.byteLen()
+	b := make([]byte, l)
+	p.Y.FillBytes(b)
+	reverse(b)
+	b[l-1] |= byte(p.X.Bit(0) << 7)
+	return b, nil
+}
+
+// Unmarshal decodes a point encoded with Marshal, recovering x from the curve
+// equation x^2 = (y^2 - 1) / (d y^2 - a)
+func (te *TwistedEdwards) Unmarshal(b []byte) (Point, error) {
+	l := te.byteLen()
+	if len(b) != l {
+		return Point{}, errors.New("invalid point encoding: length")
+	}
+	yb := make([]byte, l)
+	copy(yb, b)
+	xBit := uint(yb[l-1] >> 7)
+	yb[l-1] &= 0x7f
+	reverse(yb)
+	y := new(big.Int).SetBytes(yb)
+	if y.Cmp(te.Q) >= 0 {
+		return Point{}, errors.New("invalid point encoding: y out of the field")
+	}
+	y2 := new(big.Int).Mul(y, y)
+	num := new(big.Int).Sub(y2, BigOne)
+	den := new(big.Int).Mul(te.D, y2)
+	den.Sub(den, te.A)
+	den.Mod(den, te.Q)
+	denInv := new(big.Int).ModInverse(den, te.Q)
+	if denInv == nil {
+		return Point{}, errors.New("invalid point encoding: no point with y = " + y.String())
+	}
+	x2 := num.Mul(num, denInv)
+	x2.Mod(x2, te.Q)
+	x := new(big.Int).ModSqrt(x2, te.Q)
+	if x == nil {
+		return Point{}, errors.New("invalid point encoding: no point with y = " + y.String())
+	}
+	if x.Sign() == 0 && xBit == 1 {
+		return Point{}, errors.New("invalid point encoding: x = 0 with sign bit")
+	}
+	if x.Bit(0) != xBit {
+		x.Sub(te.Q, x)
+	}
+	return Point{x, y}, nil
+}
+
+// ToMontgomery returns the birationally equivalent Montgomery curve, with
+// A = 2(a+d)/(a-d) and B = 4/(a-d)
+func (te *TwistedEdwards) ToMontgomery() Montgomery {
+	aMinusD := new(big.Int).Sub(te.A, te.D)
+	inv := new(big.Int).ModInverse(aMinusD.Mod(aMinusD, te.Q), te.Q)
+	a := new(big.Int).Add(te.A, te.D)
+	a.Lsh(a, 1)
+	a.Mul(a, inv)
+	b := new(big.Int).Lsh(inv, 2)
+	return NewMontgomery(a.Mod(a, te.Q), b.Mod(b, te.Q), te.Q)
+}
+
+// PointToMontgomery maps the point p to the Montgomery curve returned by
+// ToMontgomery, (u, v) = ((1+y)/(1-y), (1+y)/((1-y)x)). The neutral element
+// (0, 1) is mapped to the point at infinity, and (0, -1) to (0, 0)
+func (te *TwistedEdwards) PointToMontgomery(p Point) (Point, error) {
+	if err := te.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.X.Sign() == 0 {
+		// (0, 1) is the neutral element, (0, -1) the point of order 2
+		return ZeroPoint, nil
+	}
+	onePlusY := new(big.Int).Add(BigOne, p.Y)
+	oneMinusY := new(big.Int).Sub(BigOne, p.Y)
+	u := new(big.Int).Mul(onePlusY, new(big.Int).ModInverse(oneMinusY.Mod(oneMinusY, te.Q), te.Q))
+	u.Mod(u, te.Q)
+	v := new(big.Int).Mul(u, new(big.Int).ModInverse(p.X, te.Q))
+	return Point{u, v.Mod(v, te.Q)}, nil
+}
+
+// ToWeierstrass returns the birationally equivalent short Weierstrass curve,
+// through the Montgomery form
+func (te *TwistedEdwards) ToWeierstrass() EC {
+	m := te.ToMontgomery()
+	return m.ToWeierstrass()
+}
+
+// PointToWeierstrass maps the point p to the curve returned by ToWeierstrass
+func (te *TwistedEdwards) PointToWeierstrass(p Point) (Point, error) {
+	pm, err := te.PointToMontgomery(p)
+	if err != nil {
+		return Point{}, err
+	}
+	m := te.ToMontgomery()
+	return m.PointToWeierstrass(pm)
+}
+
+// PointFromWeierstrass maps a point of the curve returned by ToWeierstrass to
+// the twisted Edwards curve
+func (te *TwistedEdwards) PointFromWeierstrass(p Point) (Point, error) {
+	m := te.ToMontgomery()
+	pm, err := m.PointFromWeierstrass(p)
+	if err != nil {
+		return Point{}, err
+	}
+	return m.PointToEdwards(pm)
+}
+
+// Weierstrass returns the named curve in short Weierstrass form, with the
+// generator mapped by PointToWeierstrass, so it can be used with the Curve
+// APIs, like the group.Group based schemes
+func (c EdwardsCurve) Weierstrass() (Curve, error) {
+	g, err := c.TE.PointToWeierstrass(c.G)
+	if err != nil {
+		return Curve{}, err
+	}
+	return Curve{
+		Name: c.Name + "-weierstrass",
+		EC:   c.TE.ToWeierstrass(),
+		G:    g,
+		N:    c.N,
+		H:    c.H,
+	}, nil
+}
+
+// reverse reverses the bytes of b, to convert between big and little endian
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
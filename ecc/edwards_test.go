@@ -0,0 +1,133 @@
+package ecc
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdwards25519(t *testing.T) {
+	c := Edwards25519()
+	assert.True(t, c.TE.IsOnCurve(c.G))
+	assert.True(t, c.TE.IsOnCurve(c.TE.Identity()))
+	assert.False(t, c.TE.IsOnCurve(Point{big.NewInt(int64(1)), big.NewInt(int64(1))}))
+
+	// N x G == (0, 1)
+	nG, err := c.TE.Mul(c.G, c.N)
+	assert.Nil(t, err)
+	assert.True(t, nG.Equal(c.TE.Identity()))
+
+	// G + G + G == 3G, G + (-G) == (0, 1)
+	g2, err := c.TE.Add(c.G, c.G)
+	assert.Nil(t, err)
+	g3, err := c.TE.Add(g2, c.G)
+	assert.Nil(t, err)
+	g3Mul, err := c.TE.Mul(c.G, big.NewInt(int64(3)))
+	assert.Nil(t, err)
+	assert.True(t, g3.Equal(g3Mul))
+	gNeg, err := c.TE.Neg(c.G)
+	assert.Nil(t, err)
+	id, err := c.TE.Add(c.G, gNeg)
+	assert.Nil(t, err)
+	assert.True(t, id.Equal(c.TE.Identity()))
+
+	_, err = c.TE.Add(c.G, Point{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+}
+
+func TestEd25519PublicKey(t *testing.T) {
+	// RFC 8032, section 7.1, TEST 1
+	c := Edwards25519()
+	secret, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	h := sha512.Sum512(secret)
+	s := h[:32]
+	s[0] &= 248
+	s[31] &= 127
+	s[31] |= 64
+	reverse(s)
+	pubK, err := c.TE.Mul(c.G, new(big.Int).SetBytes(s))
+	assert.Nil(t, err)
+	b, err := c.TE.Marshal(pubK)
+	assert.Nil(t, err)
+	assert.Equal(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", hex.EncodeToString(b))
+
+	p, err := c.TE.Unmarshal(b)
+	assert.Nil(t, err)
+	assert.True(t, pubK.Equal(p))
+}
+
+func TestEdwardsMarshal(t *testing.T) {
+	c := Edwards25519()
+	p := c.TE.Identity()
+	for i := 0; i < 10; i++ {
+		b, err := c.TE.Marshal(p)
+		assert.Nil(t, err)
+		p2, err := c.TE.Unmarshal(b)
+		assert.Nil(t, err)
+		assert.True(t, p.Equal(p2))
+		p, err = c.TE.Add(p, c.G)
+		assert.Nil(t, err)
+	}
+
+	_, err := c.TE.Unmarshal([]byte{1, 2, 3})
+	assert.NotNil(t, err)
+	// y = q is out of the field
+	b := c.TE.Q.Bytes()
+	reverse(b)
+	_, err = c.TE.Unmarshal(b)
+	assert.NotNil(t, err)
+	// y = 2 has no x
+	b = make([]byte, 32)
+	b[0] = 2
+	_, err = c.TE.Unmarshal(b)
+	assert.NotNil(t, err)
+	// the identity with the sign bit of x
+	b[0] = 1
+	b[31] = 0x80
+	_, err = c.TE.Unmarshal(b)
+	assert.NotNil(t, err)
+}
+
+func TestEdwardsBirationalMaps(t *testing.T) {
+	c := Edwards25519()
+	m := c.TE.ToMontgomery()
+	assert.Equal(t, big.NewInt(int64(486662)), m.A)
+
+	// the generator is mapped to the point with u = 9
+	gm, err := c.TE.PointToMontgomery(c.G)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(9)), gm.X)
+	assert.True(t, m.IsOnCurve(gm))
+	g, err := m.PointToEdwards(gm)
+	assert.Nil(t, err)
+	assert.True(t, c.G.Equal(g))
+
+	// the maps to the Weierstrass form are group homomorphisms
+	ec := c.TE.ToWeierstrass()
+	k := big.NewInt(int64(123456789))
+	kG, err := c.TE.Mul(c.G, k)
+	assert.Nil(t, err)
+	gw, err := c.TE.PointToWeierstrass(c.G)
+	assert.Nil(t, err)
+	assert.True(t, ec.IsOnCurve(gw))
+	kGw, err := ec.Mul(gw, k)
+	assert.Nil(t, err)
+	kG2, err := c.TE.PointFromWeierstrass(kGw)
+	assert.Nil(t, err)
+	assert.True(t, kG.Equal(kG2))
+
+	id, err := c.TE.PointToWeierstrass(c.TE.Identity())
+	assert.Nil(t, err)
+	assert.True(t, id.Equal(ZeroPoint))
+
+	// the Weierstrass form as a Curve
+	cw, err := c.Weierstrass()
+	assert.Nil(t, err)
+	assert.Nil(t, cw.Validate(cw.G))
+	nG, err := cw.EC.Mul(cw.G, cw.N)
+	assert.Nil(t, err)
+	assert.True(t, nG.Equal(ZeroPoint))
+}
@@ -0,0 +1,202 @@
+package ecc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// SEC1 (SEC 1 v2, section 2.3.3) point encoding prefixes
+const (
+	sec1Infinity     = 0x00
+	sec1CompressedY0 = 0x02
+	sec1CompressedY1 = 0x03
+	sec1Uncompressed = 0x04
+)
+
+// byteLen returns the number of bytes of an encoded field element
+func (ec *EC) byteLen() int {
+	return (ec.Q.BitLen() + 7) / 8
+}
+
+// Marshal returns the SEC1 uncompressed encoding of the point p,
+// 0x04 || X || Y, or 0x00 for the point at infinity
+func (ec *EC) Marshal(p Point) ([]byte, error) {
+	if err := ec.Validate(p); err != nil {
+		return nil, err
+	}
+	if p.Equal(ZeroPoint) {
+		return []byte{sec1Infinity}, nil
+	}
+	l := ec.byteLen()
+	b := make([]byte, 1+2*l)
+	b[0] = sec1Uncompressed
+	p.X.FillBytes(b[1 : 1+l])
+	p.Y.FillBytes(b[1+l:])
+	return b, nil
+}
+
+// MarshalCompressed returns the SEC1 compressed encoding of the point p,
+// 0x02 || X when Y is even and 0x03 || X when Y is odd, or 0x00 for the point
+// at infinity
+func (ec *EC) MarshalCompressed(p Point) ([]byte, error) {
+	if err := ec.Validate(p); err != nil {
+		return nil, err
+	}
+	if p.Equal(ZeroPoint) {
+		return []byte{sec1Infinity}, nil
+	}
+	b := make([]byte, 1+ec.byteLen())
+	b[0] = sec1CompressedY0 + byte(p.Y.Bit(0))
+	p.X.FillBytes(b[1:])
+	return b, nil
+}
+
+// Unmarshal decodes a point in any of the SEC1 encodings (compressed,
+// uncompressed or infinity), and checks that the point is on the curve. The Y
+// coordinate of the compressed points is recovered with At
+func (ec *EC) Unmarshal(b []byte) (Point, error) {
+	if len(b) == 0 {
+		return Point{}, errors.New("invalid point encoding: empty")
+	}
+	l := ec.byteLen()
+	switch b[0] {
+	case sec1Infinity:
+		if len(b) != 1 {
+			return Point{}, errors.New("invalid point encoding: length")
+		}
+		return Point{big.NewInt(int64(0)), big.NewInt(int64(0))}, nil
+	case sec1Uncompressed:
+		if len(b) != 1+2*l {
+			return Point{}, errors.New("invalid point encoding: length")
+		}
+		p := Point{
+			new(big.Int).SetBytes(b[1 : 1+l]),
+			new(big.Int).SetBytes(b[1+l:]),
+		}
+		if p.Equal(ZeroPoint) {
+			// the point at infinity has its own encoding
+			return Point{}, errors.New("invalid point: (0, 0) not on the curve")
+		}
+		if err := ec.Validate(p); err != nil {
+			return Point{}, err
+		}
+		return p, nil
+	case sec1CompressedY0, sec1CompressedY1:
+		if len(b) != 1+l {
+			return Point{}, errors.New("invalid point encoding: length")
+		}
+		return ec.decompress(new(big.Int).SetBytes(b[1:]), uint(b[0]-sec1CompressedY0))
+	}
+	return Point{}, errors.New("invalid point encoding: unknown prefix")
+}
+
+// decompress returns the point of the curve with the given x and the parity
+// of y
+func (ec *EC) decompress(x *big.Int, yBit uint) (Point, error) {
+	if x.Cmp(ec.Q) >= 0 {
+		return Point{}, errors.New("invalid point encoding: x out of the field")
+	}
+	p, pNeg, err := ec.At(x)
+	if err != nil {
+		return Point{}, errors.New("invalid point encoding: " + err.Error())
+	}
+	if p.Y.Bit(0) != yBit {
+		p = pNeg
+	}
+	if p.Y.Bit(0) != yBit {
+		return Point{}, errors.New("invalid point encoding: no point with the given y parity")
+	}
+	return p, nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. As the
+// point is not tied to a curve, it uses the SEC1 uncompressed encoding with the
+// length of the biggest coordinate; use EC.Marshal for the encoding of the
+// curve
+func (p Point) MarshalBinary() ([]byte, error) {
+	if p.X == nil || p.Y == nil {
+		return nil, errors.New("invalid point: nil coordinate")
+	}
+	if p.X.Sign() < 0 || p.Y.Sign() < 0 {
+		return nil, errors.New("invalid point: negative coordinate")
+	}
+	if p.Equal(ZeroPoint) {
+		return []byte{sec1Infinity}, nil
+	}
+	l := (p.X.BitLen() + 7) / 8
+	if yl := (p.Y.BitLen() + 7) / 8; yl > l {
+		l = yl
+	}
+	b := make([]byte, 1+2*l)
+	b[0] = sec1Uncompressed
+	p.X.FillBytes(b[1 : 1+l])
+	p.Y.FillBytes(b[1+l:])
+	return b, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface, decoding
+// the SEC1 uncompressed and infinity encodings. The point is not checked to be
+// on a curve, use EC.Unmarshal or EC.Validate for that
+func (p *Point) UnmarshalBinary(b []byte) error {
+	if len(b) == 1 && b[0] == sec1Infinity {
+		*p = Point{big.NewInt(int64(0)), big.NewInt(int64(0))}
+		return nil
+	}
+	if len(b) < 1 || b[0] != sec1Uncompressed || len(b)%2 != 1 {
+		return errors.New("invalid point encoding")
+	}
+	l := (len(b) - 1) / 2
+	*p = Point{
+		new(big.Int).SetBytes(b[1 : 1+l]),
+		new(big.Int).SetBytes(b[1+l:]),
+	}
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, returning the
+// hex of the binary encoding. It is also used by encoding/json, so a Point is
+// encoded as a JSON string instead of the {"X": x, "Y": y} object of the
+// versions without marshalers, which is still accepted by UnmarshalJSON
+func (p Point) MarshalText() ([]byte, error) {
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (p *Point) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	return p.UnmarshalBinary(b)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, decoding the JSON
+// string of MarshalText, or the {"X": x, "Y": y} object of the previous
+// encoding of Point
+func (p *Point) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		return p.UnmarshalText([]byte(text))
+	}
+	var legacy struct {
+		X *big.Int
+		Y *big.Int
+	}
+	if err := json.Unmarshal(b, &legacy); err != nil {
+		return errors.New("invalid point encoding: " + err.Error())
+	}
+	if legacy.X == nil || legacy.Y == nil {
+		return errors.New("invalid point encoding: missing coordinate")
+	}
+	*p = Point{legacy.X, legacy.Y}
+	return nil
+}
@@ -0,0 +1,120 @@
+package ecc
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalCompatibility(t *testing.T) {
+	// compare with the encodings of crypto/elliptic
+	c := P256()
+	for i := int64(1); i < 20; i++ {
+		k := new(big.Int).Exp(big.NewInt(i), big.NewInt(int64(31)), c.N)
+		p, err := c.EC.Mul(c.G, k)
+		assert.Nil(t, err)
+		x, y := elliptic.P256().ScalarBaseMult(k.Bytes())
+
+		b, err := c.EC.Marshal(p)
+		assert.Nil(t, err)
+		assert.Equal(t, elliptic.Marshal(elliptic.P256(), x, y), b)
+		p2, err := c.EC.Unmarshal(b)
+		assert.Nil(t, err)
+		assert.True(t, p.Equal(p2))
+
+		b, err = c.EC.MarshalCompressed(p)
+		assert.Nil(t, err)
+		assert.Equal(t, elliptic.MarshalCompressed(elliptic.P256(), x, y), b)
+		p2, err = c.EC.Unmarshal(b)
+		assert.Nil(t, err)
+		assert.True(t, p.Equal(p2))
+	}
+}
+
+func TestMarshalToyCurve(t *testing.T) {
+	// all the points of the curve, including the point at infinity
+	c := Toy29()
+	p := ZeroPoint
+	for i := 0; i <= int(c.N.Int64()); i++ {
+		for _, compressed := range []bool{false, true} {
+			var b []byte
+			var err error
+			if compressed {
+				b, err = c.EC.MarshalCompressed(p)
+			} else {
+				b, err = c.EC.Marshal(p)
+			}
+			assert.Nil(t, err)
+			p2, err := c.EC.Unmarshal(b)
+			assert.Nil(t, err)
+			assert.True(t, p.Equal(p2), p.String())
+		}
+		var err error
+		p, err = c.EC.Add(p, c.G)
+		assert.Nil(t, err)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	c := Toy29()
+	for _, b := range [][]byte{
+		{},
+		{0x00, 0x00},
+		{0x05, 0x17},
+		{0x04, 0x17, 0x09, 0x00},
+		{0x04, 0x17, 0x0a}, // not on the curve
+		{0x04, 0x1d, 0x09}, // x out of the field
+		{0x02, 0x1e},       // x out of the field
+		{0x02, 0x02},       // 2^3 + 7 = 15 is not a square mod 29
+		{0x02, 0x17, 0x09}, // length
+		{0x04, 0x00, 0x00}, // (0, 0) is not on the curve
+	} {
+		_, err := c.EC.Unmarshal(b)
+		assert.NotNil(t, err, "%x", b)
+	}
+
+	_, err := c.EC.Marshal(Point{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+	_, err = c.EC.MarshalCompressed(Point{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+}
+
+func TestPointTextAndJSON(t *testing.T) {
+	c := Secp256k1()
+	text, err := c.G.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", string(text))
+
+	var p Point
+	assert.Nil(t, p.UnmarshalText(text))
+	assert.True(t, c.G.Equal(p))
+
+	type keys struct {
+		PubK Point
+		Zero Point
+	}
+	j, err := json.Marshal(keys{c.G, ZeroPoint})
+	assert.Nil(t, err)
+	var k keys
+	assert.Nil(t, json.Unmarshal(j, &k))
+	assert.True(t, c.G.Equal(k.PubK))
+	assert.True(t, ZeroPoint.Equal(k.Zero))
+	// the same encoding for values and pointers
+	j2, err := json.Marshal(&keys{c.G, ZeroPoint})
+	assert.Nil(t, err)
+	assert.Equal(t, string(j), string(j2))
+
+	// the objects of the previous encoding
+	k = keys{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"PubK":{"X":7,"Y":8},"Zero":{"X":0,"Y":0}}`), &k))
+	assert.True(t, k.PubK.Equal(Point{big.NewInt(int64(7)), big.NewInt(int64(8))}))
+	assert.True(t, ZeroPoint.Equal(k.Zero))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"PubK":{"X":7}}`), &k))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"PubK":3}`), &k))
+
+	assert.NotNil(t, p.UnmarshalText([]byte("02aa")))
+	assert.NotNil(t, p.UnmarshalText([]byte("zz")))
+}
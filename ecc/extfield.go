@@ -0,0 +1,243 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// ExtField is the extension field F_(q^k) = F_q[t]/(m(t)), where m is a monic
+// irreducible polynomial of degree k. Its elements are the polynomials in t of
+// degree lower than k
+type ExtField struct {
+	Q *big.Int
+	K int
+
+	pf    polyField
+	mod   *polyModulus
+	order *big.Int // q^k - 1, the order of the multiplicative group
+}
+
+// ExtElement is an element of an ExtField, with the coefficients of the
+// polynomial in t from the lower to the higher degree, in [0, q) and without
+// leading zero coefficients, so 0 is the empty slice. The coefficients are
+// never modified in place
+type ExtElement []*big.Int
+
+// NewExtField returns the extension field of degree k of F_q, q prime, with
+// the first monic irreducible polynomial of degree k in the order of the
+// coefficients, which for k = 2 and q = 3 mod 4 is t^2 + 1
+func NewExtField(q *big.Int, k int) (*ExtField, error) {
+	if k < 1 {
+		return nil, errors.New("the degree of the extension must be positive")
+	}
+	f := &ExtField{Q: q, K: k, pf: polyField{q}}
+	f.order = new(big.Int).Exp(q, big.NewInt(int64(k)), nil)
+	f.order.Sub(f.order, BigOne)
+	// candidates t^k + c(t), with c(t) the polynomial of the base q digits of n
+	limit := new(big.Int).Exp(q, big.NewInt(int64(k)), nil)
+	for n := big.NewInt(int64(1)); n.Cmp(limit) < 0; n = new(big.Int).Add(n, BigOne) {
+		m := make(poly, k+1)
+		d := f.digits(n)
+		for i := range m {
+			m[i] = BigZero
+			if i < len(d) {
+				m[i] = d[i]
+			}
+		}
+		m[k] = BigOne
+		if m[0].Sign() != 0 && f.isIrreducible(m) {
+			f.mod = newPolyModulus(f.pf, m)
+			return f, nil
+		}
+	}
+	return nil, errors.New("no irreducible polynomial of degree " + strconv.Itoa(k))
+}
+
+// digits returns the digits of n in base q, from the least significant
+func (f *ExtField) digits(n *big.Int) []*big.Int {
+	var d []*big.Int
+	for n = new(big.Int).Set(n); n.Sign() > 0; {
+		r := new(big.Int)
+		n.QuoRem(n, f.Q, r)
+		d = append(d, r)
+	}
+	return d
+}
+
+// isIrreducible returns true if the monic polynomial m of degree k is
+// irreducible, with Rabin's test: t^(q^k) = t mod m, and
+// gcd(t^(q^(k/d)) - t, m) = 1 for each prime d dividing k
+func (f *ExtField) isIrreducible(m poly) bool {
+	k := f.pf.deg(m)
+	if k == 1 {
+		return true
+	}
+	pm := newPolyModulus(f.pf, m)
+	t := f.pf.monomial(1)
+	// frob[i] = t^(q^i) mod m
+	frob := make([]poly, k+1)
+	frob[0] = pm.reduce(t)
+	for i := 1; i <= k; i++ {
+		frob[i] = pm.exp(frob[i-1], f.Q)
+	}
+	if !f.pf.equal(frob[k], frob[0]) {
+		return false
+	}
+	for d := 2; d <= k; d++ {
+		if k%d != 0 || !big.NewInt(int64(d)).ProbablyPrime(1) {
+			continue
+		}
+		g := f.pf.gcd(f.pf.sub(frob[k/d], t), m)
+		if f.pf.deg(g) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Modulus returns the irreducible polynomial m(t) of the extension
+func (f *ExtField) Modulus() ExtElement {
+	return ExtElement(f.mod.h)
+}
+
+// Element returns the element c[0] + c[1] t + ... + c[n] t^n, reduced modulo q
+// and m(t)
+func (f *ExtField) Element(c ...*big.Int) ExtElement {
+	p := make(poly, len(c))
+	for i := range c {
+		p[i] = new(big.Int).Mod(c[i], f.Q)
+	}
+	return ExtElement(f.mod.reduce(f.pf.trim(p)))
+}
+
+// Zero returns the element 0
+func (f *ExtField) Zero() ExtElement {
+	return ExtElement{}
+}
+
+// One returns the element 1
+func (f *ExtField) One() ExtElement {
+	return ExtElement{big.NewInt(int64(1))}
+}
+
+// IsZero returns true if a = 0
+func (f *ExtField) IsZero(a ExtElement) bool {
+	return len(a) == 0
+}
+
+// Equal returns true if a = b
+func (f *ExtField) Equal(a, b ExtElement) bool {
+	return f.pf.equal(poly(a), poly(b))
+}
+
+// Add returns a + b
+func (f *ExtField) Add(a, b ExtElement) ExtElement {
+	return ExtElement(f.pf.add(poly(a), poly(b)))
+}
+
+// Sub returns a - b
+func (f *ExtField) Sub(a, b ExtElement) ExtElement {
+	return ExtElement(f.pf.sub(poly(a), poly(b)))
+}
+
+// Neg returns -a
+func (f *ExtField) Neg(a ExtElement) ExtElement {
+	return ExtElement(f.pf.neg(poly(a)))
+}
+
+// Mul returns a b
+func (f *ExtField) Mul(a, b ExtElement) ExtElement {
+	return ExtElement(f.mod.mul(poly(a), poly(b)))
+}
+
+// Inverse returns 1/a, or an error if a = 0
+func (f *ExtField) Inverse(a ExtElement) (ExtElement, error) {
+	if len(a) == 0 {
+		return nil, errors.New("inverse of zero")
+	}
+	inv, _ := f.pf.invMod(poly(a), f.mod.h)
+	return ExtElement(inv), nil
+}
+
+// Div returns a / b, or an error if b = 0
+func (f *ExtField) Div(a, b ExtElement) (ExtElement, error) {
+	bInv, err := f.Inverse(b)
+	if err != nil {
+		return nil, err
+	}
+	return f.Mul(a, bInv), nil
+}
+
+// Exp returns a^e, for negative e the inverse of a^|e|
+func (f *ExtField) Exp(a ExtElement, e *big.Int) (ExtElement, error) {
+	if e.Sign() < 0 {
+		inv, err := f.Inverse(a)
+		if err != nil {
+			return nil, err
+		}
+		return f.Exp(inv, new(big.Int).Neg(e))
+	}
+	return ExtElement(f.mod.exp(poly(a), e)), nil
+}
+
+// IsSquare returns true if a is a square, with Euler's criterion
+// a^((q^k - 1) / 2) = 1. In characteristic 2 all the elements are squares
+func (f *ExtField) IsSquare(a ExtElement) bool {
+	if len(a) == 0 || f.Q.Bit(0) == 0 {
+		return true
+	}
+	e := new(big.Int).Rsh(f.order, 1)
+	r, _ := f.Exp(a, e)
+	return f.Equal(r, f.One())
+}
+
+// Sqrt returns a square root of a with the Tonelli-Shanks algorithm, or an
+// error if a is not a square. In characteristic 2 the square root is
+// a^(2^(k-1))
+func (f *ExtField) Sqrt(a ExtElement) (ExtElement, error) {
+	if len(a) == 0 {
+		return a, nil
+	}
+	if f.Q.Bit(0) == 0 {
+		return f.Exp(a, new(big.Int).Rsh(new(big.Int).Add(f.order, BigOne), 1))
+	}
+	if !f.IsSquare(a) {
+		return nil, errors.New("not a square")
+	}
+	// q^k - 1 = 2^s t, with t odd
+	s := 0
+	t := new(big.Int).Set(f.order)
+	for t.Bit(0) == 0 {
+		t.Rsh(t, 1)
+		s++
+	}
+	// z is a non square, and c = z^t generates the 2-Sylow subgroup
+	var z ExtElement
+	for n := big.NewInt(int64(2)); ; n = new(big.Int).Add(n, BigOne) {
+		z = f.Element(f.digits(n)...)
+		if len(z) > 0 && !f.IsSquare(z) {
+			break
+		}
+	}
+	c, _ := f.Exp(z, t)
+	x, _ := f.Exp(a, new(big.Int).Rsh(new(big.Int).Add(t, BigOne), 1))
+	b, _ := f.Exp(a, t)
+	for m := s; !f.Equal(b, f.One()); {
+		// the smallest i with b^(2^i) = 1
+		i := 0
+		for b2 := b; !f.Equal(b2, f.One()); i++ {
+			b2 = f.Mul(b2, b2)
+		}
+		// w = c^(2^(m-i-1))
+		w := c
+		for j := 0; j < m-i-1; j++ {
+			w = f.Mul(w, w)
+		}
+		x = f.Mul(x, w)
+		c = f.Mul(w, w)
+		b = f.Mul(b, c)
+		m = i
+	}
+	return x, nil
+}
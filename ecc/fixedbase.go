@@ -0,0 +1,101 @@
+package ecc
+
+import (
+	"crypto/subtle"
+	"math/big"
+)
+
+// fixedBaseWindow is the window size in bits of the FixedBase tables
+const fixedBaseWindow = 4
+
+// FixedBase is a precomputed table for the multiplication of a fixed point P,
+// like the generator of a curve. For each window i of fixedBaseWindow bits of
+// the scalar it stores the points j x 2^(w i) x P, so a multiplication only
+// needs one addition per window and no doublings. As the scalars are usually
+// secret (private keys and nonces), the points are read scanning the whole row
+// of the window and added with the complete formulas
+type FixedBase struct {
+	ec    EC
+	c     *curveArith
+	p     Point
+	bits  int
+	table [][]homogeneousPoint // points with Z = 1, and the point at infinity
+}
+
+// NewFixedBase precomputes the table for the multiplication of the point p by
+// scalars of up to bits bits. Bigger scalars are multiplied with Mul
+func (ec *EC) NewFixedBase(p Point, bits int) (*FixedBase, error) {
+	if err := ec.Validate(p); err != nil {
+		return nil, err
+	}
+	c, err := ec.arith()
+	if err != nil {
+		return nil, err
+	}
+	windows := (bits + fixedBaseWindow - 1) / fixedBaseWindow
+	fb := &FixedBase{
+		ec:    *ec,
+		c:     c,
+		p:     p,
+		bits:  windows * fixedBaseWindow,
+		table: make([][]homogeneousPoint, windows),
+	}
+	// base = 2^(w i) x P
+	base := c.toJacobian(p)
+	rows := make([]jacobianPoint, 0, windows<<fixedBaseWindow)
+	for i := 0; i < windows; i++ {
+		acc := c.infinity()
+		rows = append(rows, acc)
+		for j := 1; j < 1<<fixedBaseWindow; j++ {
+			acc = c.add(acc, base)
+			rows = append(rows, acc)
+		}
+		// the next base is 2^w x base = (2^w - 1) x base + base
+		base = c.add(acc, base)
+	}
+	// normalize all the points to Z = 1 with a single inversion
+	points := make([]homogeneousPoint, len(rows))
+	for i, q := range c.batchToAffine(rows) {
+		points[i] = c.toHomogeneous(q)
+	}
+	for i := range fb.table {
+		fb.table[i] = points[i<<fixedBaseWindow : (i+1)<<fixedBaseWindow]
+	}
+	return fb, nil
+}
+
+// Point returns the fixed point of the table
+func (fb *FixedBase) Point() Point {
+	return fb.p
+}
+
+// Mul returns n x P, adding the precomputed point of each window of n. The
+// point of the window is selected with conditional swaps over all the points
+// of its row, and the sum uses the complete formulas, so the operations do not
+// depend on the value of n (as in EC.Mul, the big.Int conversions are not
+// constant time). Negative scalars and scalars bigger than the table are
+// multiplied with EC.Mul. n is not modified
+func (fb *FixedBase) Mul(n *big.Int) (Point, error) {
+	if n.Sign() < 0 || n.BitLen() > fb.bits {
+		return fb.ec.Mul(fb.p, n)
+	}
+	c := fb.c
+	r := c.homogeneousInfinity()
+	for i := range fb.table {
+		v := 0
+		for j := fixedBaseWindow - 1; j >= 0; j-- {
+			v = v<<1 | int(n.Bit(i*fixedBaseWindow+j))
+		}
+		q := c.homogeneousInfinity()
+		for j := range fb.table[i] {
+			t := fb.table[i][j]
+			c.condSwap(&q, &t, uint64(subtle.ConstantTimeEq(int32(j), int32(v))))
+		}
+		r = c.completeAdd(r, q)
+	}
+	if r.isExceptional() {
+		// only when the subgroup of P has a point of order 2
+		return fb.ec.Mul(fb.p, n)
+	}
+	return c.homogeneousToAffine(r, true), nil
+}
@@ -0,0 +1,74 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedBase(t *testing.T) {
+	for _, c := range []Curve{P256(), Secp256k1(), Toy29()} {
+		fb, err := c.EC.NewFixedBase(c.G, c.N.BitLen())
+		assert.Nil(t, err)
+		assert.True(t, c.G.Equal(fb.Point()))
+
+		scalars := []*big.Int{
+			big.NewInt(int64(0)),
+			big.NewInt(int64(1)),
+			big.NewInt(int64(16)),
+			new(big.Int).Sub(c.N, BigOne),
+			c.N,
+			// bigger than the table, computed with Mul
+			new(big.Int).Lsh(c.N, 10),
+		}
+		for i := 0; i < 10; i++ {
+			k, err := rand.Int(rand.Reader, c.N)
+			assert.Nil(t, err)
+			scalars = append(scalars, k)
+		}
+		for _, k := range scalars {
+			kCopy := new(big.Int).Set(k)
+			p, err := fb.Mul(k)
+			assert.Nil(t, err)
+			p2, err := c.EC.Mul(c.G, k)
+			assert.Nil(t, err)
+			assert.True(t, p.Equal(p2), "%s k=%s", c.Name, k.String())
+			assert.Equal(t, 0, kCopy.Cmp(k))
+		}
+	}
+
+	c := P256()
+	_, err := c.EC.NewFixedBase(Point{big.NewInt(int64(1)), big.NewInt(int64(1))}, 256)
+	assert.NotNil(t, err)
+
+	// (7, 8) has order 12 on y^2 = x^3 + 7 mod 11, so some of the additions
+	// are exceptions of the complete formulas
+	ec := NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11)))
+	g := Point{big.NewInt(int64(7)), big.NewInt(int64(8))}
+	fb, err := ec.NewFixedBase(g, 8)
+	assert.Nil(t, err)
+	for k := int64(0); k < 256; k++ {
+		p, err := fb.Mul(big.NewInt(k))
+		assert.Nil(t, err)
+		assert.True(t, p.Equal(mulAffine(&ec, g, big.NewInt(k))), "k=%d", k)
+	}
+}
+
+func BenchmarkFixedBaseMul(b *testing.B) {
+	c := P256()
+	fb, _ := c.EC.NewFixedBase(c.G, c.N.BitLen())
+	k := new(big.Int).Sub(c.N, big.NewInt(int64(12345)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = fb.Mul(k)
+	}
+}
+
+func BenchmarkNewFixedBase(b *testing.B) {
+	c := P256()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.EC.NewFixedBase(c.G, c.N.BitLen())
+	}
+}
@@ -0,0 +1,304 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+// GLV contains the parameters of the Gallant-Lambert-Vanstone endomorphism
+// phi(x, y) = (beta x, y) of the curves y^2 = x^3 + b with q = 1 mod 3, where
+// beta is a non trivial cube root of unity mod q. Over the subgroup of prime
+// order N, phi acts as the multiplication by lambda, a cube root of unity mod
+// N, so k P = k1 P + k2 phi(P) with k = k1 + k2 lambda mod N, where k1 and k2
+// are about half the size of N
+type GLV struct {
+	Beta   *big.Int
+	Lambda *big.Int
+	N      *big.Int
+	// Basis is a reduced basis {(a1, b1), (a2, b2)} of the lattice of the
+	// (x, y) with x + y lambda = 0 mod N
+	Basis [2][2]*big.Int
+
+	bits int // bit length bound of the decomposed scalars
+}
+
+// NewGLV detects the GLV parameters of the curve for the generator g of prime
+// order n, taking the smallest of the two lambda values and the beta that
+// matches it: the curve must have a = 0 and q = 1 mod 3, and n = 1 mod 3. It
+// returns an error when the curve has no such endomorphism, like the curves
+// y^2 = x^3 + b with q = 2 mod 3, which are supersingular
+func NewGLV(ec EC, g Point, n *big.Int) (*GLV, error) {
+	if new(big.Int).Mod(ec.A, ec.Q).Sign() != 0 {
+		return nil, errors.New("glv: the curve must have a = 0")
+	}
+	three := big.NewInt(int64(3))
+	if new(big.Int).Mod(ec.Q, three).Cmp(BigOne) != 0 || new(big.Int).Mod(n, three).Cmp(BigOne) != 0 {
+		return nil, errors.New("glv: q and N must be 1 mod 3")
+	}
+	if !n.ProbablyPrime(20) {
+		return nil, errors.New("glv: N must be prime")
+	}
+	// beta = z^((q - 1) / 3) != 1, and lambda = (-1 + sqrt(-3)) / 2 mod N
+	e := new(big.Int).Div(new(big.Int).Sub(ec.Q, BigOne), three)
+	beta := new(big.Int)
+	for z := big.NewInt(int64(2)); beta.Exp(z, e, ec.Q).Cmp(BigOne) == 0; z.Add(z, BigOne) {
+	}
+	sqrt3, err := ModSqrt(big.NewInt(int64(-3)), n)
+	if err != nil {
+		return nil, err
+	}
+	lambda := new(big.Int).Sub(sqrt3, BigOne)
+	lambda.Mul(lambda, new(big.Int).ModInverse(big.NewInt(int64(2)), n))
+	lambda.Mod(lambda, n)
+	// the other root is -1 - lambda, use the smallest one
+	lambda2 := new(big.Int).Sub(n, BigOne)
+	lambda2.Sub(lambda2, lambda)
+	if lambda2.Cmp(lambda) < 0 {
+		lambda = lambda2
+	}
+
+	// the two values of beta (beta and beta^2) correspond to the two values
+	// of lambda (lambda and lambda^2)
+	lG, err := ec.Mul(g, lambda)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range []*big.Int{beta, new(big.Int).Exp(beta, big.NewInt(int64(2)), ec.Q)} {
+		phiG, err := ec.endomorphism(b, g)
+		if err != nil {
+			return nil, err
+		}
+		if phiG.Equal(lG) {
+			return newGLV(b, lambda, n), nil
+		}
+	}
+	return nil, errors.New("glv: phi(G) != lambda G")
+}
+
+// NewGLVFromParams returns the GLV parameters with the given beta, lambda and
+// lattice basis, checking that beta and lambda are non trivial cube roots of
+// unity, that phi(g) = lambda g, and that the basis generates the lattice. If
+// basis is nil it is computed
+func NewGLVFromParams(ec EC, g Point, n, beta, lambda *big.Int, basis *[2][2]*big.Int) (*GLV, error) {
+	if new(big.Int).Mod(ec.A, ec.Q).Sign() != 0 {
+		return nil, errors.New("glv: the curve must have a = 0")
+	}
+	three := big.NewInt(int64(3))
+	if beta.Cmp(BigOne) == 0 || new(big.Int).Exp(beta, three, ec.Q).Cmp(BigOne) != 0 {
+		return nil, errors.New("glv: beta is not a non trivial cube root of unity mod q")
+	}
+	if lambda.Cmp(BigOne) == 0 || new(big.Int).Exp(lambda, three, n).Cmp(BigOne) != 0 {
+		return nil, errors.New("glv: lambda is not a non trivial cube root of unity mod N")
+	}
+	lG, err := ec.Mul(g, lambda)
+	if err != nil {
+		return nil, err
+	}
+	phiG, err := ec.endomorphism(beta, g)
+	if err != nil {
+		return nil, err
+	}
+	if !phiG.Equal(lG) {
+		return nil, errors.New("glv: phi(G) != lambda G")
+	}
+	if basis == nil {
+		return newGLV(beta, lambda, n), nil
+	}
+	// the vectors are in the lattice, and their determinant is +-N
+	for _, v := range basis {
+		t := new(big.Int).Mul(v[1], lambda)
+		t.Add(t, v[0])
+		if t.Mod(t, n).Sign() != 0 {
+			return nil, errors.New("glv: the basis vectors are not in the lattice")
+		}
+	}
+	det := new(big.Int).Mul(basis[0][0], basis[1][1])
+	det.Sub(det, new(big.Int).Mul(basis[0][1], basis[1][0]))
+	if det.Abs(det).Cmp(n) != 0 {
+		return nil, errors.New("glv: the basis does not generate the lattice")
+	}
+	glv := &GLV{Beta: beta, Lambda: lambda, N: n, Basis: *basis}
+	glv.setBits()
+	return glv, nil
+}
+
+// newGLV returns the GLV parameters computing the basis of the lattice with
+// the extended Euclidean algorithm over N and lambda, which gives the
+// remainders r_i = s_i N + t_i lambda, so (r_i, -t_i) are in the lattice. With
+// l the last index with r_l >= sqrt(N), the basis is (r_(l+1), -t_(l+1)) and
+// the shortest of (r_l, -t_l) and (r_(l+2), -t_(l+2)) (Guide to Elliptic Curve
+// Cryptography, algorithm 3.74)
+func newGLV(beta, lambda, n *big.Int) *GLV {
+	sqrtN := new(big.Int).Sqrt(n)
+	r0, r1 := new(big.Int).Set(n), new(big.Int).Set(lambda)
+	t0, t1 := big.NewInt(int64(0)), big.NewInt(int64(1))
+	for r1.Cmp(sqrtN) >= 0 {
+		q, r := new(big.Int).QuoRem(r0, r1, new(big.Int))
+		t := new(big.Int).Sub(t0, new(big.Int).Mul(q, t1))
+		r0, r1 = r1, r
+		t0, t1 = t1, t
+	}
+	// r0 = r_l, r1 = r_(l+1)
+	q, r2 := new(big.Int).QuoRem(r0, r1, new(big.Int))
+	t2 := new(big.Int).Sub(t0, new(big.Int).Mul(q, t1))
+	v1 := [2]*big.Int{r1, new(big.Int).Neg(t1)}
+	v2 := [2]*big.Int{r0, new(big.Int).Neg(t0)}
+	if normSquare(r2, t2).Cmp(normSquare(r0, t0)) < 0 {
+		v2 = [2]*big.Int{r2, new(big.Int).Neg(t2)}
+	}
+	glv := &GLV{Beta: beta, Lambda: lambda, N: n, Basis: [2][2]*big.Int{v1, v2}}
+	glv.setBits()
+	return glv
+}
+
+// normSquare returns a^2 + b^2
+func normSquare(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, a)
+	return r.Add(r, new(big.Int).Mul(b, b))
+}
+
+// setBits sets the bound of the bit length of the decomposed scalars, which
+// are bounded by the sum of the absolute values of the basis coordinates
+func (glv *GLV) setBits() {
+	glv.bits = 0
+	for _, v := range glv.Basis {
+		for _, c := range v {
+			if c.BitLen() > glv.bits {
+				glv.bits = c.BitLen()
+			}
+		}
+	}
+	glv.bits += 2
+}
+
+// Decompose returns k1 and k2 such that k = k1 + k2 lambda mod N, with |k1|
+// and |k2| about sqrt(N), computed as (k, 0) minus the closest lattice vector,
+// c1 v1 + c2 v2, with c1 = round(b2 k / N) and c2 = round(-b1 k / N)
+func (glv *GLV) Decompose(k *big.Int) (*big.Int, *big.Int) {
+	k = new(big.Int).Mod(k, glv.N)
+	a1, b1 := glv.Basis[0][0], glv.Basis[0][1]
+	a2, b2 := glv.Basis[1][0], glv.Basis[1][1]
+	// solving (k, 0) = c1 v1 + c2 v2 over the rationals, with det = +-N
+	det := new(big.Int).Mul(a1, b2)
+	det.Sub(det, new(big.Int).Mul(a2, b1))
+	c1 := roundDiv(new(big.Int).Mul(b2, k), det)
+	c2 := roundDiv(new(big.Int).Neg(new(big.Int).Mul(b1, k)), det)
+	k1 := new(big.Int).Sub(k, new(big.Int).Mul(c1, a1))
+	k1.Sub(k1, new(big.Int).Mul(c2, a2))
+	k2 := new(big.Int).Neg(new(big.Int).Mul(c1, b1))
+	k2.Sub(k2, new(big.Int).Mul(c2, b2))
+	return k1, k2
+}
+
+// roundDiv returns a / b rounded to the nearest integer
+func roundDiv(a, b *big.Int) *big.Int {
+	if b.Sign() < 0 {
+		a = new(big.Int).Neg(a)
+		b = new(big.Int).Neg(b)
+	}
+	// floor((2a + b) / 2b)
+	n := new(big.Int).Lsh(a, 1)
+	n.Add(n, b)
+	d := new(big.Int).Lsh(b, 1)
+	return n.Div(n, d)
+}
+
+// endomorphism returns (beta x, y)
+func (ec *EC) endomorphism(beta *big.Int, p Point) (Point, error) {
+	if err := ec.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.Equal(ZeroPoint) {
+		return ZeroPoint, nil
+	}
+	x := new(big.Int).Mul(beta, p.X)
+	return Point{x.Mod(x, ec.Q), new(big.Int).Set(p.Y)}, nil
+}
+
+// Endomorphism returns phi(p) = (beta x, y), which is lambda p for the points
+// of the subgroup of order N
+func (ec *EC) Endomorphism(glv *GLV, p Point) (Point, error) {
+	return ec.endomorphism(glv.Beta, p)
+}
+
+// glvSplit returns the points and the non negative scalars of the GLV
+// decomposition of k p, k p = k1 p + k2 phi(p), negating the points of the
+// negative scalars
+func (ec *EC) glvSplit(glv *GLV, p Point, k *big.Int) ([]Point, []*big.Int, error) {
+	k1, k2 := glv.Decompose(k)
+	phiP, err := ec.Endomorphism(glv, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	points := []Point{p, phiP}
+	scalars := []*big.Int{k1, k2}
+	for i := range points {
+		if scalars[i].Sign() < 0 {
+			points[i], err = ec.Neg(points[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			scalars[i] = new(big.Int).Neg(scalars[i])
+		}
+	}
+	return points, scalars, nil
+}
+
+// MulGLV returns k p, with k reduced mod N, using the GLV endomorphism: k p =
+// k1 p + k2 phi(p), computed with Shamir's trick over the half size scalars, so
+// it performs about half of the doublings of Mul. The number of doublings only
+// depends on N, but unlike the ladder of Mul the additions depend on the bits
+// of k1 and k2, so k must be public. The point p must be in the subgroup of
+// order N
+func (ec *EC) MulGLV(glv *GLV, p Point, k *big.Int) (Point, error) {
+	points, scalars, err := ec.glvSplit(glv, p, k)
+	if err != nil {
+		return Point{}, err
+	}
+	c, err := ec.arith()
+	if err != nil {
+		return Point{}, err
+	}
+	bits := glv.bits
+	for _, s := range scalars {
+		if s.BitLen() > bits {
+			bits = s.BitLen()
+		}
+	}
+	return c.toAffine(c.shamir(points, scalars, bits)), nil
+}
+
+// MultiMulGLV returns the sum of scalars[i] x points[i], with the scalars
+// reduced mod N, splitting each multiplication with the GLV endomorphism, so
+// MultiMul is done over twice the points with half size scalars. The points
+// must be in the subgroup of order N
+func (ec *EC) MultiMulGLV(glv *GLV, points []Point, scalars []*big.Int) (Point, error) {
+	if len(points) != len(scalars) {
+		return Point{}, errors.New("the number of points and scalars must be the same")
+	}
+	var allPoints []Point
+	var allScalars []*big.Int
+	for i := range points {
+		if scalars[i] == nil {
+			return Point{}, errors.New("nil scalar")
+		}
+		p, s, err := ec.glvSplit(glv, points[i], scalars[i])
+		if err != nil {
+			return Point{}, err
+		}
+		allPoints = append(allPoints, p...)
+		allScalars = append(allScalars, s...)
+	}
+	return ec.MultiMul(allPoints, allScalars)
+}
+
+// WithGLV returns the curve with the GLV parameters detected with NewGLV, so
+// MultiMul uses the endomorphism
+func (c Curve) WithGLV() (Curve, error) {
+	glv, err := NewGLV(c.EC, c.G, c.N)
+	if err != nil {
+		return Curve{}, err
+	}
+	c.GLV = glv
+	return c, nil
+}
@@ -0,0 +1,259 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/group"
+	"github.com/stretchr/testify/assert"
+)
+
+// glvTestCurves returns curves y^2 = x^3 + b with q = 1 mod 3 and prime order
+func glvTestCurves() []Curve {
+	return []Curve{
+		{Name: "glv103", EC: NewEC(big.NewInt(int64(0)), big.NewInt(int64(5)), big.NewInt(int64(103))),
+			G: Point{big.NewInt(int64(94)), big.NewInt(int64(10))}, N: big.NewInt(int64(97)), H: big.NewInt(int64(1))},
+		{Name: "glv1048627", EC: NewEC(big.NewInt(int64(0)), big.NewInt(int64(3)), big.NewInt(int64(1048627))),
+			G: Point{big.NewInt(int64(782512)), big.NewInt(int64(416479))}, N: big.NewInt(int64(1046827)), H: big.NewInt(int64(1))},
+		{Name: "glv1099511628079", EC: NewEC(big.NewInt(int64(0)), big.NewInt(int64(6)), big.NewInt(int64(1099511628079))),
+			G: Point{big.NewInt(int64(582559832987)), big.NewInt(int64(787506309646))}, N: big.NewInt(int64(1099512457333)), H: big.NewInt(int64(1))},
+	}
+}
+
+func TestNewGLV(t *testing.T) {
+	for _, c := range append(glvTestCurves(), Secp256k1()) {
+		glv, err := NewGLV(c.EC, c.G, c.N)
+		assert.Nil(t, err, c.Name)
+		// phi(G) = lambda G
+		phiG, err := c.EC.Endomorphism(glv, c.G)
+		assert.Nil(t, err)
+		lG, err := c.EC.Mul(c.G, glv.Lambda)
+		assert.Nil(t, err)
+		assert.True(t, phiG.Equal(lG), c.Name)
+		// the detected parameters are valid
+		_, err = NewGLVFromParams(c.EC, c.G, c.N, glv.Beta, glv.Lambda, &glv.Basis)
+		assert.Nil(t, err, c.Name)
+	}
+
+	// the detected secp256k1 parameters are the known ones
+	c := Secp256k1()
+	glv, err := NewGLV(c.EC, c.G, c.N)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, glv.Beta.Cmp(c.GLV.Beta))
+	assert.Equal(t, 0, glv.Lambda.Cmp(c.GLV.Lambda))
+	assert.Equal(t, "3086d221a7d46bcde86c90e49284eb15", glv.Basis[0][0].Text(16))
+	assert.Equal(t, "-e4437ed6010e88286f547fa90abfe4c3", glv.Basis[0][1].Text(16))
+	assert.Equal(t, "114ca50f7a8e2f3f657c1108d9d44cfd8", glv.Basis[1][0].Text(16))
+	assert.Equal(t, "3086d221a7d46bcde86c90e49284eb15", glv.Basis[1][1].Text(16))
+	_, err = NewGLVFromParams(c.EC, c.G, c.N, c.GLV.Beta, c.GLV.Lambda, nil)
+	assert.Nil(t, err)
+
+	// a != 0
+	p256 := P256()
+	_, err = NewGLV(p256.EC, p256.G, p256.N)
+	assert.NotNil(t, err)
+	// q = 2 mod 3: y^2 = x^3 + 7 over F_11 is supersingular
+	ec := NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11)))
+	g := Point{big.NewInt(int64(7)), big.NewInt(int64(3))}
+	_, err = NewGLV(ec, g, big.NewInt(int64(12)))
+	assert.NotNil(t, err)
+	_, err = Toy11().WithGLV()
+	assert.NotNil(t, err)
+}
+
+func TestNewGLVFromParamsErrors(t *testing.T) {
+	c := Secp256k1()
+	// beta = 1
+	_, err := NewGLVFromParams(c.EC, c.G, c.N, big.NewInt(int64(1)), c.GLV.Lambda, nil)
+	assert.NotNil(t, err)
+	// beta^2 with lambda: phi(G) = lambda^2 G
+	beta2 := new(big.Int).Exp(c.GLV.Beta, big.NewInt(int64(2)), c.EC.Q)
+	_, err = NewGLVFromParams(c.EC, c.G, c.N, beta2, c.GLV.Lambda, nil)
+	assert.NotNil(t, err)
+	// a vector out of the lattice
+	basis := c.GLV.Basis
+	basis[0] = [2]*big.Int{new(big.Int).Add(basis[0][0], BigOne), basis[0][1]}
+	_, err = NewGLVFromParams(c.EC, c.G, c.N, c.GLV.Beta, c.GLV.Lambda, &basis)
+	assert.NotNil(t, err)
+	// a basis of a sublattice
+	basis = c.GLV.Basis
+	basis[1] = [2]*big.Int{new(big.Int).Lsh(basis[0][0], 1), new(big.Int).Lsh(basis[0][1], 1)}
+	_, err = NewGLVFromParams(c.EC, c.G, c.N, c.GLV.Beta, c.GLV.Lambda, &basis)
+	assert.NotNil(t, err)
+}
+
+func TestDecompose(t *testing.T) {
+	for _, c := range append(glvTestCurves(), Secp256k1()) {
+		glv, err := NewGLV(c.EC, c.G, c.N)
+		assert.Nil(t, err)
+		ks := []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), new(big.Int).Sub(c.N, BigOne), c.N}
+		for i := 0; i < 20; i++ {
+			k, err := rand.Int(rand.Reader, c.N)
+			assert.Nil(t, err)
+			ks = append(ks, k)
+		}
+		for _, k := range ks {
+			k1, k2 := glv.Decompose(k)
+			// k = k1 + k2 lambda mod N
+			r := new(big.Int).Mul(k2, glv.Lambda)
+			r.Add(r, k1)
+			r.Sub(r, k)
+			assert.Equal(t, 0, r.Mod(r, c.N).Sign(), c.Name)
+			// about half the bits of N
+			assert.True(t, k1.BitLen() <= (c.N.BitLen()+1)/2+1, c.Name)
+			assert.True(t, k2.BitLen() <= (c.N.BitLen()+1)/2+1, c.Name)
+		}
+	}
+}
+
+func TestMulGLV(t *testing.T) {
+	for _, c := range append(glvTestCurves(), Secp256k1()) {
+		glv, err := NewGLV(c.EC, c.G, c.N)
+		assert.Nil(t, err)
+		ks := []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), big.NewInt(int64(-1)), c.N,
+			new(big.Int).Add(c.N, big.NewInt(int64(2)))}
+		for i := 0; i < 10; i++ {
+			k, err := rand.Int(rand.Reader, c.N)
+			assert.Nil(t, err)
+			ks = append(ks, k)
+		}
+		for _, k := range ks {
+			expected, err := c.EC.Mul(c.G, new(big.Int).Mod(k, c.N))
+			assert.Nil(t, err)
+			r, err := c.EC.MulGLV(glv, c.G, k)
+			assert.Nil(t, err)
+			assert.True(t, r.Equal(expected), "%s k=%s", c.Name, k)
+		}
+		r, err := c.EC.MulGLV(glv, ZeroPoint, big.NewInt(int64(5)))
+		assert.Nil(t, err)
+		assert.True(t, r.Equal(ZeroPoint))
+		_, err = c.EC.MulGLV(glv, Point{big.NewInt(int64(1)), big.NewInt(int64(1))}, big.NewInt(int64(5)))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestMultiMulGLV(t *testing.T) {
+	for _, c := range append(glvTestCurves(), Secp256k1()) {
+		glv, err := NewGLV(c.EC, c.G, c.N)
+		assert.Nil(t, err)
+		for _, n := range []int{0, 1, 2, 5} {
+			points := make([]Point, n)
+			scalars := make([]*big.Int, n)
+			for i := 0; i < n; i++ {
+				k, err := rand.Int(rand.Reader, c.N)
+				assert.Nil(t, err)
+				points[i], err = c.EC.Mul(c.G, k)
+				assert.Nil(t, err)
+				scalars[i], err = rand.Int(rand.Reader, c.N)
+				assert.Nil(t, err)
+			}
+			r, err := c.EC.MultiMulGLV(glv, points, scalars)
+			assert.Nil(t, err)
+			assert.True(t, r.Equal(multiMulNaive(t, c.EC, points, scalars)), "%s n=%d", c.Name, n)
+		}
+	}
+}
+
+func TestCurveGLV(t *testing.T) {
+	for _, c := range glvTestCurves() {
+		cGLV, err := c.WithGLV()
+		assert.Nil(t, err)
+		k, err := rand.Int(rand.Reader, c.N)
+		assert.Nil(t, err)
+		p1, err := c.Mul(c.G, k)
+		assert.Nil(t, err)
+		p2, err := cGLV.Mul(c.G, k)
+		assert.Nil(t, err)
+		assert.True(t, c.Equal(p1, p2))
+
+		m1, err := c.MultiMul([]group.Element{c.G, p1}, []*big.Int{k, big.NewInt(int64(-3))})
+		assert.Nil(t, err)
+		m2, err := cGLV.MultiMul([]group.Element{c.G, p1}, []*big.Int{k, big.NewInt(int64(-3))})
+		assert.Nil(t, err)
+		assert.True(t, c.Equal(m1, m2))
+	}
+
+	// Mul does not use GLV, which is variable time
+	c := Secp256k1()
+	c.GLV = &GLV{}
+	k := glvBenchScalar()
+	p, err := c.Mul(c.G, k)
+	assert.Nil(t, err)
+	expected, err := c.EC.MultiMul([]Point{c.G}, []*big.Int{k})
+	assert.Nil(t, err)
+	assert.True(t, c.Equal(expected, p))
+
+	// with PublicMul, Mul uses GLV
+	c = Secp256k1()
+	c.PublicMul = true
+	p, err = c.Mul(c.G, k)
+	assert.Nil(t, err)
+	assert.True(t, c.Equal(expected, p))
+	// (with a wrong lambda the result differs, so the endomorphism is used)
+	c.GLV = newGLV(c.GLV.Beta, big.NewInt(int64(2)), c.N)
+	p, err = c.Mul(c.G, k)
+	assert.Nil(t, err)
+	assert.False(t, c.Equal(expected, p))
+}
+
+// glvBenchScalar returns a scalar with k1 and k2 of full size, unlike N - 12345
+// of BenchmarkMul, which decomposes into k1 = -12345 and k2 = 0
+func glvBenchScalar() *big.Int {
+	return hexToInt("9d3f1b2c4a5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8")
+}
+
+func BenchmarkMulGLV(b *testing.B) {
+	c := Secp256k1()
+	k := glvBenchScalar()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.EC.MulGLV(c.GLV, c.G, k)
+	}
+}
+
+// BenchmarkMulDoubleAndAdd is the plain double-and-add with Jacobian
+// coordinates, to compare with MulGLV, which halves the doublings
+func BenchmarkMulDoubleAndAdd(b *testing.B) {
+	c := Secp256k1()
+	k := glvBenchScalar()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.EC.MultiMul([]Point{c.G}, []*big.Int{k})
+	}
+}
+
+func benchmarkMultiMulGLV(b *testing.B, n int, glv bool) {
+	c := Secp256k1()
+	points := make([]Point, n)
+	scalars := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		k, _ := rand.Int(rand.Reader, c.N)
+		points[i], _ = c.EC.Mul(c.G, k)
+		scalars[i], _ = rand.Int(rand.Reader, c.N)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if glv {
+			_, _ = c.EC.MultiMulGLV(c.GLV, points, scalars)
+			continue
+		}
+		_, _ = c.EC.MultiMul(points, scalars)
+	}
+}
+
+func BenchmarkMultiMulGLV2(b *testing.B) {
+	benchmarkMultiMulGLV(b, 2, true)
+}
+
+func BenchmarkMultiMulNoGLV2(b *testing.B) {
+	benchmarkMultiMulGLV(b, 2, false)
+}
+
+func BenchmarkMultiMulGLV64(b *testing.B) {
+	benchmarkMultiMulGLV(b, 64, true)
+}
+
+func BenchmarkMultiMulNoGLV64(b *testing.B) {
+	benchmarkMultiMulGLV(b, 64, false)
+}
@@ -0,0 +1,206 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/group"
+)
+
+// Curve implements the group.Group interface, the group being the subgroup of
+// order N generated by G, with ecc.Point elements
+var _ group.Group = Curve{}
+
+// Curve implements the group.MultiMuler interface with EC.MultiMul
+var _ group.MultiMuler = Curve{}
+
+// Curve implements the group.FixedBaser interface with FixedBase
+var _ group.FixedBaser = Curve{}
+
+// toPoint returns the Point of the group element e
+func toPoint(e group.Element) (Point, error) {
+	p, ok := e.(Point)
+	if !ok {
+		return Point{}, errors.New("invalid element: not an ecc.Point")
+	}
+	return p, nil
+}
+
+// Identity returns the point at infinity
+func (c Curve) Identity() group.Element {
+	return ZeroPoint
+}
+
+// Generator returns G
+func (c Curve) Generator() group.Element {
+	return c.G
+}
+
+// Order returns N, the order of G
+func (c Curve) Order() *big.Int {
+	return c.N
+}
+
+// Add returns a + b
+func (c Curve) Add(a, b group.Element) (group.Element, error) {
+	p1, err := toPoint(a)
+	if err != nil {
+		return nil, err
+	}
+	p2, err := toPoint(b)
+	if err != nil {
+		return nil, err
+	}
+	return c.EC.Add(p1, p2)
+}
+
+// Neg returns -a
+func (c Curve) Neg(a group.Element) (group.Element, error) {
+	p, err := toPoint(a)
+	if err != nil {
+		return nil, err
+	}
+	return c.EC.Neg(p)
+}
+
+// Mul returns k x a, with k reduced mod N, using the ladder of EC.Mul. As k is
+// usually secret (private keys, nonces), it does not use GLV, whose additions
+// depend on the bits of the scalar, unless the curve opts in with PublicMul,
+// then it uses EC.MulGLV when GLV is set
+func (c Curve) Mul(a group.Element, k *big.Int) (group.Element, error) {
+	p, err := toPoint(a)
+	if err != nil {
+		return nil, err
+	}
+	if c.PublicMul && c.GLV != nil {
+		return c.EC.MulGLV(c.GLV, p, k)
+	}
+	return c.EC.Mul(p, new(big.Int).Mod(k, c.N))
+}
+
+// Equal returns true if a and b are the same point
+func (c Curve) Equal(a, b group.Element) bool {
+	p1, err := toPoint(a)
+	if err != nil || p1.X == nil || p1.Y == nil {
+		return false
+	}
+	p2, err := toPoint(b)
+	if err != nil || p2.X == nil || p2.Y == nil {
+		return false
+	}
+	return p1.Equal(p2)
+}
+
+// Validate returns an error if the point p is not a point of the curve or it is
+// not in the subgroup generated by G. When the cofactor H is unknown (nil), the
+// subgroup membership is always checked
+func (c Curve) Validate(e group.Element) error {
+	p, err := toPoint(e)
+	if err != nil {
+		return err
+	}
+	if err := c.EC.Validate(p); err != nil {
+		return err
+	}
+	// with cofactor 1 all the points of the curve are in the subgroup
+	if (c.H == nil || c.H.Cmp(BigOne) != 0) && !c.EC.InSubgroup(p, c.N) {
+		return errors.New("invalid point: " + p.String() + " not in the subgroup")
+	}
+	return nil
+}
+
+// ValidateElement returns an error if the point p is not a point of the curve,
+// without checking that it is in the subgroup generated by G
+func (c Curve) ValidateElement(e group.Element) error {
+	p, err := toPoint(e)
+	if err != nil {
+		return err
+	}
+	return c.EC.Validate(p)
+}
+
+// Int returns the x coordinate of the point
+func (c Curve) Int(e group.Element) (*big.Int, error) {
+	p, err := toPoint(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.EC.Validate(p); err != nil {
+		return nil, err
+	}
+	if p.Equal(ZeroPoint) {
+		return nil, errors.New("the point at infinity has no x coordinate")
+	}
+	return new(big.Int).Set(p.X), nil
+}
+
+// Marshal returns the SEC1 uncompressed encoding of the point
+func (c Curve) Marshal(e group.Element) ([]byte, error) {
+	p, err := toPoint(e)
+	if err != nil {
+		return nil, err
+	}
+	return c.EC.Marshal(p)
+}
+
+// Unmarshal decodes a SEC1 encoded point, and checks that it is in the
+// subgroup generated by G
+func (c Curve) Unmarshal(b []byte) (group.Element, error) {
+	p, err := c.EC.Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MultiMul returns the sum of scalars[i] x elements[i], with the scalars
+// reduced mod N, using EC.MultiMul, or EC.MultiMulGLV when GLV is set, which
+// requires the elements to be in the subgroup generated by G. Both are variable
+// time, so the scalars must be public, as the ones of the signature
+// verifications. It implements the group.MultiMuler interface
+func (c Curve) MultiMul(elements []group.Element, scalars []*big.Int) (group.Element, error) {
+	if len(elements) != len(scalars) {
+		return nil, errors.New("the number of elements and scalars must be the same")
+	}
+	points := make([]Point, len(elements))
+	ks := make([]*big.Int, len(scalars))
+	for i := range elements {
+		p, err := toPoint(elements[i])
+		if err != nil {
+			return nil, err
+		}
+		points[i] = p
+		ks[i] = new(big.Int).Mod(scalars[i], c.N)
+	}
+	if c.GLV != nil {
+		return c.EC.MultiMulGLV(c.GLV, points, ks)
+	}
+	return c.EC.MultiMul(points, ks)
+}
+
+// curveFixedBase implements the group.FixedBase interface for a Curve
+type curveFixedBase struct {
+	n  *big.Int
+	fb *FixedBase
+}
+
+func (c curveFixedBase) Mul(k *big.Int) (group.Element, error) {
+	return c.fb.Mul(new(big.Int).Mod(k, c.n))
+}
+
+// NewFixedBase precomputes the FixedBase table for the multiplication of base
+// by scalars mod N. It implements the group.FixedBaser interface
+func (c Curve) NewFixedBase(base group.Element) (group.FixedBase, error) {
+	p, err := toPoint(base)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := c.EC.NewFixedBase(p, c.N.BitLen())
+	if err != nil {
+		return nil, err
+	}
+	return curveFixedBase{c.N, fb}, nil
+}
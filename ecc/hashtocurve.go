@@ -0,0 +1,329 @@
+package ecc
+
+import (
+	"crypto"
+	"errors"
+	"math/big"
+
+	// register the hash functions used by the suites
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// HashToCurve hashes messages to points of a Curve following RFC 9380. Curves
+// with A != 0 and B != 0 use the Simplified SWU map, secp256k1 uses it over a
+// 3-isogenous curve, and the other curves (as the toy curves with A = 0) use a
+// try-and-increment map, which is not constant time
+type HashToCurve struct {
+	Curve    Curve
+	DST      []byte
+	HashFunc crypto.Hash
+	K        int // target security level in bits
+
+	z   *big.Int // Z constant of the Simplified SWU map, nil for try-and-increment
+	iso *isogeny
+}
+
+// NewHashToCurve returns the HashToCurve of the curve c with the domain
+// separation tag dst. The hash function is SHA-256, SHA-384 or SHA-512
+// depending on the size of the field, as in the suites of RFC 9380
+func NewHashToCurve(c Curve, dst []byte) (*HashToCurve, error) {
+	if len(dst) == 0 {
+		return nil, errors.New("hash to curve: empty domain separation tag")
+	}
+	h := &HashToCurve{Curve: c, DST: dst, HashFunc: crypto.SHA256, K: 128}
+	switch bits := c.EC.Q.BitLen(); {
+	case bits > 384:
+		h.HashFunc, h.K = crypto.SHA512, 256
+	case bits > 256:
+		h.HashFunc, h.K = crypto.SHA384, 192
+	}
+	switch {
+	case c.EC.A.Sign() != 0 && c.EC.B.Sign() != 0:
+		h.z = findZ(c.EC)
+	case c.Name == "secp256k1":
+		h.iso = secp256k1Isogeny()
+		h.z = findZ(h.iso.ec)
+	}
+	return h, nil
+}
+
+// ExpandMessageXMD implements expand_message_xmd (RFC 9380, section 5.3.1),
+// returning n pseudo-random bytes from msg and the domain separation tag dst
+func ExpandMessageXMD(hash crypto.Hash, msg, dst []byte, n int) ([]byte, error) {
+	if !hash.Available() {
+		return nil, errors.New("expand message: hash function not available")
+	}
+	if len(dst) > 255 {
+		hf := hash.New()
+		hf.Write([]byte("H2C-OVERSIZE-DST-"))
+		hf.Write(dst)
+		dst = hf.Sum(nil)
+	}
+	bLen := hash.Size()
+	ell := (n + bLen - 1) / bLen
+	if ell > 255 || n > 65535 || n <= 0 {
+		return nil, errors.New("expand message: invalid output length")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	hf := hash.New()
+	hf.Write(make([]byte, hash.New().BlockSize()))
+	hf.Write(msg)
+	hf.Write([]byte{byte(n >> 8), byte(n), 0})
+	hf.Write(dstPrime)
+	b0 := hf.Sum(nil)
+
+	hf.Reset()
+	hf.Write(b0)
+	hf.Write([]byte{1})
+	hf.Write(dstPrime)
+	bi := hf.Sum(nil)
+	uniform := append([]byte{}, bi...)
+	for i := 2; i <= ell; i++ {
+		x := make([]byte, bLen)
+		for j := range x {
+			x[j] = b0[j] ^ bi[j]
+		}
+		hf.Reset()
+		hf.Write(x)
+		hf.Write([]byte{byte(i)})
+		hf.Write(dstPrime)
+		bi = hf.Sum(nil)
+		uniform = append(uniform, bi...)
+	}
+	return uniform[:n], nil
+}
+
+// HashToField implements hash_to_field (RFC 9380, section 5.2), returning count
+// elements of F_q
+func (h *HashToCurve) HashToField(msg []byte, count int) ([]*big.Int, error) {
+	q := h.Curve.EC.Q
+	l := (q.BitLen() + h.K + 7) / 8
+	uniform, err := ExpandMessageXMD(h.HashFunc, msg, h.DST, count*l)
+	if err != nil {
+		return nil, err
+	}
+	u := make([]*big.Int, count)
+	for i := range u {
+		u[i] = new(big.Int).SetBytes(uniform[i*l : (i+1)*l])
+		u[i].Mod(u[i], q)
+	}
+	return u, nil
+}
+
+// Hash implements hash_to_curve, the random oracle encoding, which maps two
+// field elements to the curve and adds the resulting points
+func (h *HashToCurve) Hash(msg []byte) (Point, error) {
+	u, err := h.HashToField(msg, 2)
+	if err != nil {
+		return Point{}, err
+	}
+	q0, err := h.MapToCurve(u[0])
+	if err != nil {
+		return Point{}, err
+	}
+	q1, err := h.MapToCurve(u[1])
+	if err != nil {
+		return Point{}, err
+	}
+	r, err := h.Curve.EC.Add(q0, q1)
+	if err != nil {
+		return Point{}, err
+	}
+	return h.clearCofactor(r)
+}
+
+// Encode implements encode_to_curve, the nonuniform encoding, which maps a
+// single field element to the curve
+func (h *HashToCurve) Encode(msg []byte) (Point, error) {
+	u, err := h.HashToField(msg, 1)
+	if err != nil {
+		return Point{}, err
+	}
+	p, err := h.MapToCurve(u[0])
+	if err != nil {
+		return Point{}, err
+	}
+	return h.clearCofactor(p)
+}
+
+// MapToCurve maps the field element u to a point of the curve, without
+// clearing the cofactor
+func (h *HashToCurve) MapToCurve(u *big.Int) (Point, error) {
+	ec := h.Curve.EC
+	if u.Sign() < 0 || u.Cmp(ec.Q) >= 0 {
+		return Point{}, errors.New("hash to curve: u out of the field")
+	}
+	if h.z == nil {
+		return tryAndIncrement(ec, u)
+	}
+	if h.iso == nil {
+		return sswu(ec, h.z, u), nil
+	}
+	return h.iso.mapPoint(sswu(h.iso.ec, h.z, u)), nil
+}
+
+func (h *HashToCurve) clearCofactor(p Point) (Point, error) {
+	if h.Curve.H == nil || h.Curve.H.Cmp(BigOne) == 0 {
+		return p, nil
+	}
+	return h.Curve.EC.Mul(p, h.Curve.H)
+}
+
+// sswu implements the Simplified SWU map (RFC 9380, section 6.6.2), the curve
+// must have A != 0 and B != 0
+func sswu(ec EC, z, u *big.Int) Point {
+	q := ec.Q
+	// tv1 = 1 / (Z^2 u^4 + Z u^2)
+	zu2 := new(big.Int).Mul(u, u)
+	zu2.Mul(zu2, z)
+	zu2.Mod(zu2, q)
+	tv1 := new(big.Int).Mul(zu2, zu2)
+	tv1.Add(tv1, zu2)
+	tv1.Mod(tv1, q)
+	// x1 = (-B / A) (1 + tv1), or B / (Z A) when tv1 = 0
+	x1 := new(big.Int)
+	if tv1.Sign() == 0 {
+		x1.Mul(z, ec.A)
+		x1.ModInverse(x1, q)
+		x1.Mul(x1, ec.B)
+	} else {
+		tv1.ModInverse(tv1, q)
+		x1.ModInverse(ec.A, q)
+		x1.Mul(x1, ec.B)
+		x1.Neg(x1)
+		x1.Mul(x1, tv1.Add(tv1, BigOne))
+	}
+	x1.Mod(x1, q)
+	x, y := x1, curveRHS(ec, x1)
+	if !isSquare(y, q) {
+		// x2 = Z u^2 x1, and g(x2) is a square
+		x = new(big.Int).Mul(zu2, x1)
+		x.Mod(x, q)
+		y = curveRHS(ec, x)
+	}
+	y.ModSqrt(y, q)
+	if y.Bit(0) != u.Bit(0) {
+		y.Sub(q, y).Mod(y, q)
+	}
+	return Point{x, y}
+}
+
+// tryAndIncrement returns the first point with x = u, u+1, ... mod q, with the
+// sign of y chosen by the parity of u
+func tryAndIncrement(ec EC, u *big.Int) (Point, error) {
+	x := new(big.Int).Set(u)
+	for i := new(big.Int); i.Cmp(ec.Q) < 0; i.Add(i, BigOne) {
+		f := curveRHS(ec, x)
+		if isSquare(f, ec.Q) {
+			y := new(big.Int).ModSqrt(f, ec.Q)
+			if y.Bit(0) != u.Bit(0) {
+				y.Sub(ec.Q, y).Mod(y, ec.Q)
+			}
+			return Point{x, y}, nil
+		}
+		x.Add(x, BigOne).Mod(x, ec.Q)
+	}
+	return Point{}, errors.New("hash to curve: no point found")
+}
+
+// findZ returns the Z constant of the Simplified SWU map (RFC 9380, appendix
+// H.2), or nil if there is none
+func findZ(ec EC) *big.Int {
+	q := ec.Q
+	pf := polyField{q}
+	minusOne := new(big.Int).Sub(q, BigOne)
+	half := new(big.Int).Rsh(q, 1)
+	for ctr := big.NewInt(int64(1)); ctr.Cmp(half) <= 0; ctr = new(big.Int).Add(ctr, BigOne) {
+		for _, z := range []*big.Int{ctr, new(big.Int).Sub(q, ctr)} {
+			// Z is non square and Z != -1
+			if isSquare(z, q) || z.Cmp(minusOne) == 0 {
+				continue
+			}
+			// g(x) - Z is irreducible, as a cubic it has no roots: gcd(x^q - x, g - Z) = 1
+			g := pf.trim(poly{new(big.Int).Mod(new(big.Int).Sub(ec.B, z), q), new(big.Int).Mod(ec.A, q), BigZero, BigOne})
+			m := newPolyModulus(pf, g)
+			xq := pf.sub(m.exp(pf.monomial(1), q), pf.monomial(1))
+			if pf.deg(pf.gcd(g, xq)) > 0 {
+				continue
+			}
+			// g(B / (Z A)) is square
+			x := new(big.Int).Mul(z, ec.A)
+			if x.ModInverse(x, q) == nil {
+				continue
+			}
+			x.Mul(x, ec.B).Mod(x, q)
+			if !isSquare(curveRHS(ec, x), q) {
+				continue
+			}
+			return z
+		}
+	}
+	return nil
+}
+
+// isogeny is a rational map from the curve ec to the target curve, with the
+// coefficients of the polynomials from the lower to the higher degree
+type isogeny struct {
+	ec                     EC
+	xNum, xDen, yNum, yDen []*big.Int
+}
+
+// mapPoint returns (xNum(x) / xDen(x), y yNum(x) / yDen(x)), or the point at
+// infinity when a denominator is zero
+func (iso *isogeny) mapPoint(p Point) Point {
+	q := iso.ec.Q
+	eval := func(c []*big.Int) *big.Int {
+		r := new(big.Int)
+		for i := len(c) - 1; i >= 0; i-- {
+			r.Mul(r, p.X)
+			r.Add(r, c[i])
+			r.Mod(r, q)
+		}
+		return r
+	}
+	xDen, yDen := eval(iso.xDen), eval(iso.yDen)
+	if xDen.Sign() == 0 || yDen.Sign() == 0 {
+		return Point{big.NewInt(int64(0)), big.NewInt(int64(0))}
+	}
+	x := new(big.Int).ModInverse(xDen, q)
+	x.Mul(x, eval(iso.xNum)).Mod(x, q)
+	y := new(big.Int).ModInverse(yDen, q)
+	y.Mul(y, eval(iso.yNum))
+	y.Mul(y, p.Y).Mod(y, q)
+	return Point{x, y}
+}
+
+// secp256k1Isogeny returns the 3-isogeny from E': y^2 = x^3 + A'x + 1771 to
+// secp256k1 (RFC 9380, appendix E.1)
+func secp256k1Isogeny() *isogeny {
+	c := func(s ...string) []*big.Int {
+		r := make([]*big.Int, len(s))
+		for i := range s {
+			r[i] = hexToInt(s[i])
+		}
+		return r
+	}
+	return &isogeny{
+		ec: NewEC(
+			hexToInt("3f8731abdd661adca08a5558f0f5d272e953d363cb6f0e5d405447c01a444533"),
+			big.NewInt(int64(1771)),
+			hexToInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")),
+		xNum: c("8e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38daaaaa8c7",
+			"7d3d4c80bc321d5b9f315cea7fd44c5d595d2fc0bf63b92dfff1044f17c6581",
+			"534c328d23f234e6e2a413deca25caece4506144037c40314ecbd0b53d9dd262",
+			"8e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38daaaaa88c"),
+		xDen: c("d35771193d94918a9ca34ccbb7b640dd86cd409542f8487d9fe6b745781eb49b",
+			"edadc6f64383dc1df7c4b2d51b54225406d36b641f5e41bbc52a56612a8c6d14",
+			"1"),
+		yNum: c("4bda12f684bda12f684bda12f684bda12f684bda12f684bda12f684b8e38e23c",
+			"c75e0c32d5cb7c0fa9d0a54b12a0a6d5647ab046d686da6fdffc90fc201d71a3",
+			"29a6194691f91a73715209ef6512e576722830a201be2018a765e85a9ecee931",
+			"2f684bda12f684bda12f684bda12f684bda12f684bda12f684bda12f38e38d84"),
+		yDen: c("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffff93b",
+			"7a06534bb8bdb49fd5e9e6632722c2989467c1bfc8e8d978dfb425d2685c2573",
+			"6484aa716545ca2cf3a70c3fa8fe337e0a3d21162f0d6299a7bf8192bfd2a76f",
+			"1"),
+	}
+}
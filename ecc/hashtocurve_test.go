@@ -0,0 +1,97 @@
+package ecc
+
+import (
+	"crypto"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	// RFC 9380, appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	b, err := ExpandMessageXMD(crypto.SHA256, []byte(""), dst, 0x20)
+	assert.Nil(t, err)
+	assert.Equal(t, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235", hex.EncodeToString(b))
+	b, err = ExpandMessageXMD(crypto.SHA256, []byte("abc"), dst, 0x20)
+	assert.Nil(t, err)
+	assert.Equal(t, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615", hex.EncodeToString(b))
+
+	_, err = ExpandMessageXMD(crypto.SHA256, []byte(""), dst, 256*32)
+	assert.NotNil(t, err)
+}
+
+func TestFindZ(t *testing.T) {
+	// Z values of the suites of RFC 9380, section 8
+	p256 := P256()
+	assert.Equal(t, new(big.Int).Sub(p256.EC.Q, big.NewInt(int64(10))), findZ(p256.EC))
+	iso := secp256k1Isogeny()
+	assert.Equal(t, new(big.Int).Sub(iso.ec.Q, big.NewInt(int64(11))), findZ(iso.ec))
+}
+
+func TestSecp256k1Isogeny(t *testing.T) {
+	iso := secp256k1Isogeny()
+	c := Secp256k1()
+	for i := int64(1); i < 20; i++ {
+		p := sswu(iso.ec, findZ(iso.ec), big.NewInt(i))
+		assert.True(t, iso.ec.IsOnCurve(p))
+		assert.True(t, c.EC.IsOnCurve(iso.mapPoint(p)))
+	}
+}
+
+func TestHashToCurve(t *testing.T) {
+	// RFC 9380, appendices J.1.1 and J.8.1
+	vectors := []struct {
+		curve  Curve
+		dst    string
+		msg    string
+		px, py string
+	}{
+		{P256(), "QUUX-V01-CS02-with-P256_XMD:SHA-256_SSWU_RO_", "",
+			"2c15230b26dbc6fc9a37051158c95b79656e17a1a920b11394ca91c44247d3e4",
+			"8a7a74985cc5c776cdfe4b1f19884970453912e9d31528c060be9ab5c43e8415"},
+		{P256(), "QUUX-V01-CS02-with-P256_XMD:SHA-256_SSWU_RO_", "abc",
+			"0bb8b87485551aa43ed54f009230450b492fead5f1cc91658775dac4a3388a0f",
+			"5c41b3d0731a27a7b14bc0bf0ccded2d8751f83493404c84a88e71ffd424212e"},
+		{Secp256k1(), "QUUX-V01-CS02-with-secp256k1_XMD:SHA-256_SSWU_RO_", "",
+			"c1cae290e291aee617ebaef1be6d73861479c48b841eaba9b7b5852ddfeb1346",
+			"64fa678e07ae116126f08b022a94af6de15985c996c3a91b64c406a960e51067"},
+		{Secp256k1(), "QUUX-V01-CS02-with-secp256k1_XMD:SHA-256_SSWU_RO_", "abc",
+			"3377e01eab42db296b512293120c6cee72b6ecf9f9205760bd9ff11fb3cb2c4b",
+			"7f95890f33efebd1044d382a01b1bee0900fb6116f94688d487c6c7b9c8371f6"},
+	}
+	for _, v := range vectors {
+		h, err := NewHashToCurve(v.curve, []byte(v.dst))
+		assert.Nil(t, err)
+		p, err := h.Hash([]byte(v.msg))
+		assert.Nil(t, err)
+		assert.Equal(t, v.px, hex.EncodeToString(p.X.Bytes()), v.curve.Name+" "+v.msg)
+		assert.Equal(t, v.py, hex.EncodeToString(p.Y.Bytes()), v.curve.Name+" "+v.msg)
+	}
+}
+
+func TestHashToCurveAllCurves(t *testing.T) {
+	for _, name := range CurveNames() {
+		c, err := CurveByName(name)
+		assert.Nil(t, err)
+		h, err := NewHashToCurve(c, []byte("cryptofun-test"))
+		assert.Nil(t, err)
+		for _, msg := range []string{"", "abc", "hello world"} {
+			p, err := h.Hash([]byte(msg))
+			assert.Nil(t, err)
+			assert.True(t, c.EC.IsOnCurve(p), name)
+			p2, err := h.Hash([]byte(msg))
+			assert.Nil(t, err)
+			assert.True(t, p.Equal(p2))
+
+			p, err = h.Encode([]byte(msg))
+			assert.Nil(t, err)
+			assert.True(t, c.EC.IsOnCurve(p), name)
+		}
+	}
+
+	_, err := NewHashToCurve(P256(), nil)
+	assert.NotNil(t, err)
+}
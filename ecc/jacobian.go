@@ -0,0 +1,255 @@
+package ecc
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/arnaucube/cryptofun/field"
+)
+
+// jacobianPoint is a point in Jacobian coordinates, where (X, Y, Z) represents
+// the affine point (X/Z^2, Y/Z^3) and Z = 0 the point at infinity. It is used
+// internally by the point operations to avoid a modular inversion at each
+// addition, the affine coordinates are only computed at the end. The
+// coordinates are field elements, in Montgomery representation.
+//
+// The Jacobian formulas are the fastest ones, but they are not complete: they
+// branch on the point at infinity and on equal or opposite points, so they are
+// only used with public scalars (MultiMul, MulGLV, the precomputation of the
+// tables). The operations on secret scalars use the complete formulas of
+// homogeneousPoint
+type jacobianPoint struct {
+	X field.Element
+	Y field.Element
+	Z field.Element
+}
+
+// curveArith contains the field of the curve and its coefficients as field
+// elements, and implements the point operations in Jacobian and projective
+// coordinates
+type curveArith struct {
+	f         *field.Field
+	a         field.Element
+	b3        field.Element // 3 b, for the complete formulas
+	aIsZero   bool
+	aIsMinus3 bool
+}
+
+// arithCacheSize is the maximum number of curveArith kept by arith
+const arithCacheSize = 64
+
+var (
+	arithCache    sync.Map // arithKey -> *curveArith
+	arithCacheLen atomic.Int64
+)
+
+// arithKey returns the key of the curve in arithCache, the length prefixed
+// bytes of q and a mod q followed by the bytes of b mod q, so two different
+// curves can not have the same key
+func arithKey(q, a, b *big.Int) string {
+	var key []byte
+	for _, v := range [][]byte{q.Bytes(), new(big.Int).Mod(a, q).Bytes()} {
+		key = binary.BigEndian.AppendUint32(key, uint32(len(v)))
+		key = append(key, v...)
+	}
+	return string(append(key, new(big.Int).Mod(b, q).Bytes()...))
+}
+
+// arith returns the curveArith of the curve, which is cached by the values of
+// q, a and b, as building the Montgomery constants of the field is not free.
+// For q bigger than field.MaxBits the field elements are big.Int values
+func (ec *EC) arith() (*curveArith, error) {
+	if ec.Q == nil || ec.A == nil || ec.B == nil {
+		return nil, errors.New("invalid curve: nil parameter")
+	}
+	if ec.Q.Sign() <= 0 {
+		return nil, errors.New("invalid curve: q must be positive")
+	}
+	key := arithKey(ec.Q, ec.A, ec.B)
+	if c, ok := arithCache.Load(key); ok {
+		return c.(*curveArith), nil
+	}
+	f, err := newField(ec.Q)
+	if err != nil {
+		return nil, err
+	}
+	c := &curveArith{f: f}
+	c.a.SetBigInt(f, ec.A)
+	c.b3.SetBigInt(f, new(big.Int).Mul(ec.B, big.NewInt(int64(3))))
+	c.aIsZero = c.a.IsZero()
+	c.aIsMinus3 = f.NewElement(new(big.Int).Add(ec.A, big.NewInt(int64(3)))).IsZero()
+	if arithCacheLen.Add(1) > arithCacheSize {
+		// drop the cached curves, the next calls build them again
+		arithCache.Range(func(k, _ interface{}) bool {
+			arithCache.Delete(k)
+			return true
+		})
+		arithCacheLen.Store(1)
+	}
+	if cached, loaded := arithCache.LoadOrStore(key, c); loaded {
+		return cached.(*curveArith), nil
+	}
+	return c, nil
+}
+
+// infinity returns the point at infinity in Jacobian coordinates
+func (c *curveArith) infinity() jacobianPoint {
+	return jacobianPoint{*c.f.One(), *c.f.One(), *c.f.Zero()}
+}
+
+// toJacobian converts an affine point to Jacobian coordinates
+func (c *curveArith) toJacobian(p Point) jacobianPoint {
+	if p.Equal(ZeroPoint) {
+		return c.infinity()
+	}
+	return jacobianPoint{*c.f.NewElement(p.X), *c.f.NewElement(p.Y), *c.f.One()}
+}
+
+// toAffine converts a point in Jacobian coordinates to affine coordinates
+func (c *curveArith) toAffine(p jacobianPoint) Point {
+	if p.Z.IsZero() {
+		return ZeroPoint
+	}
+	var zInv field.Element
+	zInv.Inverse(&p.Z)
+	return c.toAffineInv(p, &zInv)
+}
+
+// toAffineInv converts a point in Jacobian coordinates to affine coordinates,
+// given the inverse of its Z coordinate
+func (c *curveArith) toAffineInv(p jacobianPoint, zInv *field.Element) Point {
+	var zInv2, x, y field.Element
+	zInv2.Square(zInv)
+	x.Mul(&p.X, &zInv2)
+	y.Mul(&p.Y, zInv2.Mul(&zInv2, zInv))
+	return Point{x.BigInt(), y.BigInt()}
+}
+
+// batchToAffine converts the points to affine coordinates with a single field
+// inversion
+func (c *curveArith) batchToAffine(points []jacobianPoint) []Point {
+	var zs []*field.Element
+	for i := range points {
+		if !points[i].Z.IsZero() {
+			zs = append(zs, new(field.Element).Set(&points[i].Z))
+		}
+	}
+	// no error, as the zero Z are not included
+	_ = field.BatchInverse(zs)
+	r := make([]Point, len(points))
+	for i := range points {
+		if points[i].Z.IsZero() {
+			r[i] = ZeroPoint
+			continue
+		}
+		r[i] = c.toAffineInv(points[i], zs[0])
+		zs = zs[1:]
+	}
+	return r
+}
+
+// double returns 2p, using the dbl-2001-b formulas when a = -3 and the
+// dbl-2007-bl formulas otherwise
+func (c *curveArith) double(p jacobianPoint) jacobianPoint {
+	if p.Z.IsZero() || p.Y.IsZero() {
+		return c.infinity()
+	}
+	var zz, yy, m, t, s, x3, y3, z3 field.Element
+	// zz = Z^2, yy = Y^2
+	zz.Square(&p.Z)
+	yy.Square(&p.Y)
+
+	// m = 3 * X^2 + a * zz^2
+	if c.aIsMinus3 {
+		// a = -3: m = 3 * (X - zz) * (X + zz)
+		m.Sub(&p.X, &zz)
+		m.Mul(&m, t.Add(&p.X, &zz))
+	} else {
+		m.Square(&p.X)
+	}
+	t.Set(&m)
+	m.Add(m.Double(&m), &t)
+	if !c.aIsMinus3 && !c.aIsZero {
+		t.Square(&zz)
+		m.Add(&m, t.Mul(&t, &c.a))
+	}
+
+	// s = 4 * X * yy
+	s.Mul(&p.X, &yy)
+	s.Double(s.Double(&s))
+	// X3 = m^2 - 2s
+	x3.Square(&m)
+	x3.Sub(&x3, t.Double(&s))
+	// Y3 = m * (s - X3) - 8 * yy^2
+	y3.Sub(&s, &x3)
+	y3.Mul(&y3, &m)
+	t.Square(&yy)
+	t.Double(t.Double(t.Double(&t)))
+	y3.Sub(&y3, &t)
+	// Z3 = 2 * Y * Z
+	z3.Mul(&p.Y, &p.Z)
+	z3.Double(&z3)
+	return jacobianPoint{x3, y3, z3}
+}
+
+// add returns p1 + p2, using the add-2007-bl formulas, or the madd-2007-bl
+// formulas when p2 has Z = 1. It handles all the cases, including the point at
+// infinity and the addition of two equal or opposite points
+func (c *curveArith) add(p1, p2 jacobianPoint) jacobianPoint {
+	if p1.Z.IsZero() {
+		return p2
+	}
+	if p2.Z.IsZero() {
+		return p1
+	}
+	var u1, s1, u2, s2, z1z1, z2z2, h, r, hh, hhh, v, t, x3, y3, z3 field.Element
+	// u1 = X1 * Z2^2, s1 = Y1 * Z2^3
+	z2IsOne := p2.Z.IsOne()
+	if z2IsOne {
+		u1.Set(&p1.X)
+		s1.Set(&p1.Y)
+	} else {
+		z2z2.Square(&p2.Z)
+		u1.Mul(&p1.X, &z2z2)
+		s1.Mul(&p1.Y, &p2.Z)
+		s1.Mul(&s1, &z2z2)
+	}
+	// u2 = X2 * Z1^2, s2 = Y2 * Z1^3
+	z1z1.Square(&p1.Z)
+	u2.Mul(&p2.X, &z1z1)
+	s2.Mul(&p2.Y, &p1.Z)
+	s2.Mul(&s2, &z1z1)
+
+	// h = u2 - u1, r = s2 - s1
+	h.Sub(&u2, &u1)
+	r.Sub(&s2, &s1)
+	if h.IsZero() {
+		if r.IsZero() {
+			// p1 == p2
+			return c.double(p1)
+		}
+		// p1 == -p2
+		return c.infinity()
+	}
+	// hh = h^2, hhh = h * hh, v = u1 * hh
+	hh.Square(&h)
+	hhh.Mul(&h, &hh)
+	v.Mul(&u1, &hh)
+	// X3 = r^2 - hhh - 2v
+	x3.Square(&r)
+	x3.Sub(&x3, &hhh)
+	x3.Sub(&x3, t.Double(&v))
+	// Y3 = r * (v - X3) - s1 * hhh
+	y3.Sub(&v, &x3)
+	y3.Mul(&y3, &r)
+	y3.Sub(&y3, t.Mul(&s1, &hhh))
+	// Z3 = Z1 * Z2 * h
+	z3.Mul(&p1.Z, &h)
+	if !z2IsOne {
+		z3.Mul(&z3, &p2.Z)
+	}
+	return jacobianPoint{x3, y3, z3}
+}
@@ -0,0 +1,208 @@
+package ecc
+
+import (
+	"math/big"
+)
+
+// bipoly is a polynomial in X and J over F_q, stored as the coefficients of the
+// powers of X, each of them a polynomial in J
+type bipoly []poly
+
+// canonicalModular returns the canonical modular polynomial Psi_l(X, J) mod q of
+// the odd prime l (Müller), whose roots in X for J = j(tau) are the conjugates
+// of f(tau) = l^s (eta(l tau) / eta(tau))^(2s), with s = 12 / gcd(12, l - 1).
+// It has degree l + 1 in X and v = s (l - 1) / 12 in J, much smaller than the
+// l + 1 of the classical modular polynomial. The power sums of the conjugates
+// are modular functions, so polynomials in j, which are found from the
+// principal part of their q-expansions, and the coefficients of Psi_l are
+// obtained from them with the Newton identities. q must be a prime bigger
+// than (l + 1) v
+func canonicalModular(pf polyField, l int) bipoly {
+	s := modularExponent(l)
+	v := s * (l - 1) / 12
+	prec := (l+1)*v + 1
+
+	// the conjugates f(tau) and (eta((tau + k) / l) / eta(tau))^(2s), where the
+	// later are g(zeta^k u) with u = q^(1/l) and
+	// g = u^-v prod (1 - u^n)^(2s) / prod (1 - u^(ln))^(2s) = u^-v G(u)
+	lPrec := prec/l + 1
+	den := pf.seriesInverse(pf.seriesPow(etaSeries(pf, lPrec), 2*s, lPrec), lPrec)
+	g := pf.seriesMul(pf.seriesPow(etaSeries(pf, prec), 2*s, prec), pf.spread(den, l, prec), prec)
+
+	// powers of q j(q) for the principal parts of the powers of j
+	dMax := (l + 1) * v / l
+	js := jSeries(pf, dMax+1)
+	jPows := []poly{{BigOne}}
+	for k := 1; k <= dMax; k++ {
+		jPows = append(jPows, pf.seriesMul(jPows[k-1], js, dMax+1))
+	}
+
+	// G^i = G^(a + bB) is split in the baby powers G^a and the giant powers
+	// G^(bB), and only the coefficients of G^i that are needed are computed, as
+	// the dot products of the two
+	bSize := 1
+	for bSize*bSize < l+2 {
+		bSize++
+	}
+	baby := []poly{{BigOne}}
+	for a := 1; a < bSize; a++ {
+		baby = append(baby, pf.seriesMul(baby[a-1], g, prec))
+	}
+	gB := pf.seriesMul(baby[bSize-1], g, prec)
+	giant := []poly{{BigOne}}
+	for b := 1; b*bSize <= l+1; b++ {
+		giant = append(giant, pf.seriesMul(giant[b-1], gB, prec))
+	}
+
+	bl := big.NewInt(int64(l))
+	sums := make([]poly, l+2)
+	t := new(big.Int)
+	for i := 1; i <= l+1; i++ {
+		ga, gb := baby[i%bSize], giant[i/bSize]
+		// the sum over k of g(zeta^k u)^i keeps l times the terms of g^i whose
+		// exponent is a multiple of l, f^i only has positive powers of q, so the
+		// coefficient of q^-k is l G^i[iv - kl]
+		d := i * v / l
+		pp := make([]*big.Int, d+1)
+		for k := 0; k <= d; k++ {
+			m := i*v - k*l
+			c := new(big.Int)
+			for j := 0; j <= m && j < len(ga); j++ {
+				c.Add(c, t.Mul(ga[j], coefficient(gb, m-j)))
+			}
+			pp[k] = c.Mul(c, bl)
+		}
+		// subtract the powers of j from the highest one
+		c := make(poly, d+1)
+		for k := d; k >= 0; k-- {
+			c[k] = pp[k].Mod(pp[k], pf.q)
+			for m := 1; m <= k; m++ {
+				pp[k-m].Sub(pp[k-m], t.Mul(c[k], coefficient(jPows[k], m)))
+			}
+		}
+		sums[i] = pf.trim(c)
+	}
+
+	// Newton identities: i e_i = sum_{k=1}^{i} (-1)^(k-1) e_(i-k) p_k, and
+	// Psi_l = sum_i (-1)^i e_i X^(l+1-i)
+	e := make([]poly, l+2)
+	e[0] = poly{BigOne}
+	psi := make(bipoly, l+2)
+	psi[l+1] = e[0]
+	for i := 1; i <= l+1; i++ {
+		acc := poly{}
+		for k := 1; k <= i; k++ {
+			if k%2 == 1 {
+				acc = pf.add(acc, pf.mul(e[i-k], sums[k]))
+			} else {
+				acc = pf.sub(acc, pf.mul(e[i-k], sums[k]))
+			}
+		}
+		e[i] = pf.scale(acc, new(big.Int).ModInverse(big.NewInt(int64(i)), pf.q))
+		if i%2 == 0 {
+			psi[l+1-i] = e[i]
+		} else {
+			psi[l+1-i] = pf.neg(e[i])
+		}
+	}
+	return psi
+}
+
+// modularExponent returns s = 12 / gcd(12, l - 1), the smallest exponent for
+// which s (l - 1) / 12 is an integer
+func modularExponent(l int) int {
+	g := 12
+	for r := (l - 1) % g; r != 0; {
+		g, r = r, g%r
+	}
+	return 12 / g
+}
+
+// coefficient returns the coefficient of x^i of a, which is zero past its
+// degree
+func coefficient(a poly, i int) *big.Int {
+	if i < 0 || i >= len(a) {
+		return BigZero
+	}
+	return a[i]
+}
+
+// etaSeries returns prod_{n>=1} (1 - u^n) mod u^prec, with the pentagonal
+// number theorem: sum_k (-1)^k u^(k (3k - 1) / 2) over all the integers k
+func etaSeries(pf polyField, prec int) poly {
+	r := make(poly, prec)
+	for i := range r {
+		r[i] = BigZero
+	}
+	r[0] = BigOne
+	minusOne := new(big.Int).Sub(pf.q, BigOne)
+	for k := 1; k*(3*k-1)/2 < prec; k++ {
+		c := BigOne
+		if k%2 == 1 {
+			c = minusOne
+		}
+		r[k*(3*k-1)/2] = c
+		if e := k * (3*k + 1) / 2; e < prec {
+			r[e] = c
+		}
+	}
+	return pf.trim(r)
+}
+
+// jSeries returns q j(q) = E4(q)^3 / prod (1 - q^n)^24 mod q^prec, where
+// E4 = 1 + 240 sum sigma_3(n) q^n
+func jSeries(pf polyField, prec int) poly {
+	e4 := make(poly, prec)
+	e4[0] = BigOne
+	for n := 1; n < prec; n++ {
+		sigma := new(big.Int)
+		for d := 1; d <= n; d++ {
+			if n%d == 0 {
+				sigma.Add(sigma, big.NewInt(int64(d*d*d)))
+			}
+		}
+		e4[n] = sigma.Mod(sigma.Mul(sigma, big.NewInt(int64(240))), pf.q)
+	}
+	e4 = pf.trim(e4)
+	num := pf.seriesPow(e4, 3, prec)
+	den := pf.seriesPow(etaSeries(pf, prec), 24, prec)
+	return pf.seriesMul(num, pf.seriesInverse(den, prec), prec)
+}
+
+// seriesMul returns a b mod x^prec
+func (pf polyField) seriesMul(a, b poly, prec int) poly {
+	return pf.truncate(pf.mul(pf.truncate(a, prec), pf.truncate(b, prec)), prec)
+}
+
+// seriesPow returns a^k mod x^prec
+func (pf polyField) seriesPow(a poly, k, prec int) poly {
+	r := poly{BigOne}
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			r = pf.seriesMul(r, a, prec)
+		}
+		if k > 1 {
+			a = pf.seriesMul(a, a, prec)
+		}
+	}
+	return r
+}
+
+// spread returns a(x^k) mod x^prec
+func (pf polyField) spread(a poly, k, prec int) poly {
+	n := (len(a)-1)*k + 1
+	if n > prec {
+		n = prec
+	}
+	if n <= 0 {
+		return poly{}
+	}
+	r := make(poly, n)
+	for i := range r {
+		r[i] = BigZero
+	}
+	for i := 0; i*k < n; i++ {
+		r[i*k] = a[i]
+	}
+	return pf.trim(r)
+}
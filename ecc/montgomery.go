@@ -0,0 +1,405 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/field"
+)
+
+// Montgomery is the data structure for the Montgomery curve
+// (b v^2 = u^3 + a u^2 + u) mod q. As in EC, the ZeroPoint (0, 0) represents the
+// point at infinity, so the point of order two (0, 0) of the curve can not be
+// represented. The arithmetic of the full points is done through the
+// birationally equivalent short Weierstrass curve, and MulU implements the
+// Montgomery ladder over the u coordinate
+type Montgomery struct {
+	A *big.Int
+	B *big.Int
+	Q *big.Int
+}
+
+// NewMontgomery defines a new Montgomery curve (b v^2 = u^3 + a u^2 + u) mod q,
+// where q is a prime number
+func NewMontgomery(a, b, q *big.Int) (m Montgomery) {
+	m.A = a
+	m.B = b
+	m.Q = q
+	return m
+}
+
+// MontgomeryCurve is the data structure for a named Montgomery curve, with its
+// generator point G, the order N of G and the cofactor H
+type MontgomeryCurve struct {
+	Name string
+	M    Montgomery
+	G    Point
+	N    *big.Int
+	H    *big.Int
+}
+
+// Curve25519 returns the curve25519 curve (RFC 7748, section 4.1)
+func Curve25519() MontgomeryCurve {
+	return MontgomeryCurve{
+		Name: "curve25519",
+		M: NewMontgomery(
+			big.NewInt(int64(486662)),
+			big.NewInt(int64(1)),
+			hexToInt("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed")),
+		G: Point{
+			big.NewInt(int64(9)),
+			hexToInt("20ae19a1b8a086b4e01edd2c7748d14c923d4d7e6d7c61b229e9c5a27eced3d9"),
+		},
+		N: hexToInt("1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed"),
+		H: big.NewInt(int64(8)),
+	}
+}
+
+// Curve448 returns the curve448 curve (RFC 7748, section 4.2)
+func Curve448() MontgomeryCurve {
+	return MontgomeryCurve{
+		Name: "curve448",
+		M: NewMontgomery(
+			big.NewInt(int64(156326)),
+			big.NewInt(int64(1)),
+			hexToInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffffffffffffffffffffffffffffffffffffffffffffffffffff")),
+		G: Point{
+			big.NewInt(int64(5)),
+			hexToInt("7d235d1295f5b1f66c98ab6e58326fcecbae5d34f55545d060f75dc28df3f6edb8027e2346430d211312c4b150677af76fd7223d457b5b1a"),
+		},
+		N: hexToInt("3fffffffffffffffffffffffffffffffffffffffffffffffffffffff7cca23e9c44edb49aed63690216cc2728dc58f552378c292ab5844f3"),
+		H: big.NewInt(int64(4)),
+	}
+}
+
+// IsOnCurve returns true if the point p satisfies the curve equation and its
+// coordinates are in [0, q). The point at infinity is considered on the curve
+func (m *Montgomery) IsOnCurve(p Point) bool {
+	if p.X == nil || p.Y == nil {
+		return false
+	}
+	if p.Equal(ZeroPoint) {
+		return true
+	}
+	if p.X.Sign() < 0 || p.X.Cmp(m.Q) >= 0 || p.Y.Sign() < 0 || p.Y.Cmp(m.Q) >= 0 {
+		return false
+	}
+	// b v^2 == u^3 + a u^2 + u mod q
+	l := new(big.Int).Mul(p.Y, p.Y)
+	l.Mul(l, m.B)
+	l.Mod(l, m.Q)
+	r := new(big.Int).Add(p.X, m.A)
+	r.Mul(r, p.X)
+	r.Add(r, BigOne)
+	r.Mul(r, p.X)
+	r.Mod(r, m.Q)
+	return l.Cmp(r) == 0
+}
+
+// Validate returns an error if the point p is not a point of the curve
+func (m *Montgomery) Validate(p Point) error {
+	if p.X == nil || p.Y == nil {
+		return errors.New("invalid point: nil coordinate")
+	}
+	if !m.IsOnCurve(p) {
+		return errors.New("invalid point: " + p.String() + " not on the curve")
+	}
+	return nil
+}
+
+// Neg returns the inverse of the point p, (u, -v)
+func (m *Montgomery) Neg(p Point) (Point, error) {
+	if err := m.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.Equal(ZeroPoint) {
+		return ZeroPoint, nil
+	}
+	v := new(big.Int).Sub(m.Q, p.Y)
+	return Point{new(big.Int).Set(p.X), v.Mod(v, m.Q)}, nil
+}
+
+// Add adds two points p1 and p2
+func (m *Montgomery) Add(p1, p2 Point) (Point, error) {
+	if err := m.Validate(p1); err != nil {
+		return Point{}, err
+	}
+	if err := m.Validate(p2); err != nil {
+		return Point{}, err
+	}
+	ec := m.ToWeierstrass()
+	w1, err := m.PointToWeierstrass(p1)
+	if err != nil {
+		return Point{}, err
+	}
+	w2, err := m.PointToWeierstrass(p2)
+	if err != nil {
+		return Point{}, err
+	}
+	w, err := ec.Add(w1, w2)
+	if err != nil {
+		return Point{}, err
+	}
+	return m.PointFromWeierstrass(w)
+}
+
+// Mul multiplies a point n times on the curve
+func (m *Montgomery) Mul(p Point, n *big.Int) (Point, error) {
+	if err := m.Validate(p); err != nil {
+		return Point{}, err
+	}
+	ec := m.ToWeierstrass()
+	w, err := m.PointToWeierstrass(p)
+	if err != nil {
+		return Point{}, err
+	}
+	w, err = ec.Mul(w, n)
+	if err != nil {
+		return Point{}, err
+	}
+	return m.PointFromWeierstrass(w)
+}
+
+// MulU returns the u coordinate of n times the point with u coordinate u,
+// using the Montgomery ladder of RFC 7748 (section 5) over the bits of n, with
+// the x-only arithmetic of XZ. It does not need the v coordinate, and u does not
+// need to be on the curve (it can be on its quadratic twist). The point at
+// infinity is returned as u = 0, and nil is returned when q is not odd
+func (m *Montgomery) MulU(u, n *big.Int) *big.Int {
+	c, err := m.XZ()
+	if err != nil {
+		return nil
+	}
+	bits := m.Q.BitLen()
+	if n.BitLen() > bits {
+		bits = n.BitLen()
+	}
+	r := c.Ladder(c.Point(u, BigOne), n, bits)
+	// X / Z, where Z^(q-2) = 0 when Z = 0
+	var zInv field.Element
+	zInv.Exp(&r.Z, new(big.Int).Sub(m.Q, big.NewInt(int64(2))))
+	return zInv.Mul(&r.X, &zInv).BigInt()
+}
+
+// inv returns the inverse of x mod q
+func (m *Montgomery) inv(x *big.Int) *big.Int {
+	return new(big.Int).ModInverse(new(big.Int).Mod(x, m.Q), m.Q)
+}
+
+// ToWeierstrass returns the birationally equivalent short Weierstrass curve,
+// with a = (3 - A^2) / (3 B^2) and b = (2 A^3 - 9 A) / (27 B^3)
+func (m *Montgomery) ToWeierstrass() EC {
+	three := big.NewInt(int64(3))
+	bInv := m.inv(m.B)
+	// a = (3 - A^2) / (3 B^2)
+	a := new(big.Int).Mul(m.A, m.A)
+	a.Sub(three, a)
+	a.Mul(a, m.inv(three))
+	a.Mul(a, bInv)
+	a.Mul(a, bInv)
+	a.Mod(a, m.Q)
+	// b = (2 A^3 - 9 A) / (27 B^3)
+	b := new(big.Int).Mul(m.A, m.A)
+	b.Lsh(b, 1)
+	b.Sub(b, big.NewInt(int64(9)))
+	b.Mul(b, m.A)
+	b.Mul(b, m.inv(big.NewInt(int64(27))))
+	b.Mul(b, new(big.Int).Exp(bInv, three, m.Q))
+	b.Mod(b, m.Q)
+	return NewEC(a, b, new(big.Int).Set(m.Q))
+}
+
+// PointToWeierstrass maps the point p to the curve returned by ToWeierstrass,
+// (x, y) = (u/B + A/(3B), v/B)
+func (m *Montgomery) PointToWeierstrass(p Point) (Point, error) {
+	if err := m.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.Equal(ZeroPoint) {
+		return ZeroPoint, nil
+	}
+	bInv := m.inv(m.B)
+	x := new(big.Int).Mul(m.A, m.inv(big.NewInt(int64(3))))
+	x.Add(x, p.X)
+	x.Mul(x, bInv)
+	y := new(big.Int).Mul(p.Y, bInv)
+	return Point{x.Mod(x, m.Q), y.Mod(y, m.Q)}, nil
+}
+
+// PointFromWeierstrass maps a point of the curve returned by ToWeierstrass to
+// the Montgomery curve, (u, v) = (B x - A/3, B y)
+func (m *Montgomery) PointFromWeierstrass(p Point) (Point, error) {
+	ec := m.ToWeierstrass()
+	if err := ec.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.Equal(ZeroPoint) {
+		return ZeroPoint, nil
+	}
+	u := new(big.Int).Mul(m.B, p.X)
+	u.Sub(u, new(big.Int).Mul(m.A, m.inv(big.NewInt(int64(3)))))
+	v := new(big.Int).Mul(m.B, p.Y)
+	return Point{u.Mod(u, m.Q), v.Mod(v, m.Q)}, nil
+}
+
+// ToEdwards returns the birationally equivalent twisted Edwards curve, with
+// a = (A+2)/B and d = (A-2)/B
+func (m *Montgomery) ToEdwards() TwistedEdwards {
+	bInv := m.inv(m.B)
+	a := new(big.Int).Add(m.A, big.NewInt(int64(2)))
+	a.Mul(a, bInv)
+	d := new(big.Int).Sub(m.A, big.NewInt(int64(2)))
+	d.Mul(d, bInv)
+	return NewTwistedEdwards(a.Mod(a, m.Q), d.Mod(d, m.Q), new(big.Int).Set(m.Q))
+}
+
+// PointToEdwards maps the point p to the curve returned by ToEdwards,
+// (x, y) = (u/v, (u-1)/(u+1)). The point at infinity is mapped to the neutral
+// element (0, 1)
+func (m *Montgomery) PointToEdwards(p Point) (Point, error) {
+	if err := m.Validate(p); err != nil {
+		return Point{}, err
+	}
+	if p.Equal(ZeroPoint) {
+		return Point{big.NewInt(int64(0)), big.NewInt(int64(1))}, nil
+	}
+	uPlusOne := new(big.Int).Add(p.X, BigOne)
+	if p.Y.Sign() == 0 || new(big.Int).Mod(uPlusOne, m.Q).Sign() == 0 {
+		// points of order 2 and 4 with v = 0 or u = -1, out of the domain of
+		// the map (the exceptional points of the birational equivalence)
+		return Point{}, errors.New("exceptional point of the birational map: " + p.String())
+	}
+	x := new(big.Int).Mul(p.X, m.inv(p.Y))
+	y := new(big.Int).Sub(p.X, BigOne)
+	y.Mul(y, m.inv(uPlusOne))
+	return Point{x.Mod(x, m.Q), y.Mod(y, m.Q)}, nil
+}
+
+// Weierstrass returns the named curve in short Weierstrass form, with the
+// generator mapped by PointToWeierstrass, so it can be used with the Curve
+// APIs, like the group.Group based schemes
+func (c MontgomeryCurve) Weierstrass() (Curve, error) {
+	g, err := c.M.PointToWeierstrass(c.G)
+	if err != nil {
+		return Curve{}, err
+	}
+	return Curve{
+		Name: c.Name + "-weierstrass",
+		EC:   c.M.ToWeierstrass(),
+		G:    g,
+		N:    c.N,
+		H:    c.H,
+	}, nil
+}
+
+// Marshal returns the little endian encoding of the u coordinate of the point,
+// as in RFC 7748 (section 5)
+func (m *Montgomery) Marshal(p Point) ([]byte, error) {
+	if err := m.Validate(p); err != nil {
+		return nil, err
+	}
+	return m.encodeU(p.X), nil
+}
+
+// Unmarshal decodes the u coordinate encoded with Marshal, and returns the
+// point of the curve with that u coordinate and an even v coordinate. The
+// encoding of RFC 7748 does not contain the sign of v, so the returned point
+// can be the inverse of the encoded point
+func (m *Montgomery) Unmarshal(b []byte) (Point, error) {
+	u, err := m.decodeU(b)
+	if err != nil {
+		return Point{}, err
+	}
+	if u.Cmp(m.Q) >= 0 {
+		return Point{}, errors.New("invalid point encoding: u out of the field")
+	}
+	// v^2 = (u^3 + a u^2 + u) / b
+	v2 := new(big.Int).Add(u, m.A)
+	v2.Mul(v2, u)
+	v2.Add(v2, BigOne)
+	v2.Mul(v2, u)
+	v2.Mul(v2, m.inv(m.B))
+	v2.Mod(v2, m.Q)
+	v := new(big.Int).ModSqrt(v2, m.Q)
+	if v == nil {
+		return Point{}, errors.New("invalid point encoding: no point with u = " + u.String())
+	}
+	if v.Bit(0) == 1 {
+		v.Sub(m.Q, v)
+	}
+	if u.Sign() == 0 {
+		// (0, 0) is the representation of the point at infinity
+		return Point{}, errors.New("invalid point encoding: u = 0")
+	}
+	return Point{u, v}, nil
+}
+
+// byteLen returns the number of bytes of the encoding of the u coordinate
+func (m *Montgomery) byteLen() int {
+	return (m.Q.BitLen() + 7) / 8
+}
+
+// encodeU returns the little endian encoding of u
+func (m *Montgomery) encodeU(u *big.Int) []byte {
+	b := make([]byte, m.byteLen())
+	new(big.Int).Mod(u, m.Q).FillBytes(b)
+	reverse(b)
+	return b
+}
+
+// decodeU decodes a little endian u coordinate. As in RFC 7748, the unused
+// most significant bits of the last byte are ignored
+func (m *Montgomery) decodeU(b []byte) (*big.Int, error) {
+	if len(b) != m.byteLen() {
+		return nil, errors.New("invalid u coordinate encoding: length")
+	}
+	ub := make([]byte, len(b))
+	copy(ub, b)
+	if unused := uint(8*len(b) - m.Q.BitLen()); unused > 0 {
+		ub[len(ub)-1] &= byte(0xff >> unused)
+	}
+	reverse(ub)
+	return new(big.Int).SetBytes(ub), nil
+}
+
+// X25519 returns the X25519 function of RFC 7748 (section 5): the u coordinate
+// of the scalar multiplication over curve25519 of the point with the u
+// coordinate u, with the scalar decoded and clamped from the 32 bytes k
+func X25519(k, u []byte) ([]byte, error) {
+	c := Curve25519()
+	return x(c.M, k, u, func(s []byte) {
+		s[0] &= 248
+		s[31] &= 127
+		s[31] |= 64
+	})
+}
+
+// X448 returns the X448 function of RFC 7748 (section 5), with 56 bytes
+// scalars and u coordinates
+func X448(k, u []byte) ([]byte, error) {
+	c := Curve448()
+	return x(c.M, k, u, func(s []byte) {
+		s[0] &= 252
+		s[55] |= 128
+	})
+}
+
+// x computes the X25519 and X448 functions, with the given scalar clamping
+func x(m Montgomery, k, u []byte, clamp func([]byte)) ([]byte, error) {
+	if len(k) != m.byteLen() {
+		return nil, errors.New("invalid scalar length")
+	}
+	s := make([]byte, len(k))
+	copy(s, k)
+	clamp(s)
+	reverse(s)
+	uInt, err := m.decodeU(u)
+	if err != nil {
+		return nil, err
+	}
+	r := m.MulU(uInt, new(big.Int).SetBytes(s))
+	if r == nil {
+		return nil, errors.New("invalid curve: q must be odd")
+	}
+	return m.encodeU(r), nil
+}
@@ -0,0 +1,144 @@
+package ecc
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/field"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestX25519(t *testing.T) {
+	// RFC 7748, section 5.2
+	k, _ := hex.DecodeString("a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4")
+	u, _ := hex.DecodeString("e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c")
+	r, err := X25519(k, u)
+	assert.Nil(t, err)
+	assert.Equal(t, "c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552", hex.EncodeToString(r))
+
+	// RFC 7748, section 6.1
+	base := make([]byte, 32)
+	base[0] = 9
+	alicePrivK, _ := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	bobPrivK, _ := hex.DecodeString("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	alicePubK, err := X25519(alicePrivK, base)
+	assert.Nil(t, err)
+	assert.Equal(t, "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a", hex.EncodeToString(alicePubK))
+	bobPubK, err := X25519(bobPrivK, base)
+	assert.Nil(t, err)
+	assert.Equal(t, "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f", hex.EncodeToString(bobPubK))
+	k1, err := X25519(alicePrivK, bobPubK)
+	assert.Nil(t, err)
+	k2, err := X25519(bobPrivK, alicePubK)
+	assert.Nil(t, err)
+	assert.Equal(t, "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742", hex.EncodeToString(k1))
+	assert.Equal(t, k1, k2)
+
+	_, err = X25519(k[:31], u)
+	assert.NotNil(t, err)
+}
+
+func TestX448(t *testing.T) {
+	// RFC 7748, section 5.2
+	k, _ := hex.DecodeString("3d262fddf9ec8e88495266fea19a34d28882acef045104d0d1aae121700a779c984c24f8cdd78fbff44943eba368f54b29259a4f1c600ad3")
+	u, _ := hex.DecodeString("06fce640fa3487bfda5f6cf2d5263f8aad88334cbd07437f020f08f9814dc031ddbdc38c19c6da2583fa5429db94ada18aa7a7fb4ef8a086")
+	r, err := X448(k, u)
+	assert.Nil(t, err)
+	assert.Equal(t, "ce3e4ff95a60dc6697da1db1d85e6afbdf79b50a2412d7546d5f239fe14fbaadeb445fc66a01b0779d98223961111e21766282f73dd96b6f", hex.EncodeToString(r))
+}
+
+func TestMontgomeryCurves(t *testing.T) {
+	for _, c := range []MontgomeryCurve{Curve25519(), Curve448()} {
+		assert.True(t, c.M.IsOnCurve(c.G), c.Name)
+		nG, err := c.M.Mul(c.G, c.N)
+		assert.Nil(t, err)
+		assert.True(t, nG.Equal(ZeroPoint), c.Name)
+
+		// the u coordinate of the full point multiplication matches MulU
+		k := big.NewInt(int64(987654321))
+		kG, err := c.M.Mul(c.G, k)
+		assert.Nil(t, err)
+		assert.Equal(t, kG.X, c.M.MulU(c.G.X, k), c.Name)
+
+		g2, err := c.M.Add(c.G, c.G)
+		assert.Nil(t, err)
+		g3, err := c.M.Add(g2, c.G)
+		assert.Nil(t, err)
+		g3Mul, err := c.M.Mul(c.G, big.NewInt(int64(3)))
+		assert.Nil(t, err)
+		assert.True(t, g3.Equal(g3Mul), c.Name)
+		gNeg, err := c.M.Neg(c.G)
+		assert.Nil(t, err)
+		id, err := c.M.Add(c.G, gNeg)
+		assert.Nil(t, err)
+		assert.True(t, id.Equal(ZeroPoint), c.Name)
+
+		// Montgomery -> Edwards -> Montgomery
+		te := c.M.ToEdwards()
+		ge, err := c.M.PointToEdwards(kG)
+		assert.Nil(t, err)
+		assert.True(t, te.IsOnCurve(ge), c.Name)
+		kGm, err := te.PointToMontgomery(ge)
+		assert.Nil(t, err)
+		assert.Equal(t, kG.X, kGm.X, c.Name)
+
+		// encoding of the u coordinate
+		b, err := c.M.Marshal(kG)
+		assert.Nil(t, err)
+		p, err := c.M.Unmarshal(b)
+		assert.Nil(t, err)
+		assert.Equal(t, kG.X, p.X, c.Name)
+		assert.True(t, c.M.IsOnCurve(p), c.Name)
+		assert.Equal(t, uint(0), p.Y.Bit(0))
+
+		cw, err := c.Weierstrass()
+		assert.Nil(t, err)
+		assert.Nil(t, cw.Validate(cw.G))
+	}
+
+	// the generator of curve25519 corresponds to the generator of edwards25519,
+	// over an isomorphic Edwards curve (with a = 486664 instead of -1), with the
+	// same y = 4/5
+	c := Curve25519()
+	ge, err := c.M.PointToEdwards(c.G)
+	assert.Nil(t, err)
+	assert.Equal(t, Edwards25519().G.Y, ge.Y)
+}
+
+func TestMontgomeryXZ(t *testing.T) {
+	c := Curve25519()
+	xz, err := c.M.XZ()
+	assert.Nil(t, err)
+	g := xz.Point(c.G.X, BigOne)
+	g2 := xz.Double(g)
+	g3 := xz.Add(g2, g, g)
+	k := big.NewInt(int64(987654321))
+	for _, e := range []struct {
+		p XZPoint
+		k *big.Int
+	}{{g2, big.NewInt(int64(2))}, {g3, big.NewInt(int64(3))}, {xz.Ladder(g, k, k.BitLen()), k}} {
+		var u field.Element
+		u.Inverse(&e.p.Z)
+		u.Mul(&u, &e.p.X)
+		assert.Equal(t, c.M.MulU(c.G.X, e.k), u.BigInt())
+	}
+	nG := xz.Ladder(g, c.N, c.N.BitLen())
+	assert.True(t, nG.Z.IsZero())
+
+	// modulo the composite n = q p, the points reduced mod q are the ones of
+	// the curve mod q
+	p := big.NewInt(int64(1000003))
+	n := new(big.Int).Mul(c.M.Q, p)
+	a24 := new(big.Int).Add(c.M.A, big.NewInt(int64(2)))
+	a24.Rsh(a24, 2)
+	xzN, err := NewMontgomeryXZ(n, a24)
+	assert.Nil(t, err)
+	r := xzN.Ladder(xzN.Point(c.G.X, BigOne), k, k.BitLen())
+	rQ := xz.Ladder(g, k, k.BitLen())
+	assert.Equal(t, rQ.X.BigInt(), new(big.Int).Mod(r.X.BigInt(), c.M.Q))
+	assert.Equal(t, rQ.Z.BigInt(), new(big.Int).Mod(r.Z.BigInt(), c.M.Q))
+
+	_, err = NewMontgomeryXZ(big.NewInt(int64(10)), a24)
+	assert.NotNil(t, err)
+}
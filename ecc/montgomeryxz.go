@@ -0,0 +1,134 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/field"
+)
+
+// XZPoint is a point of a Montgomery curve in projective (X : Z) coordinates,
+// which represents the u coordinate X/Z, Z = 0 being the point at infinity
+type XZPoint struct {
+	X field.Element
+	Z field.Element
+}
+
+// MontgomeryXZ implements the x-only arithmetic of the Montgomery curve
+// (b v^2 = u^3 + a u^2 + u) mod q over the (X : Z) coordinates, with
+// a24 = (a + 2) / 4, which does not need b nor the v coordinate. q only needs
+// to be odd: over Z/nZ with a composite n the operations are the ones of the
+// curve modulo each prime factor of n, and a Z that is not invertible mod n
+// reveals a factor (as the ECM factorization uses them)
+type MontgomeryXZ struct {
+	F   *field.Field
+	a24 field.Element
+}
+
+// NewMontgomeryXZ returns the x-only arithmetic mod q of the Montgomery curve
+// with a24 = (a + 2) / 4
+func NewMontgomeryXZ(q, a24 *big.Int) (*MontgomeryXZ, error) {
+	f, err := newField(q)
+	if err != nil {
+		return nil, err
+	}
+	c := &MontgomeryXZ{F: f}
+	c.a24.SetBigInt(f, a24)
+	return c, nil
+}
+
+// XZ returns the x-only arithmetic of the curve
+func (m *Montgomery) XZ() (*MontgomeryXZ, error) {
+	if m.Q == nil || m.A == nil {
+		return nil, errors.New("invalid curve: nil parameter")
+	}
+	a24 := new(big.Int).Add(m.A, big.NewInt(int64(2)))
+	inv4 := new(big.Int).ModInverse(big.NewInt(int64(4)), m.Q)
+	if inv4 == nil {
+		return nil, errors.New("invalid curve: q must be odd")
+	}
+	return NewMontgomeryXZ(m.Q, a24.Mul(a24, inv4))
+}
+
+// newField returns the field of the integers mod q, with the big.Int elements
+// of field.NewBigField when q is bigger than field.MaxBits
+func newField(q *big.Int) (*field.Field, error) {
+	f, err := field.NewField(q)
+	if err != nil && q.BitLen() > field.MaxBits {
+		// plain big.Int arithmetic for the fields bigger than the Montgomery
+		// representation supports
+		f, err = field.NewBigField(q)
+	}
+	return f, err
+}
+
+// Point returns the point (x : z)
+func (c *MontgomeryXZ) Point(x, z *big.Int) XZPoint {
+	return XZPoint{*c.F.NewElement(x), *c.F.NewElement(z)}
+}
+
+// Infinity returns the point at infinity (1 : 0)
+func (c *MontgomeryXZ) Infinity() XZPoint {
+	return XZPoint{*c.F.One(), *c.F.Zero()}
+}
+
+// Double returns 2P: X = (X + Z)^2 (X - Z)^2, Z = 4XZ ((X - Z)^2 + a24 4XZ),
+// with 4XZ = (X + Z)^2 - (X - Z)^2
+func (c *MontgomeryXZ) Double(p XZPoint) XZPoint {
+	var s, d, e, x, z field.Element
+	s.Add(&p.X, &p.Z)
+	s.Square(&s)
+	d.Sub(&p.X, &p.Z)
+	d.Square(&d)
+	e.Sub(&s, &d)
+	z.Mul(&c.a24, &e)
+	z.Add(&z, &d)
+	z.Mul(&z, &e)
+	x.Mul(&s, &d)
+	return XZPoint{x, z}
+}
+
+// Add returns P + Q from P, Q and their difference P - Q, which must not be
+// the point at infinity nor have X = 0: X = Z_d (U + V)^2, Z = X_d (U - V)^2,
+// with U = (X_P - Z_P)(X_Q + Z_Q) and V = (X_P + Z_P)(X_Q - Z_Q)
+func (c *MontgomeryXZ) Add(p, q, diff XZPoint) XZPoint {
+	var u, v, t, s, d field.Element
+	u.Sub(&p.X, &p.Z)
+	u.Mul(&u, t.Add(&q.X, &q.Z))
+	v.Add(&p.X, &p.Z)
+	v.Mul(&v, t.Sub(&q.X, &q.Z))
+	s.Add(&u, &v)
+	s.Square(&s)
+	d.Sub(&u, &v)
+	d.Square(&d)
+	s.Mul(&s, &diff.Z)
+	d.Mul(&d, &diff.X)
+	return XZPoint{s, d}
+}
+
+// Ladder returns k P, with the Montgomery ladder over the given number of bits
+// of k (which must not be lower than the bit length of k), where the
+// difference of the two points is always P. For each bit it performs one
+// addition and one doubling, and the points are swapped with field.CondSwap,
+// so there are no branches on the bits of k. P must not have X = 0
+func (c *MontgomeryXZ) Ladder(p XZPoint, k *big.Int, bits int) XZPoint {
+	// r0 = jP, r1 = (j+1)P, where j is the scalar formed by the processed bits
+	r0, r1 := c.Infinity(), p
+	var swap uint64
+	for i := bits - 1; i >= 0; i-- {
+		b := uint64(k.Bit(i))
+		c.condSwap(&r0, &r1, swap^b)
+		swap = b
+		r1 = c.Add(r1, r0, p)
+		r0 = c.Double(r0)
+	}
+	c.condSwap(&r0, &r1, swap)
+	return r0
+}
+
+// condSwap swaps p1 and p2 if b = 1, and leaves them unchanged if b = 0,
+// without branches on b
+func (c *MontgomeryXZ) condSwap(p1, p2 *XZPoint, b uint64) {
+	field.CondSwap(&p1.X, &p2.X, b)
+	field.CondSwap(&p1.Z, &p2.Z, b)
+}
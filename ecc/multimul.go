@@ -0,0 +1,121 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// shamirMaxPoints is the maximum number of points for which MultiMul uses
+// Shamir's trick, which precomputes the 2^n sums of subsets of the points. For
+// more points it uses the Pippenger bucket method
+const shamirMaxPoints = 4
+
+// MultiMul returns the sum of scalars[i] x points[i], computing all the
+// scalar multiplications at the same time: with Shamir's trick (Straus) for up
+// to shamirMaxPoints points, and with the Pippenger bucket method for more. The
+// number of operations depends on the scalars, so it is meant for public
+// scalars, like in the signature verifications
+func (ec *EC) MultiMul(points []Point, scalars []*big.Int) (Point, error) {
+	if len(points) != len(scalars) {
+		return Point{}, errors.New("the number of points and scalars must be the same")
+	}
+	maxBits := 0
+	for i := range points {
+		if err := ec.Validate(points[i]); err != nil {
+			return Point{}, err
+		}
+		if scalars[i] == nil || scalars[i].Sign() < 0 {
+			return Point{}, errors.New("the scalars must be non negative")
+		}
+		if scalars[i].BitLen() > maxBits {
+			maxBits = scalars[i].BitLen()
+		}
+	}
+	c, err := ec.arith()
+	if err != nil {
+		return Point{}, err
+	}
+	if len(points) <= shamirMaxPoints {
+		return c.toAffine(c.shamir(points, scalars, maxBits)), nil
+	}
+	return c.toAffine(c.pippenger(points, scalars, maxBits)), nil
+}
+
+// shamir computes the multi-scalar multiplication with Shamir's trick: after
+// precomputing the sums of all the subsets of points, it performs one doubling
+// and at most one addition for each bit of the scalars
+func (c *curveArith) shamir(points []Point, scalars []*big.Int, maxBits int) jacobianPoint {
+	// table[s] is the sum of the points with index i in the subset s
+	table := make([]jacobianPoint, 1<<uint(len(points)))
+	table[0] = c.infinity()
+	for i := range points {
+		p := c.toJacobian(points[i])
+		for s := 1 << uint(i); s < 1<<uint(i+1); s++ {
+			table[s] = c.add(table[s-1<<uint(i)], p)
+		}
+	}
+	r := c.infinity()
+	for b := maxBits - 1; b >= 0; b-- {
+		r = c.double(r)
+		s := 0
+		for i := range scalars {
+			s |= int(scalars[i].Bit(b)) << uint(i)
+		}
+		if s != 0 {
+			r = c.add(r, table[s])
+		}
+	}
+	return r
+}
+
+// pippengerWindow returns the window size in bits for the Pippenger method over
+// n points, around log2(n), which balances the bucket additions and the
+// aggregation of the buckets
+func pippengerWindow(n int) int {
+	c := bits.Len(uint(n)) - 2
+	if c < 2 {
+		c = 2
+	}
+	return c
+}
+
+// pippenger computes the multi-scalar multiplication with the Pippenger bucket
+// method: the scalars are split in windows of k bits, and for each window the
+// points are accumulated in buckets by the value of their window, so the sum
+// of j x bucket[j] can be computed with 2 * 2^k additions
+func (c *curveArith) pippenger(points []Point, scalars []*big.Int, maxBits int) jacobianPoint {
+	k := pippengerWindow(len(points))
+	jPoints := make([]jacobianPoint, len(points))
+	for i := range points {
+		jPoints[i] = c.toJacobian(points[i])
+	}
+	buckets := make([]jacobianPoint, 1<<uint(k))
+	r := c.infinity()
+	for w := (maxBits - 1) / k * k; w >= 0; w -= k {
+		for j := 0; j < k; j++ {
+			r = c.double(r)
+		}
+		for j := range buckets {
+			buckets[j] = c.infinity()
+		}
+		for i := range scalars {
+			v := 0
+			for j := k - 1; j >= 0; j-- {
+				v = v<<1 | int(scalars[i].Bit(w+j))
+			}
+			if v != 0 {
+				buckets[v] = c.add(buckets[v], jPoints[i])
+			}
+		}
+		// sum of j x buckets[j], as the sum of the running sums from the top
+		running := c.infinity()
+		sum := c.infinity()
+		for j := len(buckets) - 1; j > 0; j-- {
+			running = c.add(running, buckets[j])
+			sum = c.add(sum, running)
+		}
+		r = c.add(r, sum)
+	}
+	return r
+}
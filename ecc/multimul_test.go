@@ -0,0 +1,96 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// multiMulNaive computes the sum of scalars[i] x points[i] with Mul and Add
+func multiMulNaive(t *testing.T, ec EC, points []Point, scalars []*big.Int) Point {
+	r := ZeroPoint
+	for i := range points {
+		p, err := ec.Mul(points[i], scalars[i])
+		assert.Nil(t, err)
+		r, err = ec.Add(r, p)
+		assert.Nil(t, err)
+	}
+	return r
+}
+
+func TestMultiMul(t *testing.T) {
+	for _, c := range []Curve{P256(), Secp256k1(), Toy29()} {
+		for _, n := range []int{0, 1, 2, 3, 4, 5, 9, 40} {
+			points := make([]Point, n)
+			scalars := make([]*big.Int, n)
+			for i := 0; i < n; i++ {
+				k, err := rand.Int(rand.Reader, c.N)
+				assert.Nil(t, err)
+				points[i], err = c.EC.Mul(c.G, k)
+				assert.Nil(t, err)
+				scalars[i], err = rand.Int(rand.Reader, c.N)
+				assert.Nil(t, err)
+			}
+			if n > 2 {
+				// repeated points, the point at infinity and zero scalars
+				points[1] = points[0]
+				points[2] = ZeroPoint
+				scalars[n-1] = big.NewInt(int64(0))
+			}
+			r, err := c.EC.MultiMul(points, scalars)
+			assert.Nil(t, err)
+			assert.True(t, r.Equal(multiMulNaive(t, c.EC, points, scalars)), "%s n=%d", c.Name, n)
+		}
+	}
+}
+
+func TestMultiMulErrors(t *testing.T) {
+	c := P256()
+	_, err := c.EC.MultiMul([]Point{c.G}, []*big.Int{})
+	assert.NotNil(t, err)
+	_, err = c.EC.MultiMul([]Point{c.G}, []*big.Int{big.NewInt(int64(-1))})
+	assert.NotNil(t, err)
+	_, err = c.EC.MultiMul([]Point{{big.NewInt(int64(1)), big.NewInt(int64(1))}}, []*big.Int{big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+}
+
+func benchmarkMultiMul(b *testing.B, n int, multi bool) {
+	c := P256()
+	points := make([]Point, n)
+	scalars := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		k, _ := rand.Int(rand.Reader, c.N)
+		points[i], _ = c.EC.Mul(c.G, k)
+		scalars[i], _ = rand.Int(rand.Reader, c.N)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if multi {
+			_, _ = c.EC.MultiMul(points, scalars)
+			continue
+		}
+		r := ZeroPoint
+		for j := range points {
+			p, _ := c.EC.Mul(points[j], scalars[j])
+			r, _ = c.EC.Add(r, p)
+		}
+	}
+}
+
+func BenchmarkMultiMul2(b *testing.B) {
+	benchmarkMultiMul(b, 2, true)
+}
+
+func BenchmarkMultiMul2Separate(b *testing.B) {
+	benchmarkMultiMul(b, 2, false)
+}
+
+func BenchmarkMultiMul64(b *testing.B) {
+	benchmarkMultiMul(b, 64, true)
+}
+
+func BenchmarkMultiMul64Separate(b *testing.B) {
+	benchmarkMultiMul(b, 64, false)
+}
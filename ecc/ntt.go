@@ -0,0 +1,262 @@
+package ecc
+
+import (
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// nttMaxLog is the log2 of the maximum length of the number theoretic
+// transforms, the primes p = c 2^nttMaxLog + 1 have roots of unity of that order
+const nttMaxLog = 24
+
+// nttPrime is a prime p < 2^62 with the constants of the Montgomery
+// multiplication (mul) and of the transforms
+type nttPrime struct {
+	p    uint64
+	pInv uint64   // -p^-1 mod 2^64
+	root *big.Int // root of unity of order 2^nttMaxLog
+	pows []uint64 // 2^(UintSize j + 64) mod p, to reduce the words of a big.Int
+
+	mu       sync.Mutex
+	twiddles map[int][]uint64 // the powers of the root of unity of each length
+}
+
+var (
+	nttMu     sync.Mutex
+	nttPrimes []*nttPrime
+)
+
+// nttPrimesFor returns enough primes for the integers of the given bit length
+func nttPrimesFor(bitLen int) []*nttPrime {
+	nttMu.Lock()
+	defer nttMu.Unlock()
+	// the primes are searched downwards from 2^62, so all of them are > 2^61
+	k := (bitLen + 60) / 61
+	c := uint64(1)<<(62-nttMaxLog) - 1
+	if len(nttPrimes) > 0 {
+		c = nttPrimes[len(nttPrimes)-1].p>>nttMaxLog - 1
+	}
+	for ; len(nttPrimes) < k; c-- {
+		p := c<<nttMaxLog + 1
+		if new(big.Int).SetUint64(p).ProbablyPrime(20) {
+			nttPrimes = append(nttPrimes, newNTTPrime(p))
+		}
+	}
+	return nttPrimes[:k]
+}
+
+func newNTTPrime(p uint64) *nttPrime {
+	pr := &nttPrime{p: p, twiddles: make(map[int][]uint64)}
+	// Newton iteration for p^-1 mod 2^64, each step doubles the correct bits
+	inv := p
+	for i := 0; i < 5; i++ {
+		inv *= 2 - p*inv
+	}
+	pr.pInv = -inv
+
+	bp := new(big.Int).SetUint64(p)
+	c := new(big.Int).SetUint64(p >> nttMaxLog)
+	half := new(big.Int).Lsh(BigOne, nttMaxLog-1)
+	minusOne := new(big.Int).Sub(bp, BigOne)
+	for x := int64(2); ; x++ {
+		// x^c has order 2^nttMaxLog when its 2^(nttMaxLog-1) power is -1
+		w := new(big.Int).Exp(big.NewInt(x), c, bp)
+		if new(big.Int).Exp(w, half, bp).Cmp(minusOne) == 0 {
+			pr.root = w
+			break
+		}
+	}
+	return pr
+}
+
+// pow returns 2^(UintSize j + 64) mod p
+func (pr *nttPrime) pow(j int) uint64 {
+	for len(pr.pows) <= j {
+		w := new(big.Int).Lsh(BigOne, uint(bits.UintSize*len(pr.pows)+64))
+		pr.pows = append(pr.pows, w.Mod(w, new(big.Int).SetUint64(pr.p)).Uint64())
+	}
+	return pr.pows[j]
+}
+
+// mul returns a b 2^-64 mod p, a b must be lower than p 2^64. With the
+// twiddles multiplied by 2^64 (Montgomery form), the coefficients stay in the
+// normal form
+func (pr *nttPrime) mul(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	m := lo * pr.pInv
+	mHi, mLo := bits.Mul64(m, pr.p)
+	_, carry := bits.Add64(lo, mLo, 0)
+	t := hi + mHi + carry
+	if t >= pr.p {
+		t -= pr.p
+	}
+	return t
+}
+
+// twiddlesFor returns the twiddles of the transforms of length n, where
+// tw[h + i] = w_2h^i 2^64 mod p, for i < h, and w_2h is the root of unity of
+// order 2h, so the ones of each step are contiguous
+func (pr *nttPrime) twiddlesFor(n int) []uint64 {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if tw, ok := pr.twiddles[n]; ok {
+		return tw
+	}
+	bp := new(big.Int).SetUint64(pr.p)
+	tw := make([]uint64, n)
+	r := new(big.Int).Lsh(BigOne, 64)
+	one := r.Mod(r, bp).Uint64()
+	for h := 1; h < n; h <<= 1 {
+		w := new(big.Int).Exp(pr.root, big.NewInt(int64((1<<nttMaxLog)/(2*h))), bp)
+		wMont := w.Mod(w.Lsh(w, 64), bp).Uint64()
+		tw[h] = one
+		for i := 1; i < h; i++ {
+			tw[h+i] = pr.mul(tw[h+i-1], wMont)
+		}
+	}
+	pr.twiddles[n] = tw
+	return tw
+}
+
+// reduce returns the coefficients of a mod p, padded with zeros to n
+func (pr *nttPrime) reduce(a poly, n int) []uint64 {
+	r := make([]uint64, n)
+	for i, c := range a {
+		var s uint64
+		for j, w := range c.Bits() {
+			s += pr.mul(uint64(w), pr.pow(j))
+			if s >= pr.p {
+				s -= pr.p
+			}
+		}
+		r[i] = s
+	}
+	return r
+}
+
+// transform computes the number theoretic transform of a in place, len(a) is a
+// power of two
+func (pr *nttPrime) transform(a []uint64) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	tw := pr.twiddlesFor(n)
+	p := pr.p
+	for half := 1; half < n; half <<= 1 {
+		w := tw[half : 2*half]
+		for start := 0; start < n; start += 2 * half {
+			x := a[start : start+half]
+			y := a[start+half : start+2*half]
+			for j := range x {
+				u := x[j]
+				v := pr.mul(y[j], w[j])
+				s := u + v
+				if s >= p {
+					s -= p
+				}
+				if u < v {
+					u += p
+				}
+				x[j] = s
+				y[j] = u - v
+			}
+		}
+	}
+}
+
+// mulNTT multiplies a and b with the number theoretic transforms modulo
+// several 62 bit primes, whose product is bigger than the coefficients of the
+// product over the integers, which are then recovered with the chinese
+// remainder theorem (Garner's algorithm)
+func (pf polyField) mulNTT(a, b poly) poly {
+	rLen := len(a) + len(b) - 1
+	n := 1
+	for n < rLen {
+		n <<= 1
+	}
+	minLen := min(len(a), len(b))
+	primes := nttPrimesFor(2*pf.q.BitLen() + bits.Len(uint(minLen)))
+	square := len(a) == len(b) && &a[0] == &b[0]
+	res := make([][]uint64, len(primes))
+	for i, pr := range primes {
+		fa := pr.reduce(a, n)
+		pr.transform(fa)
+		fb := fa
+		if !square {
+			fb = pr.reduce(b, n)
+			pr.transform(fb)
+		}
+		for j := range fa {
+			fa[j] = pr.mul(fa[j], fb[j])
+		}
+		// the inverse transform is the transform with the indexes negated,
+		// then multiplied by 2^128 / n, as mul divided by 2^64 twice
+		pr.transform(fa)
+		for j, k := 1, n-1; j < k; j, k = j+1, k-1 {
+			fa[j], fa[k] = fa[k], fa[j]
+		}
+		bp := new(big.Int).SetUint64(pr.p)
+		scale := new(big.Int).Lsh(BigOne, 128)
+		scale.Mul(scale, new(big.Int).ModInverse(big.NewInt(int64(n)), bp))
+		sc := scale.Mod(scale, bp).Uint64()
+		for j := 0; j < rLen; j++ {
+			fa[j] = pr.mul(fa[j], sc)
+		}
+		res[i] = fa
+	}
+
+	// Garner: x = v_0 + v_1 p_0 + v_2 p_0 p_1 + ..., where
+	// v_i = (r_i - v_0 - v_1 p_0 - ...) / (p_0 ... p_(i-1)) mod p_i
+	k := len(primes)
+	// inv[j][i] = p_j^-1 2^64 mod p_i
+	inv := make([][]uint64, k)
+	for j := range inv {
+		inv[j] = make([]uint64, k)
+		for i := j + 1; i < k; i++ {
+			bp := new(big.Int).SetUint64(primes[i].p)
+			v := new(big.Int).ModInverse(new(big.Int).SetUint64(primes[j].p), bp)
+			v.Lsh(v, 64)
+			inv[j][i] = v.Mod(v, bp).Uint64()
+		}
+	}
+	pBig := make([]*big.Int, k)
+	for i, pr := range primes {
+		pBig[i] = new(big.Int).SetUint64(pr.p)
+	}
+	r := make(poly, rLen)
+	v := make([]uint64, k)
+	t := new(big.Int)
+	for c := 0; c < rLen; c++ {
+		for i, pr := range primes {
+			x := res[i][c]
+			for j := 0; j < i; j++ {
+				// (x - v_j) p_j^-1 mod p_i, v_j < p_j < 2 p_i
+				vj := v[j]
+				if vj >= pr.p {
+					vj -= pr.p
+				}
+				if x < vj {
+					x += pr.p
+				}
+				x = pr.mul(x-vj, inv[j][i])
+			}
+			v[i] = x
+		}
+		x := new(big.Int).SetUint64(v[k-1])
+		for i := k - 2; i >= 0; i-- {
+			x.Mul(x, pBig[i])
+			x.Add(x, t.SetUint64(v[i]))
+		}
+		r[c] = x.Mod(x, pf.q)
+	}
+	return pf.trim(r)
+}
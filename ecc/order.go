@@ -0,0 +1,188 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/arnaucube/cryptofun/prime"
+)
+
+// bsgsMaxBits is the maximum bit length of q for which Order searches a multiple
+// of the order of the point with baby-step giant-step, which takes O(q^(1/4))
+// steps, instead of computing the number of points of the curve
+const bsgsMaxBits = 64
+
+// bsgsMaxMatches is the maximum number of solutions returned by bsgs, there are
+// more than one only when the order of the step point is smaller than the width
+// of the search
+const bsgsMaxMatches = 64
+
+// bsgsBatch is the number of giant steps converted to affine coordinates with a
+// single inversion
+const bsgsBatch = 256
+
+// orderFactorSteps is the limit of iterations of the rho method when factoring
+// a multiple of the order, enough for the prime factors of up to ~40 bits
+// besides the biggest one
+const orderFactorSteps = 1 << 20
+
+// mulPublic returns k p with the variable time multi-scalar multiplication,
+// for the public scalars of the order computations
+func (ec *EC) mulPublic(p Point, k *big.Int) (Point, error) {
+	return ec.MultiMul([]Point{p}, []*big.Int{k})
+}
+
+// bsgs returns the k in [0, width) with k s = target, in ascending order, using
+// baby-step giant-step: k = i m + j, with the baby steps j s for j in [0, m)
+// stored by their x coordinate, and the giant steps target - i m s. When the
+// order of s is small there are many solutions, only the first bsgsMaxMatches
+// are returned
+func (ec *EC) bsgs(target, s Point, width *big.Int) ([]*big.Int, error) {
+	c, err := ec.arith()
+	if err != nil {
+		return nil, err
+	}
+	m := new(big.Int).Sqrt(width)
+	m.Add(m, BigOne)
+	if !m.IsInt64() || m.Int64() > 1<<32 {
+		return nil, errors.New("bsgs: the search interval is too big")
+	}
+	mi := m.Int64()
+
+	// baby steps
+	sJ := c.toJacobian(s)
+	baby := make([]jacobianPoint, mi)
+	baby[0] = c.infinity()
+	for j := int64(1); j < mi; j++ {
+		baby[j] = c.add(baby[j-1], sJ)
+	}
+	babyPoints := c.batchToAffine(baby)
+	table := make(map[string][]int64, mi)
+	var babyInf []int64
+	for j, p := range babyPoints {
+		if baby[j].Z.IsZero() {
+			babyInf = append(babyInf, int64(j))
+			continue
+		}
+		key := string(p.X.Bytes())
+		table[key] = append(table[key], int64(j))
+	}
+
+	// giant steps, -m s = -((m - 1) s + s)
+	step := c.add(baby[mi-1], sJ)
+	step.Y.Neg(&step.Y)
+	var matches []*big.Int
+	giant := c.toJacobian(target)
+	giants := make([]jacobianPoint, 0, bsgsBatch)
+	steps := new(big.Int).Quo(width, m).Int64() + 1
+	for i0 := int64(0); i0 < steps && len(matches) < bsgsMaxMatches; i0 += bsgsBatch {
+		giants = giants[:0]
+		for i := i0; i < steps && i < i0+bsgsBatch; i++ {
+			giants = append(giants, giant)
+			giant = c.add(giant, step)
+		}
+		for n, p := range c.batchToAffine(giants) {
+			var js []int64
+			if giants[n].Z.IsZero() {
+				js = babyInf
+			} else {
+				for _, j := range table[string(p.X.Bytes())] {
+					if p.Y.Cmp(babyPoints[j].Y) == 0 {
+						js = append(js, j)
+					}
+				}
+			}
+			for _, j := range js {
+				k := big.NewInt(i0 + int64(n))
+				k.Mul(k, m)
+				k.Add(k, big.NewInt(j))
+				if k.Cmp(width) < 0 {
+					matches = append(matches, k)
+				}
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Cmp(matches[j]) < 0
+	})
+	if len(matches) > bsgsMaxMatches {
+		matches = matches[:bsgsMaxMatches]
+	}
+	return matches, nil
+}
+
+// orderMultiple returns a positive n where nG = O, searching it in the Hasse
+// interval [q + 1 - 2 sqrt(q), q + 1 + 2 sqrt(q)], that contains the number of
+// points of the curve
+func (ec *EC) orderMultiple(g Point) (*big.Int, error) {
+	// low = q + 1 - floor(2 sqrt(q)), width = 2 floor(2 sqrt(q)) + 1
+	s := new(big.Int).Sqrt(new(big.Int).Lsh(ec.Q, 2))
+	low := new(big.Int).Add(ec.Q, BigOne)
+	low.Sub(low, s)
+	width := new(big.Int).Lsh(s, 1)
+	width.Add(width, BigOne)
+
+	// (low + k) G = O, so k G = -(low G)
+	lowG, err := ec.mulPublic(g, low)
+	if err != nil {
+		return nil, err
+	}
+	target, err := ec.Neg(lowG)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := ec.bsgs(target, g, width)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range matches {
+		if n := new(big.Int).Add(low, k); n.Sign() > 0 {
+			return n, nil
+		}
+	}
+	return nil, errors.New("invalid order")
+}
+
+// reduceOrder returns the order of g given a multiple m of it, dividing m by
+// its prime factors p while (m/p)G = O. The factorization of m is limited to
+// orderFactorSteps iterations of the rho method, when m has a composite part
+// that can not be factored and is needed by the order an error is returned
+func (ec *EC) reduceOrder(g Point, m *big.Int) (*big.Int, error) {
+	mG, err := ec.mulPublic(g, m)
+	if err != nil {
+		return BigZero, err
+	}
+	if !mG.Equal(ZeroPoint) {
+		return BigZero, errors.New("invalid order")
+	}
+	order := new(big.Int).Set(m)
+	factors, rest := prime.FactorLimit(m, orderFactorSteps)
+	if rest.Cmp(BigOne) != 0 {
+		// the composite part is not needed when (m/rest)G = O
+		quo := new(big.Int).Quo(order, rest)
+		qG, err := ec.mulPublic(g, quo)
+		if err != nil {
+			return BigZero, err
+		}
+		if !qG.Equal(ZeroPoint) {
+			return BigZero, errors.New("the order can not be computed: " + rest.String() +
+				" is a factor of the number of points with more than one big prime factor")
+		}
+		order = quo
+	}
+	for _, p := range factors {
+		quo, rem := new(big.Int).QuoRem(order, p, new(big.Int))
+		if rem.Sign() != 0 {
+			continue
+		}
+		qG, err := ec.mulPublic(g, quo)
+		if err != nil {
+			return BigZero, err
+		}
+		if qG.Equal(ZeroPoint) {
+			order = quo
+		}
+	}
+	return order, nil
+}
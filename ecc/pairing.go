@@ -0,0 +1,411 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// maxEmbeddingDegree is the maximum embedding degree supported by NewPairing,
+// as the arithmetic of the extension field is done with generic polynomials
+const maxEmbeddingDegree = 12
+
+// ExtPoint is a point of the elliptic curve with the coordinates in the
+// extension field F_(q^k). Unlike Point, the point at infinity is a flag, as
+// (0, 0) can be an affine point of the curve
+type ExtPoint struct {
+	X, Y ExtElement
+	Inf  bool
+}
+
+// Pairing computes the Weil and the reduced Tate pairings of the N-torsion
+// points of the curve EC over F_q, with Miller's algorithm over the extension
+// F_(q^k), where k is the embedding degree: the smallest k such that N divides
+// q^k - 1
+type Pairing struct {
+	EC EC
+	N  *big.Int
+	K  int
+	F  *ExtField
+
+	a, b     ExtElement
+	cofactor *big.Int // #E(F_(q^k)) without the N factors
+	nPow     int      // exponent of N in #E(F_(q^k))
+}
+
+// EmbeddingDegree returns the smallest k <= max such that n divides q^k - 1, or
+// an error if there is none
+func EmbeddingDegree(q, n *big.Int, max int) (int, error) {
+	qk := new(big.Int).Mod(q, n)
+	r := new(big.Int).Set(qk)
+	for k := 1; k <= max; k++ {
+		if r.Cmp(BigOne) == 0 {
+			return k, nil
+		}
+		r.Mul(r, qk)
+		r.Mod(r, n)
+	}
+	return 0, errors.New("embedding degree bigger than " + strconv.Itoa(max))
+}
+
+// NewPairing returns the pairing of the N-torsion points of the curve, where N
+// is a prime dividing the number of points of the curve over F_q, coprime with q
+// and with an embedding degree of at most 12. Supersingular curves, such as
+// y^2 = x^3 + x with q = 3 mod 4, have embedding degree 2
+func NewPairing(ec EC, n *big.Int) (*Pairing, error) {
+	if !n.ProbablyPrime(20) {
+		return nil, errors.New("the order of the pairing groups must be prime")
+	}
+	if n.Cmp(ec.Q) == 0 {
+		return nil, errors.New("the order of the pairing groups must be different from q")
+	}
+	card, err := ec.Cardinality()
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).Mod(card, n).Sign() != 0 {
+		return nil, errors.New(n.String() + " does not divide the number of points of the curve")
+	}
+	k, err := EmbeddingDegree(ec.Q, n, maxEmbeddingDegree)
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewExtField(ec.Q, k)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pairing{EC: ec, N: n, K: k, F: f, a: f.Element(ec.A), b: f.Element(ec.B)}
+
+	// #E(F_(q^k)) = q^k + 1 - V_k, with the trace t = q + 1 - #E(F_q), and
+	// V_0 = 2, V_1 = t, V_i = t V_(i-1) - q V_(i-2)
+	t := new(big.Int).Sub(new(big.Int).Add(ec.Q, BigOne), card)
+	v0, v1 := big.NewInt(int64(2)), t
+	for i := 1; i < k; i++ {
+		v2 := new(big.Int).Mul(t, v1)
+		v2.Sub(v2, new(big.Int).Mul(ec.Q, v0))
+		v0, v1 = v1, v2
+	}
+	p.cofactor = new(big.Int).Add(f.order, big.NewInt(int64(2)))
+	p.cofactor.Sub(p.cofactor, v1)
+	for new(big.Int).Mod(p.cofactor, n).Sign() == 0 {
+		p.cofactor.Div(p.cofactor, n)
+		p.nPow++
+	}
+	return p, nil
+}
+
+// Infinity returns the point at infinity
+func (p *Pairing) Infinity() ExtPoint {
+	return ExtPoint{Inf: true}
+}
+
+// Lift returns the point of the curve over F_q as a point over F_(q^k)
+func (p *Pairing) Lift(pt Point) ExtPoint {
+	if pt.Equal(ZeroPoint) {
+		return p.Infinity()
+	}
+	return ExtPoint{X: p.F.Element(pt.X), Y: p.F.Element(pt.Y)}
+}
+
+// IsOnCurve returns true if the point satisfies y^2 = x^3 + ax + b over F_(q^k)
+func (p *Pairing) IsOnCurve(pt ExtPoint) bool {
+	if pt.Inf {
+		return true
+	}
+	return p.F.Equal(p.F.Mul(pt.Y, pt.Y), p.rhs(pt.X))
+}
+
+// rhs returns x^3 + ax + b
+func (p *Pairing) rhs(x ExtElement) ExtElement {
+	f := p.F
+	return f.Add(f.Mul(f.Add(f.Mul(x, x), p.a), x), p.b)
+}
+
+// Equal returns true if the two points are the same
+func (p *Pairing) Equal(p1, p2 ExtPoint) bool {
+	if p1.Inf || p2.Inf {
+		return p1.Inf == p2.Inf
+	}
+	return p.F.Equal(p1.X, p2.X) && p.F.Equal(p1.Y, p2.Y)
+}
+
+// Neg returns -pt
+func (p *Pairing) Neg(pt ExtPoint) ExtPoint {
+	if pt.Inf {
+		return pt
+	}
+	return ExtPoint{X: pt.X, Y: p.F.Neg(pt.Y)}
+}
+
+// slope returns the slope of the line through p1 and p2, the tangent when
+// they are equal, or nil when the line is vertical
+func (p *Pairing) slope(p1, p2 ExtPoint) ExtElement {
+	f := p.F
+	var num, den ExtElement
+	if f.Equal(p1.X, p2.X) {
+		if !f.Equal(p1.Y, p2.Y) || f.IsZero(p1.Y) {
+			return nil
+		}
+		// (3x^2 + a) / 2y
+		x2 := f.Mul(p1.X, p1.X)
+		num = f.Add(f.Add(f.Add(x2, x2), x2), p.a)
+		den = f.Add(p1.Y, p1.Y)
+	} else {
+		num = f.Sub(p2.Y, p1.Y)
+		den = f.Sub(p2.X, p1.X)
+	}
+	s, _ := f.Div(num, den)
+	return s
+}
+
+// addSlope returns p1 + p2 with the slope of the line through them, which is
+// not vertical
+func (p *Pairing) addSlope(p1, p2 ExtPoint, s ExtElement) ExtPoint {
+	f := p.F
+	x := f.Sub(f.Sub(f.Mul(s, s), p1.X), p2.X)
+	y := f.Sub(f.Mul(s, f.Sub(p1.X, x)), p1.Y)
+	return ExtPoint{X: x, Y: y}
+}
+
+// Add returns p1 + p2
+func (p *Pairing) Add(p1, p2 ExtPoint) ExtPoint {
+	if p1.Inf {
+		return p2
+	}
+	if p2.Inf {
+		return p1
+	}
+	s := p.slope(p1, p2)
+	if s == nil {
+		return p.Infinity()
+	}
+	return p.addSlope(p1, p2, s)
+}
+
+// Mul returns n pt, for negative n the result is -(|n| pt)
+func (p *Pairing) Mul(pt ExtPoint, n *big.Int) ExtPoint {
+	if n.Sign() < 0 {
+		return p.Mul(p.Neg(pt), new(big.Int).Neg(n))
+	}
+	r := p.Infinity()
+	for i := n.BitLen() - 1; i >= 0; i-- {
+		r = p.Add(r, r)
+		if n.Bit(i) == 1 {
+			r = p.Add(r, pt)
+		}
+	}
+	return r
+}
+
+// RandomPoint returns a random point of the curve over F_(q^k), different from
+// the point at infinity. If r is nil, crypto/rand is used
+func (p *Pairing) RandomPoint(r io.Reader) (ExtPoint, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	for i := 0; i < 1000; i++ {
+		c := make([]*big.Int, p.K)
+		for j := range c {
+			v, err := rand.Int(r, p.EC.Q)
+			if err != nil {
+				return ExtPoint{}, err
+			}
+			c[j] = v
+		}
+		x := p.F.Element(c...)
+		y, err := p.F.Sqrt(p.rhs(x))
+		if err != nil {
+			continue
+		}
+		// the sign of y from the parity of the first coefficient
+		if c[0].Bit(0) == 1 {
+			y = p.F.Neg(y)
+		}
+		return ExtPoint{X: x, Y: y}, nil
+	}
+	return ExtPoint{}, errors.New("no random point found")
+}
+
+// RandomTorsionPoint returns a random N-torsion point over F_(q^k), different
+// from the point at infinity, multiplying a random point by the cofactor of
+// #E(F_(q^k)) and then by N while the result is not an N-torsion point. If r is
+// nil, crypto/rand is used
+func (p *Pairing) RandomTorsionPoint(r io.Reader) (ExtPoint, error) {
+	for i := 0; i < 100; i++ {
+		pt, err := p.RandomPoint(r)
+		if err != nil {
+			return ExtPoint{}, err
+		}
+		pt = p.Mul(pt, p.cofactor)
+		if pt.Inf {
+			continue
+		}
+		for j := 1; j < p.nPow; j++ {
+			nPt := p.Mul(pt, p.N)
+			if nPt.Inf {
+				break
+			}
+			pt = nPt
+		}
+		return pt, nil
+	}
+	return ExtPoint{}, errors.New("no random torsion point found")
+}
+
+// errMillerSupport is returned by miller when the function is evaluated at one
+// of its zeros or poles
+var errMillerSupport = errors.New("miller: evaluation point in the divisor support")
+
+// miller returns f_(N,pt)(q), where f_(N,pt) is the function with divisor
+// N (pt) - N (O) normalized at infinity, built as the product of the lines of
+// the double-and-add computation of N pt divided by the vertical lines of the
+// intermediate results. The numerator and the denominator are accumulated
+// separately, so only one inversion is done
+func (p *Pairing) miller(pt, q ExtPoint) (ExtElement, error) {
+	f := p.F
+	num, den := f.One(), f.One()
+	t := pt
+	// step multiplies the accumulated function by the line through t and u
+	// over the vertical line at t + u, and returns t + u
+	step := func(t, u ExtPoint) ExtPoint {
+		s := p.slope(t, u)
+		if s == nil {
+			// vertical line x - x_t, and t + u = O
+			num = f.Mul(num, f.Sub(q.X, t.X))
+			return p.Infinity()
+		}
+		// (y - y_t) - s (x - x_t), over x - x_(t+u)
+		tu := p.addSlope(t, u, s)
+		num = f.Mul(num, f.Sub(f.Sub(q.Y, t.Y), f.Mul(s, f.Sub(q.X, t.X))))
+		den = f.Mul(den, f.Sub(q.X, tu.X))
+		return tu
+	}
+	for i := p.N.BitLen() - 2; i >= 0; i-- {
+		num = f.Mul(num, num)
+		den = f.Mul(den, den)
+		t = step(t, t)
+		if p.N.Bit(i) == 1 {
+			t = step(t, pt)
+		}
+	}
+	if f.IsZero(num) || f.IsZero(den) {
+		return nil, errMillerSupport
+	}
+	return f.Div(num, den)
+}
+
+// millerDivisor returns f_(N,pt)(q + s) / f_(N,pt)(s), the evaluation of
+// f_(N,pt) at the divisor (q + s) - (s), equivalent to (q) - (O)
+func (p *Pairing) millerDivisor(pt, q, s ExtPoint) (ExtElement, error) {
+	qs := p.Add(q, s)
+	if qs.Inf || s.Inf {
+		return nil, errMillerSupport
+	}
+	f1, err := p.miller(pt, qs)
+	if err != nil {
+		return nil, err
+	}
+	f2, err := p.miller(pt, s)
+	if err != nil {
+		return nil, err
+	}
+	return p.F.Div(f1, f2)
+}
+
+// validate checks that the points are N-torsion points of the curve
+func (p *Pairing) validate(pts ...ExtPoint) error {
+	for _, pt := range pts {
+		if !p.IsOnCurve(pt) {
+			return errors.New("point not on curve")
+		}
+		if !p.Mul(pt, p.N).Inf {
+			return errors.New("point not in the " + p.N.String() + "-torsion")
+		}
+	}
+	return nil
+}
+
+// Weil returns the Weil pairing e_N(P, Q) = (-1)^N f_P(Q) / f_Q(P) of the
+// N-torsion points P and Q, which is an N-th root of unity in F_(q^k). It is
+// bilinear, alternating, and it is 1 only when P and Q are linearly dependent.
+// When Q or P are in the support of the divisors, the evaluation is moved to
+// the equivalent divisors (Q + S) - (S) and (P - S) - (-S) with a random S
+func (p *Pairing) Weil(pt, q ExtPoint) (ExtElement, error) {
+	if err := p.validate(pt, q); err != nil {
+		return nil, err
+	}
+	if pt.Inf || q.Inf || p.Equal(pt, q) {
+		return p.F.One(), nil
+	}
+	fp, err := p.miller(pt, q)
+	if err == nil {
+		var fq ExtElement
+		fq, err = p.miller(q, pt)
+		if err == nil {
+			e, err := p.F.Div(fp, fq)
+			if err != nil {
+				return nil, err
+			}
+			if p.N.Bit(0) == 1 {
+				e = p.F.Neg(e)
+			}
+			return e, nil
+		}
+	}
+	if err != errMillerSupport {
+		return nil, err
+	}
+	for i := 0; i < 100; i++ {
+		s, err := p.RandomPoint(nil)
+		if err != nil {
+			return nil, err
+		}
+		fp, err := p.millerDivisor(pt, q, s)
+		if err == errMillerSupport {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		fq, err := p.millerDivisor(q, pt, p.Neg(s))
+		if err == errMillerSupport {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return p.F.Div(fp, fq)
+	}
+	return nil, errors.New("weil: no valid random point found")
+}
+
+// Tate returns the reduced Tate pairing f_P(Q)^((q^k - 1) / N) of the N-torsion
+// point P and the point Q over F_(q^k), which is an N-th root of unity. It is
+// bilinear and, for P over F_q, non degenerate when Q is not in <P>. When Q is
+// in the support of the divisor of f_P, the evaluation is moved to the
+// equivalent divisor (Q + S) - (S) with a random S
+func (p *Pairing) Tate(pt, q ExtPoint) (ExtElement, error) {
+	if err := p.validate(pt); err != nil {
+		return nil, err
+	}
+	if !p.IsOnCurve(q) {
+		return nil, errors.New("point not on curve")
+	}
+	if pt.Inf || q.Inf {
+		return p.F.One(), nil
+	}
+	e := new(big.Int).Div(p.F.order, p.N)
+	fp, err := p.miller(pt, q)
+	for i := 0; err == errMillerSupport && i < 100; i++ {
+		var s ExtPoint
+		s, err = p.RandomPoint(nil)
+		if err != nil {
+			return nil, err
+		}
+		fp, err = p.millerDivisor(pt, q, s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.F.Exp(fp, e)
+}
@@ -0,0 +1,261 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pairingCurve is a toy curve y^2 = x^3 + ax + b over F_q with a prime N
+// dividing its number of points, and the embedding degree k of N
+type pairingCurve struct {
+	a, b, q, n int64
+	k          int
+}
+
+var pairingCurves = []pairingCurve{
+	// supersingular curves, with q + 1 points
+	{1, 0, 59, 5, 2},
+	{0, 1, 83, 7, 2},
+	// ordinary curves, with 14, 15, 15 and 14 points
+	{1, 1, 11, 7, 3},
+	{2, 2, 13, 5, 4},
+	{1, 5, 17, 5, 4},
+	{1, 4, 17, 7, 6},
+}
+
+func TestEmbeddingDegree(t *testing.T) {
+	for _, c := range pairingCurves {
+		k, err := EmbeddingDegree(big.NewInt(c.q), big.NewInt(c.n), 12)
+		assert.Nil(t, err)
+		assert.Equal(t, c.k, k)
+	}
+	// the embedding degree of secp256k1 is huge
+	c := Secp256k1()
+	_, err := EmbeddingDegree(c.EC.Q, c.N, 100)
+	assert.NotNil(t, err)
+}
+
+func TestExtField(t *testing.T) {
+	for _, qk := range [][2]int64{{59, 2}, {11, 3}, {13, 4}, {17, 6}, {2, 5}, {7, 1}} {
+		q := big.NewInt(qk[0])
+		f, err := NewExtField(q, int(qk[1]))
+		assert.Nil(t, err)
+		assert.Equal(t, int(qk[1]), len(f.Modulus())-1)
+		for i := 0; i < 20; i++ {
+			c := make([]*big.Int, f.K)
+			for j := range c {
+				c[j], err = rand.Int(rand.Reader, q)
+				assert.Nil(t, err)
+			}
+			a := f.Element(c...)
+			assert.True(t, f.Equal(a, f.Add(f.Sub(a, f.One()), f.One())))
+			assert.True(t, f.IsZero(f.Add(a, f.Neg(a))))
+			if f.IsZero(a) {
+				continue
+			}
+			// a^(q^k - 1) = 1, and a^(q^k) = a
+			e, err := f.Exp(a, f.order)
+			assert.Nil(t, err)
+			assert.True(t, f.Equal(f.One(), e))
+			inv, err := f.Inverse(a)
+			assert.Nil(t, err)
+			assert.True(t, f.Equal(f.One(), f.Mul(a, inv)))
+			e, err = f.Exp(a, big.NewInt(int64(-3)))
+			assert.Nil(t, err)
+			assert.True(t, f.Equal(f.One(), f.Mul(e, f.Mul(a, f.Mul(a, a)))))
+
+			a2 := f.Mul(a, a)
+			assert.True(t, f.IsSquare(a2))
+			r, err := f.Sqrt(a2)
+			assert.Nil(t, err)
+			assert.True(t, f.Equal(a2, f.Mul(r, r)))
+		}
+	}
+	// t^2 + 1 is irreducible mod 59, as 59 = 3 mod 4
+	f, err := NewExtField(big.NewInt(int64(59)), 2)
+	assert.Nil(t, err)
+	assert.True(t, f.pf.equal(poly{BigOne, BigZero, BigOne}, poly(f.Modulus())))
+	_, err = f.Inverse(f.Zero())
+	assert.NotNil(t, err)
+	// the elements of F_59 are squares over F_59^2, but 1 + t is not
+	assert.True(t, f.IsSquare(f.Element(big.NewInt(int64(-1)))))
+	_, err = f.Sqrt(f.Element(big.NewInt(int64(1)), big.NewInt(int64(1))))
+	assert.NotNil(t, err)
+	_, err = NewExtField(big.NewInt(int64(59)), 0)
+	assert.NotNil(t, err)
+}
+
+// newTestPairing returns the pairing of the curve, together with a point of
+// order N over F_q
+func newTestPairing(t *testing.T, c pairingCurve) (*Pairing, ExtPoint) {
+	ec := NewEC(big.NewInt(c.a), big.NewInt(c.b), big.NewInt(c.q))
+	p, err := NewPairing(ec, big.NewInt(c.n))
+	assert.Nil(t, err)
+	assert.Equal(t, c.k, p.K)
+	card, err := ec.Cardinality()
+	assert.Nil(t, err)
+	h := new(big.Int).Div(card, p.N)
+	for {
+		pt, err := ec.RandomPoint(nil)
+		assert.Nil(t, err)
+		pt, err = ec.Mul(pt, h)
+		assert.Nil(t, err)
+		if !pt.Equal(ZeroPoint) {
+			return p, p.Lift(pt)
+		}
+	}
+}
+
+// independentPoint returns an N-torsion point Q over F_(q^k) with
+// e_N(pt, Q) != 1
+func independentPoint(t *testing.T, p *Pairing, pt ExtPoint) (ExtPoint, ExtElement) {
+	for {
+		q, err := p.RandomTorsionPoint(nil)
+		assert.Nil(t, err)
+		e, err := p.Weil(pt, q)
+		assert.Nil(t, err)
+		if !p.F.Equal(e, p.F.One()) {
+			return q, e
+		}
+	}
+}
+
+// nonTorsionPoint returns a random point over F_(q^k) which is not an N-torsion
+// point
+func nonTorsionPoint(t *testing.T, p *Pairing) ExtPoint {
+	for {
+		s, err := p.RandomPoint(nil)
+		assert.Nil(t, err)
+		if !p.Mul(s, p.N).Inf {
+			return s
+		}
+	}
+}
+
+func TestNewPairing(t *testing.T) {
+	ec := NewEC(big.NewInt(int64(1)), big.NewInt(int64(0)), big.NewInt(int64(59)))
+	// 7 does not divide 60, and 15 is not prime
+	_, err := NewPairing(ec, big.NewInt(int64(7)))
+	assert.NotNil(t, err)
+	_, err = NewPairing(ec, big.NewInt(int64(15)))
+	assert.NotNil(t, err)
+}
+
+func TestPairingTorsionPoints(t *testing.T) {
+	for _, c := range pairingCurves {
+		p, pt := newTestPairing(t, c)
+		assert.True(t, p.IsOnCurve(pt))
+		assert.True(t, p.Mul(pt, p.N).Inf)
+		for i := 0; i < 5; i++ {
+			q, err := p.RandomTorsionPoint(nil)
+			assert.Nil(t, err)
+			assert.True(t, p.IsOnCurve(q))
+			assert.False(t, q.Inf)
+			assert.True(t, p.Mul(q, p.N).Inf)
+			assert.True(t, p.Equal(p.Infinity(), p.Add(q, p.Neg(q))))
+		}
+	}
+}
+
+func TestWeil(t *testing.T) {
+	for _, c := range pairingCurves {
+		p, pt := newTestPairing(t, c)
+		f := p.F
+		q, e := independentPoint(t, p, pt)
+
+		// e is an N-th root of unity
+		eN, err := f.Exp(e, p.N)
+		assert.Nil(t, err)
+		assert.True(t, f.Equal(f.One(), eN))
+
+		// e(aP, bQ) = e(P, Q)^(ab)
+		for i := 0; i < 5; i++ {
+			a, err := rand.Int(rand.Reader, p.N)
+			assert.Nil(t, err)
+			b, err := rand.Int(rand.Reader, p.N)
+			assert.Nil(t, err)
+			eab, err := p.Weil(p.Mul(pt, a), p.Mul(q, b))
+			assert.Nil(t, err)
+			expected, err := f.Exp(e, new(big.Int).Mul(a, b))
+			assert.Nil(t, err)
+			assert.True(t, f.Equal(expected, eab))
+		}
+
+		// e(Q, P) = e(P, Q)^-1, and e(P, P) = e(P, 2P) = 1
+		eqp, err := p.Weil(q, pt)
+		assert.Nil(t, err)
+		assert.True(t, f.Equal(f.One(), f.Mul(e, eqp)))
+		epp, err := p.Weil(pt, pt)
+		assert.Nil(t, err)
+		assert.True(t, f.Equal(f.One(), epp))
+		epp, err = p.Weil(pt, p.Add(pt, pt))
+		assert.Nil(t, err)
+		assert.True(t, f.Equal(f.One(), epp))
+
+		// the evaluation at the equivalent divisors gives the same result, for
+		// S out of the support of the divisors
+		for {
+			s, err := p.RandomPoint(nil)
+			assert.Nil(t, err)
+			fp, err := p.millerDivisor(pt, q, s)
+			if err == errMillerSupport {
+				continue
+			}
+			assert.Nil(t, err)
+			fq, err := p.millerDivisor(q, pt, p.Neg(s))
+			if err == errMillerSupport {
+				continue
+			}
+			assert.Nil(t, err)
+			shifted, err := f.Div(fp, fq)
+			assert.Nil(t, err)
+			assert.True(t, f.Equal(e, shifted))
+			break
+		}
+
+		_, err = p.Weil(pt, nonTorsionPoint(t, p))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestTate(t *testing.T) {
+	for _, c := range pairingCurves {
+		p, pt := newTestPairing(t, c)
+		f := p.F
+		q, _ := independentPoint(t, p, pt)
+		e, err := p.Tate(pt, q)
+		assert.Nil(t, err)
+		assert.False(t, f.Equal(f.One(), e))
+		eN, err := f.Exp(e, p.N)
+		assert.Nil(t, err)
+		assert.True(t, f.Equal(f.One(), eN))
+
+		for i := 0; i < 5; i++ {
+			a, err := rand.Int(rand.Reader, p.N)
+			assert.Nil(t, err)
+			b, err := rand.Int(rand.Reader, p.N)
+			assert.Nil(t, err)
+			eab, err := p.Tate(p.Mul(pt, a), p.Mul(q, b))
+			assert.Nil(t, err)
+			expected, err := f.Exp(e, new(big.Int).Mul(a, b))
+			assert.Nil(t, err)
+			assert.True(t, f.Equal(expected, eab))
+		}
+
+		// P over F_q gives a degenerate self pairing when k > 1, and the
+		// evaluation at P is moved to an equivalent divisor
+		epp, err := p.Tate(pt, pt)
+		assert.Nil(t, err)
+		assert.True(t, f.Equal(f.One(), epp))
+
+		// the Tate pairing is defined for any Q, not only for the torsion points
+		s := nonTorsionPoint(t, p)
+		_, err = p.Tate(pt, s)
+		assert.Nil(t, err)
+		_, err = p.Tate(s, pt)
+		assert.NotNil(t, err)
+	}
+}
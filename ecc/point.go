@@ -18,7 +18,7 @@ type Point struct {
 }
 
 // Equal compares the X and Y coord of a Point and returns true if are the same
-func (p1 *Point) Equal(p2 Point) bool {
+func (p1 Point) Equal(p2 Point) bool {
 	if !bytes.Equal(p1.X.Bytes(), p2.X.Bytes()) {
 		return false
 	}
@@ -29,6 +29,6 @@ func (p1 *Point) Equal(p2 Point) bool {
 }
 
 // String returns the components of the point in a string
-func (p *Point) String() string {
+func (p Point) String() string {
 	return "(" + p.X.String() + ", " + p.Y.String() + ")"
 }
@@ -0,0 +1,390 @@
+package ecc
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// kroneckerThreshold is the length of the polynomials from which the
+// multiplication is done by Kronecker substitution instead of schoolbook
+const kroneckerThreshold = 24
+
+// nttThreshold is the length of the polynomials from which the multiplication
+// is done with number theoretic transforms instead of Kronecker substitution
+const nttThreshold = 512
+
+// poly is a polynomial over F_q, with the coefficients ordered from the lower to
+// the higher degree and without leading zero coefficients, so the zero
+// polynomial is the empty slice. The coefficients are never modified in place
+type poly []*big.Int
+
+// polyField implements the arithmetic of the polynomials over F_q
+type polyField struct {
+	q *big.Int
+}
+
+func (pf polyField) trim(a poly) poly {
+	i := len(a)
+	for i > 0 && a[i-1].Sign() == 0 {
+		i--
+	}
+	return a[:i]
+}
+
+func (pf polyField) deg(a poly) int {
+	return len(a) - 1
+}
+
+func (pf polyField) equal(a, b poly) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// constant returns the polynomial c mod q
+func (pf polyField) constant(c *big.Int) poly {
+	return pf.trim(poly{new(big.Int).Mod(c, pf.q)})
+}
+
+// monomial returns the polynomial x^d
+func (pf polyField) monomial(d int) poly {
+	r := make(poly, d+1)
+	for i := 0; i < d; i++ {
+		r[i] = BigZero
+	}
+	r[d] = BigOne
+	return r
+}
+
+// eval returns a(x)
+func (pf polyField) eval(a poly, x *big.Int) *big.Int {
+	r := new(big.Int)
+	for i := len(a) - 1; i >= 0; i-- {
+		r.Mul(r, x)
+		r.Add(r, a[i])
+		r.Mod(r, pf.q)
+	}
+	return r
+}
+
+// deriv returns the derivative of a
+func (pf polyField) deriv(a poly) poly {
+	if len(a) <= 1 {
+		return poly{}
+	}
+	r := make(poly, len(a)-1)
+	for i := range r {
+		r[i] = new(big.Int).Mul(a[i+1], big.NewInt(int64(i+1)))
+		r[i].Mod(r[i], pf.q)
+	}
+	return pf.trim(r)
+}
+
+func (pf polyField) add(a, b poly) poly {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	r := make(poly, len(a))
+	for i := range a {
+		if i < len(b) {
+			c := new(big.Int).Add(a[i], b[i])
+			if c.Cmp(pf.q) >= 0 {
+				c.Sub(c, pf.q)
+			}
+			r[i] = c
+		} else {
+			r[i] = a[i]
+		}
+	}
+	return pf.trim(r)
+}
+
+func (pf polyField) neg(a poly) poly {
+	r := make(poly, len(a))
+	for i := range a {
+		if a[i].Sign() == 0 {
+			r[i] = a[i]
+		} else {
+			r[i] = new(big.Int).Sub(pf.q, a[i])
+		}
+	}
+	return r
+}
+
+func (pf polyField) sub(a, b poly) poly {
+	return pf.add(a, pf.neg(b))
+}
+
+// scale returns c * a
+func (pf polyField) scale(a poly, c *big.Int) poly {
+	r := make(poly, len(a))
+	for i := range a {
+		r[i] = new(big.Int).Mul(a[i], c)
+		r[i].Mod(r[i], pf.q)
+	}
+	return pf.trim(r)
+}
+
+// truncate returns a mod x^n
+func (pf polyField) truncate(a poly, n int) poly {
+	if len(a) <= n {
+		return a
+	}
+	return pf.trim(a[:n])
+}
+
+// reverse returns the n coefficients of a in reverse order, padding with zeros
+func (pf polyField) reverse(a poly, n int) poly {
+	r := make(poly, n)
+	for i := 0; i < n; i++ {
+		if n-1-i < len(a) {
+			r[i] = a[n-1-i]
+		} else {
+			r[i] = BigZero
+		}
+	}
+	return pf.trim(r)
+}
+
+func (pf polyField) mul(a, b poly) poly {
+	if len(a) == 0 || len(b) == 0 {
+		return poly{}
+	}
+	if len(a) < kroneckerThreshold || len(b) < kroneckerThreshold {
+		return pf.mulSchoolbook(a, b)
+	}
+	if len(a) < nttThreshold || len(b) < nttThreshold || len(a)+len(b) > 1<<nttMaxLog {
+		return pf.mulKronecker(a, b)
+	}
+	return pf.mulNTT(a, b)
+}
+
+func (pf polyField) mulSchoolbook(a, b poly) poly {
+	r := make(poly, len(a)+len(b)-1)
+	t := new(big.Int)
+	for k := range r {
+		c := new(big.Int)
+		for i := 0; i < len(a); i++ {
+			if k-i < 0 {
+				break
+			}
+			if k-i >= len(b) {
+				continue
+			}
+			c.Add(c, t.Mul(a[i], b[k-i]))
+		}
+		r[k] = c.Mod(c, pf.q)
+	}
+	return pf.trim(r)
+}
+
+// mulKronecker multiplies a and b packing their coefficients in two big
+// integers, so the multiplication is done by the big.Int subquadratic algorithm
+func (pf polyField) mulKronecker(a, b poly) poly {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	slotBits := 2*pf.q.BitLen() + bits.Len(uint(n)) + 1
+	w := (slotBits + bits.UintSize - 1) / bits.UintSize
+	pack := func(p poly) *big.Int {
+		words := make([]big.Word, len(p)*w)
+		for i, c := range p {
+			copy(words[i*w:], c.Bits())
+		}
+		return new(big.Int).SetBits(words)
+	}
+	prod := new(big.Int).Mul(pack(a), pack(b)).Bits()
+	r := make(poly, len(a)+len(b)-1)
+	for k := range r {
+		start := k * w
+		end := start + w
+		if end > len(prod) {
+			end = len(prod)
+		}
+		if start >= end {
+			r[k] = BigZero
+			continue
+		}
+		slot := make([]big.Word, end-start)
+		copy(slot, prod[start:end])
+		c := new(big.Int).SetBits(slot)
+		r[k] = c.Mod(c, pf.q)
+	}
+	return pf.trim(r)
+}
+
+// divMod returns the quotient and the remainder of a / b, b must be non zero
+func (pf polyField) divMod(a, b poly) (poly, poly) {
+	if len(a) < len(b) {
+		return poly{}, a
+	}
+	lcInv := new(big.Int).ModInverse(b[len(b)-1], pf.q)
+	r := make(poly, len(a))
+	for i := range a {
+		r[i] = new(big.Int).Set(a[i])
+	}
+	quo := make(poly, len(a)-len(b)+1)
+	t := new(big.Int)
+	for i := len(quo) - 1; i >= 0; i-- {
+		c := new(big.Int).Mul(r[i+len(b)-1], lcInv)
+		c.Mod(c, pf.q)
+		quo[i] = c
+		if c.Sign() == 0 {
+			continue
+		}
+		for j := 0; j < len(b)-1; j++ {
+			r[i+j].Sub(r[i+j], t.Mul(c, b[j]))
+			r[i+j].Mod(r[i+j], pf.q)
+		}
+		r[i+len(b)-1].SetInt64(int64(0))
+	}
+	return pf.trim(quo), pf.trim(r[:len(b)-1])
+}
+
+func (pf polyField) mod(a, b poly) poly {
+	_, r := pf.divMod(a, b)
+	return r
+}
+
+// monic returns a divided by its leading coefficient
+func (pf polyField) monic(a poly) poly {
+	if len(a) == 0 {
+		return a
+	}
+	return pf.scale(a, new(big.Int).ModInverse(a[len(a)-1], pf.q))
+}
+
+// gcd returns the monic greatest common divisor of a and b
+func (pf polyField) gcd(a, b poly) poly {
+	for len(b) > 0 {
+		a, b = b, pf.mod(a, b)
+	}
+	return pf.monic(a)
+}
+
+// roots returns the distinct roots in F_q of a non constant a, q must be an odd
+// prime
+func (pf polyField) roots(a poly) []*big.Int {
+	a = pf.monic(a)
+	x := pf.monomial(1)
+	m := newPolyModulus(pf, a)
+	// gcd(x^q - x, a) is the product of the x - r over the roots r of a
+	return pf.splitRoots(pf.gcd(pf.sub(m.exp(x, pf.q), x), a), 1)
+}
+
+// splitRoots returns the roots of g, a product of distinct x - r, splitting it
+// with gcd((x + delta)^((q-1)/2) - 1, g), which has the roots r where r + delta
+// is a square (Cantor-Zassenhaus)
+func (pf polyField) splitRoots(g poly, delta int64) []*big.Int {
+	switch pf.deg(g) {
+	case 0:
+		return nil
+	case 1:
+		return []*big.Int{new(big.Int).Mod(new(big.Int).Neg(g[0]), pf.q)}
+	}
+	e := new(big.Int).Rsh(pf.q, 1)
+	m := newPolyModulus(pf, g)
+	for ; ; delta++ {
+		s := m.exp(pf.add(pf.constant(big.NewInt(delta)), pf.monomial(1)), e)
+		h := pf.gcd(pf.sub(s, poly{BigOne}), g)
+		if d := pf.deg(h); d > 0 && d < pf.deg(g) {
+			other, _ := pf.divMod(g, h)
+			return append(pf.splitRoots(h, delta+1), pf.splitRoots(pf.monic(other), delta+1)...)
+		}
+	}
+}
+
+// invMod returns the inverse of a modulo m and the monic gcd of a and m. When
+// the gcd is not 1, a is not invertible and the returned inverse is nil
+func (pf polyField) invMod(a, m poly) (poly, poly) {
+	// extended Euclidean algorithm, keeping only the coefficient of a
+	r0, r1 := m, pf.mod(a, m)
+	s0, s1 := poly{}, poly{BigOne}
+	for len(r1) > 0 {
+		quo, rem := pf.divMod(r0, r1)
+		r0, r1 = r1, rem
+		s0, s1 = s1, pf.sub(s0, pf.mul(quo, s1))
+	}
+	g := pf.monic(r0)
+	if len(g) != 1 {
+		return nil, g
+	}
+	// r0 = s0 * a mod m, with r0 constant
+	inv := pf.scale(s0, new(big.Int).ModInverse(r0[0], pf.q))
+	return pf.mod(inv, m), g
+}
+
+// polyModulus implements the arithmetic of the quotient ring F_q[x]/(h), the
+// reduction is done with a precomputed inverse of the reversed modulus, which
+// turns it into two multiplications
+type polyModulus struct {
+	pf   polyField
+	h    poly
+	n    int  // degree of h
+	hInv poly // rev(h)^-1 mod x^(n-1)
+}
+
+func newPolyModulus(pf polyField, h poly) *polyModulus {
+	m := &polyModulus{pf: pf, h: h, n: pf.deg(h)}
+	if m.n > 1 {
+		m.hInv = pf.seriesInverse(pf.reverse(h, m.n+1), m.n-1)
+	}
+	return m
+}
+
+// seriesInverse returns the inverse of a mod x^k, a[0] must be non zero
+func (pf polyField) seriesInverse(a poly, k int) poly {
+	r := poly{new(big.Int).ModInverse(a[0], pf.q)}
+	two := poly{big.NewInt(int64(2))}
+	for prec := 1; prec < k; {
+		prec *= 2
+		if prec > k {
+			prec = k
+		}
+		e := pf.truncate(pf.mul(pf.truncate(a, prec), r), prec)
+		r = pf.truncate(pf.mul(r, pf.sub(two, e)), prec)
+	}
+	return r
+}
+
+// reduce returns a mod h
+func (m *polyModulus) reduce(a poly) poly {
+	if len(a) <= m.n {
+		return a
+	}
+	if m.n <= 1 || len(a) > 2*m.n-1 {
+		return m.pf.mod(a, m.h)
+	}
+	pf := m.pf
+	qLen := len(a) - m.n
+	// the quotient is rev(rev(a) * rev(h)^-1 mod x^qLen)
+	ra := pf.reverse(a, len(a))
+	quoRev := pf.truncate(pf.mul(pf.truncate(ra, qLen), pf.truncate(m.hInv, qLen)), qLen)
+	quo := pf.reverse(quoRev, qLen)
+	r := pf.sub(a, pf.mul(quo, m.h))
+	return pf.truncate(r, m.n)
+}
+
+func (m *polyModulus) mul(a, b poly) poly {
+	return m.reduce(m.pf.mul(a, b))
+}
+
+// exp returns a^e mod h
+func (m *polyModulus) exp(a poly, e *big.Int) poly {
+	r := m.reduce(poly{BigOne})
+	a = m.reduce(a)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = m.mul(r, r)
+		if e.Bit(i) == 1 {
+			r = m.mul(r, a)
+		}
+	}
+	return r
+}
@@ -0,0 +1,116 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"iter"
+	"math/big"
+)
+
+// Legendre returns the Legendre symbol (a/p) for an odd prime p: 1 if a is a
+// non zero square mod p, -1 if it is not a square, and 0 if p divides a
+func Legendre(a, p *big.Int) int {
+	return big.Jacobi(new(big.Int).Mod(a, p), p)
+}
+
+// Jacobi returns the Jacobi symbol (a/n) for an odd n > 0, which extends the
+// Legendre symbol to composite n. When it is -1, a is not a square mod n, but
+// when it is 1 a is not necessarily a square
+func Jacobi(a, n *big.Int) (int, error) {
+	if n.Sign() <= 0 || n.Bit(0) == 0 {
+		return 0, errors.New("jacobi symbol: n must be odd and positive")
+	}
+	return big.Jacobi(new(big.Int).Mod(a, n), n), nil
+}
+
+// ModSqrt returns a square root of a mod the prime p, or an error if a is not
+// a square mod p
+func ModSqrt(a, p *big.Int) (*big.Int, error) {
+	y := new(big.Int).ModSqrt(new(big.Int).Mod(a, p), p)
+	if y == nil {
+		return nil, errors.New("no square root of " + a.String())
+	}
+	return y, nil
+}
+
+// isSquare returns true if x is a square in F_q, including 0
+func isSquare(x, q *big.Int) bool {
+	return Legendre(x, q) >= 0
+}
+
+// curveRHS returns x^3 + ax + b mod q
+func curveRHS(ec EC, x *big.Int) *big.Int {
+	f := new(big.Int).Mul(x, x)
+	f.Add(f, ec.A)
+	f.Mul(f, x)
+	f.Add(f, ec.B)
+	return f.Mod(f, ec.Q)
+}
+
+// HasPointAt returns true if there is a point of the curve with the given x,
+// that is if x^3 + ax + b is a square mod q
+func (ec *EC) HasPointAt(x *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(ec.Q) >= 0 {
+		return false
+	}
+	return isSquare(curveRHS(*ec, x), ec.Q)
+}
+
+// Points returns an iterator over all the points of the curve: the point at
+// infinity, and then the points sorted by x and y. It checks every x of the
+// field, so it is only usable for small curves. When b = 0 the point (0, 0) is
+// not returned, as it is the representation of the point at infinity
+func (ec *EC) Points() iter.Seq[Point] {
+	return func(yield func(Point) bool) {
+		if !yield(ZeroPoint) {
+			return
+		}
+		for x := big.NewInt(int64(0)); x.Cmp(ec.Q) < 0; x = new(big.Int).Add(x, BigOne) {
+			p, pNeg, err := ec.At(x)
+			if err != nil {
+				continue
+			}
+			if p.Y.Sign() == 0 {
+				if x.Sign() != 0 && !yield(p) {
+					return
+				}
+				continue
+			}
+			if p.Y.Cmp(pNeg.Y) > 0 {
+				p, pNeg = pNeg, p
+			}
+			if !yield(p) || !yield(pNeg) {
+				return
+			}
+		}
+	}
+}
+
+// RandomPoint returns a uniformly random x of the curve and one of its two
+// points, also chosen at random, reading the randomness from r (or from
+// crypto/rand when it is nil). It never returns the point at infinity, and on
+// curves with a cofactor the point is not necessarily in the subgroup of the
+// generator
+func (ec *EC) RandomPoint(r io.Reader) (Point, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	// the x coordinate together with an extra bit for the sign of y
+	max := new(big.Int).Lsh(ec.Q, 1)
+	for i := 0; i < 1000; i++ {
+		v, err := rand.Int(r, max)
+		if err != nil {
+			return Point{}, err
+		}
+		p, pNeg, err := ec.At(new(big.Int).Rsh(v, 1))
+		if err != nil || p.Equal(ZeroPoint) {
+			continue
+		}
+		if v.Bit(0) == 1 {
+			return pNeg, nil
+		}
+		return p, nil
+	}
+	return Point{}, errors.New("no random point found")
+}
@@ -0,0 +1,107 @@
+package ecc
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegendre(t *testing.T) {
+	p := big.NewInt(int64(11))
+	// squares mod 11: 1, 3, 4, 5, 9
+	expected := []int{0, 1, -1, 1, 1, 1, -1, -1, -1, 1, -1}
+	for a := 0; a < 11; a++ {
+		assert.Equal(t, expected[a], Legendre(big.NewInt(int64(a)), p))
+	}
+	assert.Equal(t, -1, Legendre(big.NewInt(int64(-1)), p))
+
+	// (2/15) = (2/3)(2/5) = 1, but 2 is not a square mod 15
+	j, err := Jacobi(big.NewInt(int64(2)), big.NewInt(int64(15)))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, j)
+	_, err = Jacobi(big.NewInt(int64(2)), big.NewInt(int64(16)))
+	assert.NotNil(t, err)
+}
+
+func TestModSqrt(t *testing.T) {
+	p := big.NewInt(int64(11))
+	y, err := ModSqrt(big.NewInt(int64(5)), p)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), new(big.Int).Mod(new(big.Int).Mul(y, y), p).Int64())
+	_, err = ModSqrt(big.NewInt(int64(2)), p)
+	assert.NotNil(t, err)
+}
+
+func TestAtNoPoint(t *testing.T) {
+	ec := NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11)))
+	// x^3 + 7 = 1 mod 11 is a square, x^3 + 7 = 8 mod 11 is not
+	assert.True(t, ec.HasPointAt(big.NewInt(int64(7))))
+	assert.False(t, ec.HasPointAt(big.NewInt(int64(1))))
+	_, _, err := ec.At(big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+	_, _, err = ec.At(big.NewInt(int64(11)))
+	assert.NotNil(t, err)
+	_, _, err = ec.At(big.NewInt(int64(-1)))
+	assert.NotNil(t, err)
+
+	// y = 0, both points are the same
+	ec = NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(29)))
+	p, pNeg, err := ec.At(big.NewInt(int64(13)))
+	assert.Nil(t, err)
+	assert.True(t, p.Equal(pNeg))
+	assert.True(t, ec.IsOnCurve(pNeg))
+}
+
+func TestPoints(t *testing.T) {
+	for _, c := range []Curve{Toy11(), Toy19(), Toy29()} {
+		var points []Point
+		for p := range c.EC.Points() {
+			assert.True(t, c.EC.IsOnCurve(p))
+			points = append(points, p)
+		}
+		n, err := c.EC.Cardinality()
+		assert.Nil(t, err)
+		assert.Equal(t, n.Int64(), int64(len(points)), c.Name)
+		assert.True(t, points[0].Equal(ZeroPoint))
+		for i := 2; i < len(points); i++ {
+			assert.False(t, points[i].Equal(points[i-1]))
+		}
+	}
+
+	// stop the iteration
+	c := Toy29()
+	count := 0
+	for range c.EC.Points() {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestRandomPoint(t *testing.T) {
+	for _, name := range []string{"toy11", "secp256k1", "P-256"} {
+		c, err := CurveByName(name)
+		assert.Nil(t, err)
+		p, err := c.EC.RandomPoint(nil)
+		assert.Nil(t, err)
+		assert.True(t, c.EC.IsOnCurve(p))
+		assert.False(t, p.Equal(ZeroPoint))
+	}
+
+	// the same randomness gives the same point
+	c := P256()
+	seed := bytes.Repeat([]byte{0x42}, 256)
+	p1, err := c.EC.RandomPoint(bytes.NewReader(seed))
+	assert.Nil(t, err)
+	p2, err := c.EC.RandomPoint(bytes.NewReader(seed))
+	assert.Nil(t, err)
+	assert.True(t, p1.Equal(p2))
+
+	// not enough randomness
+	_, err = c.EC.RandomPoint(bytes.NewReader(nil))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,565 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+// countMaxBits is the maximum bit length of q for which Cardinality counts the
+// points one by one instead of using the Schoof algorithm
+const countMaxBits = 16
+
+// schoofMaxBits is the maximum bit length of q for which Cardinality uses the
+// Schoof algorithm, the bigger fields use SEA, which is faster past it
+const schoofMaxBits = 64
+
+// schoofSearchBits is the bit length of the number of candidates for the trace
+// below which schoof stops computing the trace modulo more primes l, and finds
+// it with a baby-step giant-step search over the points of the curve
+const schoofSearchBits = 36
+
+// schoofSearchPoints is the maximum number of points used by schoofSearch
+const schoofSearchPoints = 8
+
+// Cardinality returns the number of points of the elliptic curve, including the
+// point at infinity. For small fields the points are counted with the Legendre
+// symbol, for bigger fields the Schoof algorithm is used, up to q of
+// schoofMaxBits bits, and the SEA algorithm past it. It takes about 0.2s for 64
+// bits, 2s for 128 bits and 30s for 256 bits
+func (ec *EC) Cardinality() (*big.Int, error) {
+	if ec.isSingular() {
+		return nil, errors.New("singular curve")
+	}
+	if ec.Q.BitLen() <= countMaxBits {
+		return ec.countPoints(), nil
+	}
+	if ec.Q.BitLen() <= schoofMaxBits {
+		return ec.schoof(schoofSearchBits)
+	}
+	return ec.sea(schoofSearchBits)
+}
+
+// isSingular returns true when the discriminant 4a^3 + 27b^2 is zero mod q
+func (ec *EC) isSingular() bool {
+	a3 := new(big.Int).Exp(ec.A, big.NewInt(int64(3)), nil)
+	b2 := new(big.Int).Mul(ec.B, ec.B)
+	d := new(big.Int).Add(a3.Mul(a3, big.NewInt(int64(4))), b2.Mul(b2, big.NewInt(int64(27))))
+	return d.Mod(d, ec.Q).Sign() == 0
+}
+
+// countPoints returns q + 1 + sum of the Legendre symbols of x^3 + ax + b
+func (ec *EC) countPoints() *big.Int {
+	n := new(big.Int).Add(ec.Q, BigOne)
+	x := new(big.Int)
+	f := new(big.Int)
+	for ; x.Cmp(ec.Q) < 0; x.Add(x, BigOne) {
+		f.Mul(x, x)
+		f.Add(f, ec.A)
+		f.Mul(f, x)
+		f.Add(f, ec.B)
+		f.Mod(f, ec.Q)
+		n.Add(n, big.NewInt(int64(big.Jacobi(f, ec.Q))))
+	}
+	return n
+}
+
+// schoof computes the number of points with the Schoof algorithm: the trace t
+// of the Frobenius endomorphism is computed modulo small primes l, working over
+// the l-torsion points, and then reconstructed with the chinese remainder
+// theorem, as |t| <= 2 sqrt(q). When there are less than 2^searchBits candidates
+// for t left, they are checked with schoofSearch (searchBits 0 disables it)
+func (ec *EC) schoof(searchBits int) (*big.Int, error) {
+	pf := polyField{q: ec.Q}
+	a := new(big.Int).Mod(ec.A, ec.Q)
+	b := new(big.Int).Mod(ec.B, ec.Q)
+	f := pf.trim(poly{b, a, BigZero, BigOne})
+	psi := newDivisionPolys(pf, a, b, f, nil)
+	return ec.traceCRT(pf, f, searchBits, func(l int64) (int64, bool, error) {
+		t, err := schoofTrace(pf, a, f, psi.get(int(l)), ec.Q, l)
+		return t, true, err
+	})
+}
+
+// traceCRT returns the number of points q + 1 - t, where the trace t is
+// computed modulo 2 and modulo the odd primes l with traceMod, which can skip
+// a prime returning false, and reconstructed with the chinese remainder
+// theorem, or with schoofSearch when there are less than 2^searchBits
+// candidates left
+func (ec *EC) traceCRT(pf polyField, f poly, searchBits int, traceMod func(l int64) (int64, bool, error)) (*big.Int, error) {
+	// l = 2: t is even when x^3 + ax + b has a root, gcd(x^q - x, f) != 1
+	x := pf.monomial(1)
+	fm := newPolyModulus(pf, f)
+	xq := fm.exp(x, ec.Q)
+	t := big.NewInt(int64(0))
+	if pf.deg(pf.gcd(pf.sub(xq, x), f)) == 0 {
+		t.SetInt64(int64(1))
+	}
+	m := big.NewInt(int64(2))
+
+	// the primes l are used until their product is greater than 4 sqrt(q)
+	bound := new(big.Int).Mul(ec.Q, big.NewInt(int64(16)))
+	for l := int64(3); new(big.Int).Mul(m, m).Cmp(bound) <= 0; l += 2 {
+		bl := big.NewInt(l)
+		if !bl.ProbablyPrime(0) || bl.Cmp(ec.Q) == 0 {
+			continue
+		}
+		tl, ok, err := traceMod(l)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		// t = t + m * ((tl - t) * m^-1 mod l)
+		k := new(big.Int).Sub(big.NewInt(tl), t)
+		k.Mul(k, new(big.Int).ModInverse(m, bl))
+		k.Mod(k, bl)
+		t.Add(t, k.Mul(k, m))
+		m.Mul(m, bl)
+		if searchBits > 0 {
+			n, err := ec.schoofSearch(t, m, searchBits)
+			if err != nil || n != nil {
+				return n, err
+			}
+		}
+	}
+	if new(big.Int).Lsh(t, 1).Cmp(m) > 0 {
+		t.Sub(t, m)
+	}
+	n := new(big.Int).Add(ec.Q, BigOne)
+	return n.Sub(n, t), nil
+}
+
+// schoofSearch returns the number of points given the trace t0 modulo m, when
+// there are less than 2^searchBits candidates for the trace t. The number of
+// points is n = q + 1 - t1 - km, where t1 is the smallest candidate, and for
+// every point P, nP = O, so k(mP) = (q + 1 - t1)P, and k is searched with
+// baby-step giant-step. The candidates for k are intersected for several
+// points, and nil is returned if more than one remains, so more primes l have
+// to be used
+func (ec *EC) schoofSearch(t0, m *big.Int, searchBits int) (*big.Int, error) {
+	// t1 = -s + ((t0 + s) mod m), with s = floor(2 sqrt(q))
+	s := new(big.Int).Sqrt(new(big.Int).Lsh(ec.Q, 2))
+	t1 := new(big.Int).Add(t0, s)
+	t1.Mod(t1, m)
+	t1.Sub(t1, s)
+	// width = (s - t1) / m + 1
+	width := new(big.Int).Sub(s, t1)
+	width.Quo(width, m)
+	width.Add(width, BigOne)
+	if width.BitLen() > searchBits {
+		return nil, nil
+	}
+	base := new(big.Int).Add(ec.Q, BigOne)
+	base.Sub(base, t1)
+
+	var ks []*big.Int
+	points := 0
+	for x := int64(0); points < schoofSearchPoints && x < 16*schoofSearchPoints; x++ {
+		p, _, err := ec.At(big.NewInt(x))
+		if err != nil || p.Y.Sign() == 0 {
+			continue
+		}
+		target, err := ec.mulPublic(p, base)
+		if err != nil {
+			return nil, err
+		}
+		step, err := ec.mulPublic(p, m)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := ec.bsgs(target, step, width)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) >= bsgsMaxMatches {
+			// the order of mP is small, the list can be incomplete
+			continue
+		}
+		if points == 0 {
+			ks = matches
+		} else {
+			ks = intersect(ks, matches)
+		}
+		points++
+		if len(ks) == 0 {
+			return nil, errors.New("schoof: inconsistent trace")
+		}
+		if len(ks) == 1 {
+			n := new(big.Int).Mul(ks[0], m)
+			return n.Sub(base, n), nil
+		}
+	}
+	return nil, nil
+}
+
+// intersect returns the values that are in both sorted lists
+func intersect(a, b []*big.Int) []*big.Int {
+	var r []*big.Int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch a[i].Cmp(b[j]) {
+		case -1:
+			i++
+		case 1:
+			j++
+		default:
+			r = append(r, a[i])
+			i++
+			j++
+		}
+	}
+	return r
+}
+
+// divisionPolys computes the division polynomials of the curve, where the even
+// ones are stored divided by y, so all of them are polynomials in x. With a
+// modulus h they are computed mod h
+type divisionPolys struct {
+	pf   polyField
+	m    *polyModulus // nil for the whole polynomials
+	f2   poly         // (x^3 + ax + b)^2
+	half *big.Int
+	g    []poly
+}
+
+func newDivisionPolys(pf polyField, a, b *big.Int, f poly, m *polyModulus) *divisionPolys {
+	d := &divisionPolys{pf: pf, m: m}
+	d.f2 = d.mul(f, f)
+	d.half = new(big.Int).ModInverse(big.NewInt(int64(2)), pf.q)
+	n := func(v int64) *big.Int {
+		return big.NewInt(v)
+	}
+	mod := func(c *big.Int) *big.Int {
+		return c.Mod(c, pf.q)
+	}
+	a2 := new(big.Int).Mul(a, a)
+	// psi3 = 3x^4 + 6ax^2 + 12bx - a^2
+	psi3 := pf.trim(poly{
+		mod(new(big.Int).Neg(a2)),
+		mod(new(big.Int).Mul(n(12), b)),
+		mod(new(big.Int).Mul(n(6), a)),
+		BigZero,
+		mod(n(3)),
+	})
+	// psi4 / y = 4(x^6 + 5ax^4 + 20bx^3 - 5a^2x^2 - 4abx - 8b^2 - a^3)
+	c0 := new(big.Int).Mul(n(-8), new(big.Int).Mul(b, b))
+	c0.Sub(c0, new(big.Int).Mul(a2, a))
+	psi4 := pf.scale(pf.trim(poly{
+		mod(c0),
+		mod(new(big.Int).Mul(n(-4), new(big.Int).Mul(a, b))),
+		mod(new(big.Int).Mul(n(-5), a2)),
+		mod(new(big.Int).Mul(n(20), b)),
+		mod(new(big.Int).Mul(n(5), a)),
+		BigZero,
+		BigOne,
+	}), n(4))
+	d.g = []poly{{}, {BigOne}, pf.constant(n(2)), psi3, psi4}
+	if m != nil {
+		for i := range d.g {
+			d.g[i] = pf.mod(d.g[i], m.h)
+		}
+	}
+	return d
+}
+
+func (d *divisionPolys) mul(a, b poly) poly {
+	if d.m != nil {
+		return d.m.mul(a, b)
+	}
+	return d.pf.mul(a, b)
+}
+
+// get returns the n-th division polynomial (divided by y when n is even)
+func (d *divisionPolys) get(n int) poly {
+	pf := d.pf
+	for k := len(d.g); k <= n; k++ {
+		g := d.g
+		m := k / 2
+		var r poly
+		if k%2 == 1 {
+			t1 := d.mul(g[m+2], d.mul(g[m], d.mul(g[m], g[m])))
+			t2 := d.mul(g[m-1], d.mul(g[m+1], d.mul(g[m+1], g[m+1])))
+			if m%2 == 0 {
+				t1 = d.mul(t1, d.f2)
+			} else {
+				t2 = d.mul(t2, d.f2)
+			}
+			r = pf.sub(t1, t2)
+		} else {
+			t1 := d.mul(g[m+2], d.mul(g[m-1], g[m-1]))
+			t2 := d.mul(g[m-2], d.mul(g[m+1], g[m+1]))
+			r = pf.scale(d.mul(g[m], pf.sub(t1, t2)), d.half)
+		}
+		d.g = append(d.g, r)
+	}
+	return d.g[n]
+}
+
+// schoofTrace returns the trace of the Frobenius modulo the odd prime l, where
+// psi is the l-th division polynomial
+func schoofTrace(pf polyField, a *big.Int, f, psi poly, q *big.Int, l int64) (int64, error) {
+	x := pf.monomial(1)
+	h := pf.monic(psi)
+	m := newPolyModulus(pf, h)
+	// (x^q, y^q) = (x^q, y f^((q-1)/2))
+	xq := m.exp(x, q)
+	yq := m.exp(f, new(big.Int).Rsh(q, 1))
+	// (x^q^2, y^q^2) = (x^q^2, y (f^((q-1)/2))^(q+1))
+	xq2 := m.exp(xq, q)
+	yq2 := m.mul(m.exp(yq, q), yq)
+	for {
+		c := newSchoofCurve(pf, m, a, f)
+		t, factor, err := c.trace(xq, yq, xq2, yq2, q, l)
+		if err != nil || factor == nil {
+			return t, err
+		}
+		// continue working only with the points whose x is a root of factor
+		m = newPolyModulus(pf, factor)
+		xq, yq = pf.mod(xq, factor), pf.mod(yq, factor)
+		xq2, yq2 = pf.mod(xq2, factor), pf.mod(yq2, factor)
+	}
+}
+
+// schoofCurve implements the point arithmetic of the curve over the ring
+// F_q[x]/(h), where h is a factor of a division polynomial. A generic point
+// (x, y) of the curve is represented as (x, y*v(x)), and the curve
+// f(x)*v^2 = u^3 + au + b is mapped to u'^2 = v'^3 + a f^2 u' + b f^3 by
+// (u, v) -> (f*u, f^2*v), so all the coordinates are polynomials in x
+type schoofCurve struct {
+	pf polyField
+	m  *polyModulus
+	a  poly // a * f^2
+	f  poly
+}
+
+// ringPoint is an affine point of the schoofCurve, inf is the point at infinity
+type ringPoint struct {
+	x, y poly
+	inf  bool
+}
+
+// ringJacobian is a point of the schoofCurve in Jacobian coordinates
+type ringJacobian struct {
+	x, y, z poly
+	inf     bool
+}
+
+func newSchoofCurve(pf polyField, m *polyModulus, a *big.Int, f poly) *schoofCurve {
+	fr := m.reduce(pf.mod(f, m.h))
+	return &schoofCurve{
+		pf: pf,
+		m:  m,
+		a:  m.reduce(pf.scale(m.mul(fr, fr), a)),
+		f:  fr,
+	}
+}
+
+// point returns the mapped point (x, y*v)
+func (c *schoofCurve) point(x, v poly) ringPoint {
+	return ringPoint{x: c.m.mul(c.f, x), y: c.m.mul(c.m.mul(c.f, c.f), v)}
+}
+
+// trace returns the trace of the Frobenius modulo l, or a non trivial factor of
+// h when a non invertible element is found
+func (c *schoofCurve) trace(xq, yq, xq2, yq2 poly, q *big.Int, l int64) (int64, poly, error) {
+	pf := c.pf
+	p := c.point(pf.monomial(1), poly{BigOne})
+	frob := c.point(xq, yq)
+	frob2 := c.point(xq2, yq2)
+
+	// qP, with q reduced mod l
+	qP := c.scalarMul(p, new(big.Int).Mod(q, big.NewInt(l)).Int64())
+	qPAffine, factor := c.toAffine(qP)
+	if factor != nil {
+		return 0, factor, nil
+	}
+	// frob^2(P) + qP = t frob(P)
+	lhs, factor := c.add(frob2, qPAffine)
+	if factor != nil {
+		return 0, factor, nil
+	}
+	if lhs.inf {
+		return 0, nil, nil
+	}
+	t, err := c.multiple(frob, lhs, l)
+	return t, nil, err
+}
+
+// multiple returns the k in [1, l) with k p = target, where p has order l and
+// target is not the point at infinity
+func (c *schoofCurve) multiple(p, target ringPoint, l int64) (int64, error) {
+	pf := c.pf
+	kP := ringJacobian{x: p.x, y: p.y, z: poly{BigOne}}
+	for k := int64(1); k <= (l-1)/2; k++ {
+		if k == 2 {
+			kP = c.jacobianDouble(kP)
+		} else if k > 2 {
+			kP = c.jacobianAdd(kP, ringJacobian{x: p.x, y: p.y, z: poly{BigOne}})
+		}
+		z2 := c.m.mul(kP.z, kP.z)
+		if !pf.equal(kP.x, c.m.mul(target.x, z2)) {
+			continue
+		}
+		yz3 := c.m.mul(target.y, c.m.mul(z2, kP.z))
+		if pf.equal(kP.y, yz3) {
+			return k, nil
+		}
+		if pf.equal(kP.y, pf.neg(yz3)) {
+			return l - k, nil
+		}
+		return 0, errors.New("schoof: inconsistent trace")
+	}
+	return 0, errors.New("schoof: trace not found")
+}
+
+// inverse returns the inverse of a non zero element, or a non trivial factor
+// of h when it is not invertible
+func (c *schoofCurve) inverse(a poly) (poly, poly) {
+	inv, g := c.pf.invMod(a, c.m.h)
+	if inv != nil {
+		return inv, nil
+	}
+	// use the smallest factor, as the computations will be faster
+	other, _ := c.pf.divMod(c.m.h, g)
+	if c.pf.deg(other) < c.pf.deg(g) {
+		return nil, c.pf.monic(other)
+	}
+	return nil, g
+}
+
+func (c *schoofCurve) add(p1, p2 ringPoint) (ringPoint, poly) {
+	pf, m := c.pf, c.m
+	if p1.inf {
+		return p2, nil
+	}
+	if p2.inf {
+		return p1, nil
+	}
+	dx := pf.sub(p1.x, p2.x)
+	if len(dx) == 0 {
+		if len(pf.add(p1.y, p2.y)) == 0 {
+			return ringPoint{inf: true}, nil
+		}
+		dy := pf.sub(p1.y, p2.y)
+		if len(dy) == 0 {
+			return c.double(p1)
+		}
+		// the points are equal for some roots of h and opposite for others
+		_, factor := c.inverse(dy)
+		return ringPoint{}, factor
+	}
+	inv, factor := c.inverse(dx)
+	if factor != nil {
+		return ringPoint{}, factor
+	}
+	s := m.mul(pf.sub(p1.y, p2.y), inv)
+	return c.line(p1, p2, s), nil
+}
+
+func (c *schoofCurve) double(p ringPoint) (ringPoint, poly) {
+	pf, m := c.pf, c.m
+	if p.inf || len(p.y) == 0 {
+		return ringPoint{inf: true}, nil
+	}
+	inv, factor := c.inverse(pf.add(p.y, p.y))
+	if factor != nil {
+		return ringPoint{}, factor
+	}
+	x2 := m.mul(p.x, p.x)
+	num := pf.add(pf.add(x2, pf.add(x2, x2)), c.a)
+	return c.line(p, p, m.mul(num, inv)), nil
+}
+
+// line returns the third point of the line of slope s through p1 and p2, negated
+func (c *schoofCurve) line(p1, p2 ringPoint, s poly) ringPoint {
+	pf, m := c.pf, c.m
+	x3 := pf.sub(pf.sub(m.mul(s, s), p1.x), p2.x)
+	y3 := pf.sub(m.mul(s, pf.sub(p1.x, x3)), p1.y)
+	return ringPoint{x: x3, y: y3}
+}
+
+func (c *schoofCurve) toAffine(p ringJacobian) (ringPoint, poly) {
+	if p.inf {
+		return ringPoint{inf: true}, nil
+	}
+	zInv, factor := c.inverse(p.z)
+	if factor != nil {
+		return ringPoint{}, factor
+	}
+	m := c.m
+	zInv2 := m.mul(zInv, zInv)
+	return ringPoint{x: m.mul(p.x, zInv2), y: m.mul(p.y, m.mul(zInv2, zInv))}, nil
+}
+
+// scalarMul returns kP, it is used only for k < l, so the partial sums are
+// never equal or opposite and the Jacobian formulas can be used without
+// inversions
+func (c *schoofCurve) scalarMul(p ringPoint, k int64) ringJacobian {
+	base := ringJacobian{x: p.x, y: p.y, z: poly{BigOne}}
+	r := ringJacobian{inf: true}
+	for i := 62; i >= 0; i-- {
+		if !r.inf {
+			r = c.jacobianDouble(r)
+		}
+		if (k>>uint(i))&1 == 1 {
+			r = c.jacobianAdd(r, base)
+		}
+	}
+	return r
+}
+
+func (c *schoofCurve) jacobianDouble(p ringJacobian) ringJacobian {
+	pf, m := c.pf, c.m
+	if p.inf || len(p.y) == 0 {
+		return ringJacobian{inf: true}
+	}
+	xx := m.mul(p.x, p.x)
+	yy := m.mul(p.y, p.y)
+	zz := m.mul(p.z, p.z)
+	// s = 4 x y^2
+	s := m.mul(p.x, yy)
+	s = pf.add(s, s)
+	s = pf.add(s, s)
+	// mm = 3 x^2 + a z^4
+	mm := pf.add(pf.add(xx, pf.add(xx, xx)), m.mul(c.a, m.mul(zz, zz)))
+	x3 := pf.sub(m.mul(mm, mm), pf.add(s, s))
+	// 8 y^4
+	y4 := m.mul(yy, yy)
+	y4 = pf.add(y4, y4)
+	y4 = pf.add(y4, y4)
+	y4 = pf.add(y4, y4)
+	y3 := pf.sub(m.mul(mm, pf.sub(s, x3)), y4)
+	z3 := m.mul(pf.add(p.y, p.y), p.z)
+	return ringJacobian{x: x3, y: y3, z: z3}
+}
+
+func (c *schoofCurve) jacobianAdd(p1, p2 ringJacobian) ringJacobian {
+	pf, m := c.pf, c.m
+	if p1.inf {
+		return p2
+	}
+	if p2.inf {
+		return p1
+	}
+	z1z1 := m.mul(p1.z, p1.z)
+	z2z2 := m.mul(p2.z, p2.z)
+	u1 := m.mul(p1.x, z2z2)
+	u2 := m.mul(p2.x, z1z1)
+	s1 := m.mul(p1.y, m.mul(p2.z, z2z2))
+	s2 := m.mul(p2.y, m.mul(p1.z, z1z1))
+	h := pf.sub(u2, u1)
+	r := pf.sub(s2, s1)
+	if len(h) == 0 {
+		if len(r) == 0 {
+			return c.jacobianDouble(p1)
+		}
+		return ringJacobian{inf: true}
+	}
+	hh := m.mul(h, h)
+	hhh := m.mul(h, hh)
+	v := m.mul(u1, hh)
+	x3 := pf.sub(pf.sub(m.mul(r, r), hhh), pf.add(v, v))
+	y3 := pf.sub(m.mul(r, pf.sub(v, x3)), m.mul(s1, hhh))
+	z3 := m.mul(m.mul(p1.z, p2.z), h)
+	return ringJacobian{x: x3, y: y3, z: z3}
+}
@@ -0,0 +1,114 @@
+package ecc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardinalityCount(t *testing.T) {
+	for _, c := range []Curve{Toy11(), Toy19(), Toy29()} {
+		n, err := c.EC.Cardinality()
+		assert.Nil(t, err)
+		assert.Equal(t, new(big.Int).Mul(c.N, c.H), n, c.Name)
+	}
+}
+
+func TestSchoofSmallCurves(t *testing.T) {
+	// compare the Schoof algorithm with the count of the points
+	for _, q := range []int64{65537, 131071} {
+		for _, ab := range [][2]int64{{0, 7}, {1, 18}, {-3, 5}, {12345, 6789}, {2, 0}} {
+			ec := NewEC(big.NewInt(ab[0]), big.NewInt(ab[1]), big.NewInt(q))
+			if ec.isSingular() {
+				continue
+			}
+			count := ec.countPoints()
+			n, err := ec.schoof(0)
+			assert.Nil(t, err)
+			assert.Equal(t, count, n, "q=%d a=%d b=%d", q, ab[0], ab[1])
+			// finishing with baby-step giant-step
+			n, err = ec.schoof(schoofSearchBits)
+			assert.Nil(t, err)
+			assert.Equal(t, count, n, "q=%d a=%d b=%d", q, ab[0], ab[1])
+		}
+	}
+}
+
+func TestSchoof64(t *testing.T) {
+	// y^2 = x^3 + 7 mod 2^64 - 59
+	q, _ := new(big.Int).SetString("18446744073709551557", 10)
+	ec := NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), q)
+	n, err := ec.Cardinality()
+	assert.Nil(t, err)
+
+	// the number of points annihilates every point of the curve
+	for x := int64(1); x < 8; x++ {
+		p, ok := pointAt(ec, big.NewInt(x))
+		if !ok {
+			continue
+		}
+		nP, err := ec.Mul(p, n)
+		assert.Nil(t, err)
+		assert.True(t, nP.Equal(ZeroPoint))
+
+		// and matches with the multiple of the order found by baby-step giant-step
+		order, err := ec.Order(p)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, new(big.Int).Mod(n, order).Sign())
+	}
+}
+
+func TestOrderBigCurve(t *testing.T) {
+	// curve of 80 bits, where the order is computed with the Schoof algorithm
+	q, _ := new(big.Int).SetString("1208925819614629174706189", 10) // 2^80 + 13
+	ec := NewEC(big.NewInt(int64(-3)), big.NewInt(int64(5)), q)
+	var g Point
+	for x := int64(1); ; x++ {
+		p, ok := pointAt(ec, big.NewInt(x))
+		if ok {
+			g = p
+			break
+		}
+	}
+	order, err := ec.Order(g)
+	assert.Nil(t, err)
+	oG, err := ec.Mul(g, order)
+	assert.Nil(t, err)
+	assert.True(t, oG.Equal(ZeroPoint))
+}
+
+func TestSEA128(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the 128 bit SEA in short mode")
+	}
+	// y^2 = x^3 - 3x + 5 mod 2^128 - 159
+	q, _ := new(big.Int).SetString("340282366920938463463374607431768211297", 10)
+	ec := NewEC(big.NewInt(int64(-3)), big.NewInt(int64(5)), q)
+	n, err := ec.Cardinality()
+	assert.Nil(t, err)
+	assert.Equal(t, "340282366920938463490621841593987047420", n.String())
+
+	for x := int64(1); x < 4; x++ {
+		p, ok := pointAt(ec, big.NewInt(x))
+		if !ok {
+			continue
+		}
+		nP, err := ec.Mul(p, n)
+		assert.Nil(t, err)
+		assert.True(t, nP.Equal(ZeroPoint))
+	}
+}
+
+// pointAt returns the point of the curve with the given x, if it exists
+func pointAt(ec EC, x *big.Int) (Point, bool) {
+	f := new(big.Int).Exp(x, big.NewInt(int64(3)), nil)
+	f.Add(f, new(big.Int).Mul(ec.A, x))
+	f.Add(f, ec.B)
+	f.Mod(f, ec.Q)
+	y := new(big.Int).ModSqrt(f, ec.Q)
+	if y == nil {
+		return Point{}, false
+	}
+	return Point{x, y}, true
+}
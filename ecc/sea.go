@@ -0,0 +1,406 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+// seaMaxL is the biggest prime l used by the SEA algorithm, whose modular
+// polynomials take too long to compute past it
+const seaMaxL = 400
+
+// seaSchoofMaxL is the biggest prime l for which the trace is computed with
+// the division polynomial (as in the Schoof algorithm) when l is not an Elkies
+// prime
+const seaSchoofMaxL = 13
+
+// sea computes the number of points with the Schoof-Elkies-Atkin algorithm:
+// as in the Schoof algorithm the trace t of the Frobenius endomorphism is
+// computed modulo small primes l, but for the Elkies primes, where the
+// Frobenius has an eigenvalue on the l-torsion, its action is computed over the
+// kernel of an l-isogeny, whose polynomial has degree (l - 1) / 2 instead of
+// (l^2 - 1) / 2. The kernel is found with the canonical modular polynomial
+// Psi_l, about half of the primes are Elkies primes, and the remaining ones are
+// skipped or, when small, computed with the division polynomials. The curves
+// with j = 0 or j = 1728 are counted with cmCardinality
+func (ec *EC) sea(searchBits int) (*big.Int, error) {
+	pf := polyField{q: ec.Q}
+	a := new(big.Int).Mod(ec.A, ec.Q)
+	b := new(big.Int).Mod(ec.B, ec.Q)
+	if a.Sign() == 0 || b.Sign() == 0 {
+		return ec.cmCardinality(a, b)
+	}
+	f := pf.trim(poly{b, a, BigZero, BigOne})
+	e := newElkies(pf, a, b, f)
+	psi := newDivisionPolys(pf, a, b, f, nil)
+	return ec.traceCRT(pf, f, searchBits, func(l int64) (int64, bool, error) {
+		if l > seaMaxL {
+			return 0, false, errors.New("sea: not enough Elkies primes")
+		}
+		t, ok := e.trace(int(l))
+		if ok || l > seaSchoofMaxL {
+			return t, ok, nil
+		}
+		t, err := schoofTrace(pf, a, f, psi.get(int(l)), ec.Q, l)
+		return t, true, err
+	})
+}
+
+// elkies computes the trace of the Frobenius modulo the Elkies primes of the
+// curve y^2 = x^3 + ax + b, with a, b != 0
+type elkies struct {
+	pf   polyField
+	a, b *big.Int
+	j    *big.Int
+	f    poly // x^3 + ax + b
+}
+
+func newElkies(pf polyField, a, b *big.Int, f poly) *elkies {
+	// j = 1728 4a^3 / (4a^3 + 27b^2)
+	a3 := new(big.Int).Exp(a, big.NewInt(int64(3)), pf.q)
+	a3.Mul(a3, big.NewInt(int64(4)))
+	d := new(big.Int).Mul(b, b)
+	d.Mul(d, big.NewInt(int64(27)))
+	d.Add(d, a3)
+	j := new(big.Int).Mul(a3, big.NewInt(int64(1728)))
+	j.Mul(j, new(big.Int).ModInverse(d, pf.q))
+	return &elkies{pf: pf, a: a, b: b, j: j.Mod(j, pf.q), f: f}
+}
+
+// trace returns the trace of the Frobenius modulo l, or false when l is not an
+// Elkies prime (or the kernel can not be computed, for the special cases)
+func (e *elkies) trace(l int) (int64, bool) {
+	pf := e.pf
+	q := pf.q
+	psi := canonicalModular(pf, l)
+	// l is an Elkies prime when Psi_l(X, j) has a root in F_q, there are 1 or 2
+	// of them, or l + 1 when all the isogenies are defined over F_q
+	roots := pf.roots(psi.evalJ(pf, e.j))
+	if len(roots) == 0 || len(roots) == l+1 {
+		return 0, false
+	}
+	s := modularExponent(l)
+	bl := big.NewInt(int64(l))
+	ls := new(big.Int).Exp(bl, big.NewInt(int64(s)), q)
+	for _, f0 := range roots {
+		if f0.Sign() == 0 {
+			continue
+		}
+		dx, dj := psi.partials(pf, f0, e.j)
+		if dx.Sign() == 0 || dj.Sign() == 0 {
+			continue
+		}
+		// with E4 = -48a, E6 = 864b: Dj = -j E6 / E4 = 18 j b / a, where D is
+		// q d/dq, and Df = -Psi_J Dj / Psi_X
+		djv := e.mulInv(mul(pf, big.NewInt(int64(18)), e.j, e.b), e.a)
+		df := e.mulInv(mul(pf, dj, djv), dx)
+		df.Mod(df.Neg(df), q)
+		// the sum of the x of the l - 1 points of the kernel is
+		// (l / 12)(E2(tau) - l E2(l tau)) = -(l / s) Df / f
+		p1 := e.mulInv(mul(pf, bl, df), mul(pf, big.NewInt(int64(s)), f0))
+		p1.Mod(p1.Neg(p1), q)
+
+		// the isogenous curve has j(l tau), which is a root of Psi_l(l^s / f, J)
+		ft := e.mulInv(ls, f0)
+		dft := e.mulInv(mul(pf, ft, df), f0)
+		dft.Mod(dft.Neg(dft), q)
+		for _, jt := range pf.roots(psi.evalX(pf, ft)) {
+			h := e.kernel(psi, l, ft, dft, jt, p1)
+			if h == nil {
+				continue
+			}
+			if t, ok := e.eigenTrace(h, l); ok {
+				return t, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// kernel returns the kernel polynomial of the isogeny to the curve with
+// j(l tau) = jt, given f(l tau) = ft and its derivative dft, and the sum p1 of
+// the x of the points of the kernel. It returns nil when jt is not the one of
+// an isogenous curve
+func (e *elkies) kernel(psi bipoly, l int, ft, dft, jt, p1 *big.Int) poly {
+	pf := e.pf
+	q := pf.q
+	jt1728 := new(big.Int).Sub(jt, big.NewInt(int64(1728)))
+	if jt.Sign() == 0 || jt1728.Mod(jt1728, q).Sign() == 0 {
+		return nil
+	}
+	dx, dj := psi.partials(pf, ft, jt)
+	if dj.Sign() == 0 {
+		return nil
+	}
+	bl := big.NewInt(int64(l))
+	// Dj~ = -Psi_X Df~ / Psi_J, and Dj~ = -l j~ E6~ / E4~, with E4~ = E4(l tau),
+	// so r = E6~ / E4~ = Psi_X Df~ / (Psi_J l j~), E4~ = r^2 j~ / (j~ - 1728)
+	// and E6~ = r E4~
+	r := e.mulInv(mul(pf, dx, dft), mul(pf, dj, bl, jt))
+	e4 := e.mulInv(mul(pf, r, r, jt), jt1728)
+	e6 := mul(pf, r, e4)
+	// the isogenous curve y^2 = x^3 + a~x + b~, with a~ = -l^4 E4~ / 48 and
+	// b~ = l^6 E6~ / 864, for which the isogeny is normalized
+	l2 := mul(pf, bl, bl)
+	at := e.mulInv(mul(pf, l2, l2, e4), big.NewInt(int64(48)))
+	at.Mod(at.Neg(at), q)
+	bt := e.mulInv(mul(pf, l2, l2, l2, e6), big.NewInt(int64(864)))
+	h := kernelPoly(pf, e.a, e.b, at, bt, p1, (l-1)/2)
+
+	// check that h divides the division polynomial psi_l
+	if len(newDivisionPolys(pf, e.a, e.b, e.f, newPolyModulus(pf, h)).get(l)) != 0 {
+		return nil
+	}
+	return h
+}
+
+// eigenTrace returns t = lambda + q / lambda mod l, where lambda is the
+// eigenvalue of the Frobenius on the kernel of the isogeny: (x^q, y^q) is
+// lambda (x, y) mod h
+func (e *elkies) eigenTrace(h poly, l int) (int64, bool) {
+	pf := e.pf
+	q := pf.q
+	m := newPolyModulus(pf, h)
+	c := newSchoofCurve(pf, m, e.a, e.f)
+	x := pf.monomial(1)
+	p := c.point(x, poly{BigOne})
+	frob := c.point(m.exp(x, q), m.exp(e.f, new(big.Int).Rsh(q, 1)))
+	lambda, err := c.multiple(p, frob, int64(l))
+	if err != nil {
+		return 0, false
+	}
+	bl := big.NewInt(int64(l))
+	t := new(big.Int).ModInverse(big.NewInt(lambda), bl)
+	t.Mul(t, q)
+	t.Add(t, big.NewInt(lambda))
+	return t.Mod(t, bl).Int64(), true
+}
+
+// mulInv returns a / b mod q
+func (e *elkies) mulInv(a, b *big.Int) *big.Int {
+	r := new(big.Int).ModInverse(b, e.pf.q)
+	r.Mul(r, a)
+	return r.Mod(r, e.pf.q)
+}
+
+// mul returns the product of the values mod q
+func mul(pf polyField, v ...*big.Int) *big.Int {
+	r := big.NewInt(int64(1))
+	for _, x := range v {
+		r.Mul(r, x)
+		r.Mod(r, pf.q)
+	}
+	return r
+}
+
+// kernelPoly returns the polynomial of degree d = (l - 1) / 2 whose roots are
+// the x of the points of the kernel of the normalized l-isogeny from
+// y^2 = x^3 + ax + b to y^2 = x^3 + at x + bt, given the sum p1 of the x of
+// the l - 1 points of the kernel. The Laurent series of the Weierstrass
+// functions of the curves are related by
+// wp~(z) = wp(z) + sum_P (wp(z + P) - wp(P)), so their coefficients
+// c~_k - c_k = sum_P wp^(2k)(P) / (2k)!, where the wp^(2k) are polynomials
+// T_k in wp, which gives the power sums of the x of the kernel
+func kernelPoly(pf polyField, a, b, at, bt, p1 *big.Int, d int) poly {
+	q := pf.q
+	c := weierstrassCoeffs(pf, a, b, d)
+	ct := weierstrassCoeffs(pf, at, bt, d)
+	// the power sums of the d roots, p[0] = d and p[1] = p1 / 2
+	p := make([]*big.Int, d+1)
+	p[0] = big.NewInt(int64(d))
+	half := new(big.Int).ModInverse(big.NewInt(int64(2)), q)
+	p[1] = mul(pf, p1, half)
+	// T_0 = X, T_k = 4 (X^3 + aX + b) T''_(k-1) + (6X^2 + 2a) T'_(k-1)
+	f4 := pf.scale(pf.trim(poly{b, a, BigZero, BigOne}), big.NewInt(int64(4)))
+	d2 := pf.trim(poly{new(big.Int).Lsh(a, 1), BigZero, big.NewInt(int64(6))})
+	tk := pf.monomial(1)
+	fact := big.NewInt(int64(1)) // (2k)! / 2
+	for k := 1; k < d; k++ {
+		d1 := pf.deriv(tk)
+		tk = pf.add(pf.mul(f4, pf.deriv(d1)), pf.mul(d2, d1))
+		if k > 1 {
+			fact.Mul(fact, big.NewInt(int64((2*k-1)*2*k)))
+			fact.Mod(fact, q)
+		}
+		// sum_i T_k(x_i) = (2k)! / 2 (c~_k - c_k), solved for p[k+1]
+		s := new(big.Int).Sub(ct[k], c[k])
+		s.Mul(s, fact)
+		for i := 0; i <= k; i++ {
+			s.Sub(s, new(big.Int).Mul(coefficient(tk, i), p[i]))
+		}
+		s.Mod(s, q)
+		p[k+1] = mul(pf, s, new(big.Int).ModInverse(tk[k+1], q))
+	}
+
+	// Newton identities: i e_i = sum_{k=1}^{i} (-1)^(k-1) e_(i-k) p_k, and
+	// h = sum_i (-1)^i e_i x^(d-i)
+	e := make([]*big.Int, d+1)
+	e[0] = big.NewInt(int64(1))
+	h := make(poly, d+1)
+	h[d] = e[0]
+	for i := 1; i <= d; i++ {
+		s := new(big.Int)
+		for k := 1; k <= i; k++ {
+			t := new(big.Int).Mul(e[i-k], p[k])
+			if k%2 == 1 {
+				s.Add(s, t)
+			} else {
+				s.Sub(s, t)
+			}
+		}
+		s.Mod(s, q)
+		e[i] = mul(pf, s, new(big.Int).ModInverse(big.NewInt(int64(i)), q))
+		if i%2 == 0 {
+			h[d-i] = e[i]
+		} else {
+			h[d-i] = new(big.Int).Mod(new(big.Int).Neg(e[i]), q)
+		}
+	}
+	return pf.trim(h)
+}
+
+// weierstrassCoeffs returns the coefficients c_k, k in [1, n], of the Laurent
+// series wp(z) = z^-2 + sum c_k z^(2k) of the curve y^2 = x^3 + ax + b, with
+// c_1 = -a/5, c_2 = -b/7 and c_k = 3 / ((k - 2)(2k + 3)) sum_{h=1}^{k-2} c_h c_(k-1-h)
+func weierstrassCoeffs(pf polyField, a, b *big.Int, n int) []*big.Int {
+	q := pf.q
+	c := make([]*big.Int, n+1)
+	c[0] = BigZero
+	inv := func(v int) *big.Int {
+		return new(big.Int).ModInverse(big.NewInt(int64(v)), q)
+	}
+	if n >= 1 {
+		c[1] = mul(pf, new(big.Int).Neg(a), inv(5))
+	}
+	if n >= 2 {
+		c[2] = mul(pf, new(big.Int).Neg(b), inv(7))
+	}
+	for k := 3; k <= n; k++ {
+		s := new(big.Int)
+		for h := 1; h <= k-2; h++ {
+			s.Add(s, new(big.Int).Mul(c[h], c[k-1-h]))
+		}
+		s.Mod(s, q)
+		c[k] = mul(pf, s, big.NewInt(int64(3)), inv((k-2)*(2*k+3)))
+	}
+	return c
+}
+
+// evalJ returns Psi(X, j) as a polynomial in X
+func (psi bipoly) evalJ(pf polyField, j *big.Int) poly {
+	r := make(poly, len(psi))
+	for i, c := range psi {
+		r[i] = pf.eval(c, j)
+	}
+	return pf.trim(r)
+}
+
+// evalX returns Psi(x, J) as a polynomial in J
+func (psi bipoly) evalX(pf polyField, x *big.Int) poly {
+	r := poly{}
+	xi := big.NewInt(int64(1))
+	for _, c := range psi {
+		r = pf.add(r, pf.scale(c, xi))
+		xi = mul(pf, xi, x)
+	}
+	return r
+}
+
+// partials returns the partial derivatives of Psi in X and J at (x, j)
+func (psi bipoly) partials(pf polyField, x, j *big.Int) (*big.Int, *big.Int) {
+	dx := new(big.Int)
+	dj := new(big.Int)
+	xi := big.NewInt(int64(1)) // x^i
+	for i, c := range psi {
+		if i+1 < len(psi) {
+			// (i + 1) c_(i+1)(j) x^i
+			dx.Add(dx, mul(pf, big.NewInt(int64(i+1)), pf.eval(psi[i+1], j), xi))
+		}
+		dj.Add(dj, mul(pf, pf.eval(pf.deriv(c), j), xi))
+		xi = mul(pf, xi, x)
+	}
+	return dx.Mod(dx, pf.q), dj.Mod(dj, pf.q)
+}
+
+// cmCardinality returns the number of points of the curves with a = 0 (j = 0)
+// or b = 0 (j = 1728), where the Frobenius is an element of norm q of Z[w] or
+// Z[i]. They are supersingular, with q + 1 points, when q = 2 mod 3 (a = 0) or
+// q = 3 mod 4 (b = 0). Otherwise q = c^2 + 3d^2 or q = c^2 + d^2, and the trace
+// is the one of an associate of c + d sqrt(-3) or c + d i, which is found
+// checking the candidates over the points of the curve
+func (ec *EC) cmCardinality(a, b *big.Int) (*big.Int, error) {
+	q := ec.Q
+	n := new(big.Int).Add(q, BigOne)
+	var traces []*big.Int
+	if a.Sign() == 0 {
+		if new(big.Int).Mod(q, big.NewInt(int64(3))).Int64() == 2 {
+			return n, nil
+		}
+		c, d := cornacchia(3, q)
+		if c == nil {
+			return nil, errors.New("sea: q is not of the form c^2 + 3d^2")
+		}
+		// the traces of the associates: 2c, -c - 3d, -c + 3d and their opposites
+		d3 := new(big.Int).Mul(d, big.NewInt(int64(3)))
+		traces = []*big.Int{new(big.Int).Lsh(c, 1), new(big.Int).Add(c, d3), new(big.Int).Sub(c, d3)}
+	} else {
+		if q.Bit(1) == 1 {
+			return n, nil
+		}
+		c, d := cornacchia(1, q)
+		if c == nil {
+			return nil, errors.New("sea: q is not of the form c^2 + d^2")
+		}
+		traces = []*big.Int{new(big.Int).Lsh(c, 1), new(big.Int).Lsh(d, 1)}
+	}
+	var candidates []*big.Int
+	for _, t := range traces {
+		candidates = append(candidates, new(big.Int).Sub(n, t), new(big.Int).Add(n, t))
+	}
+	for x := int64(0); len(candidates) > 1 && x < 1024; x++ {
+		p, _, err := ec.At(big.NewInt(x))
+		if err != nil || p.Y.Sign() == 0 {
+			continue
+		}
+		var keep []*big.Int
+		for _, c := range candidates {
+			r, err := ec.mulPublic(p, c)
+			if err != nil {
+				return nil, err
+			}
+			if r.Equal(ZeroPoint) {
+				keep = append(keep, c)
+			}
+		}
+		candidates = keep
+	}
+	if len(candidates) != 1 {
+		return nil, errors.New("sea: number of points not found")
+	}
+	return candidates[0], nil
+}
+
+// cornacchia returns c, d with c^2 + k d^2 = q, for the prime q, or nil when
+// there is no solution
+func cornacchia(k int64, q *big.Int) (*big.Int, *big.Int) {
+	r := new(big.Int).ModSqrt(new(big.Int).Mod(big.NewInt(-k), q), q)
+	if r == nil {
+		return nil, nil
+	}
+	if new(big.Int).Lsh(r, 1).Cmp(q) < 0 {
+		r.Sub(q, r)
+	}
+	// Euclidean algorithm on q and r until the remainder is below sqrt(q)
+	r0, r1 := new(big.Int).Set(q), r
+	limit := new(big.Int).Sqrt(q)
+	for r1.Cmp(limit) > 0 {
+		r0, r1 = r1, new(big.Int).Mod(r0, r1)
+	}
+	rest := new(big.Int).Sub(q, new(big.Int).Mul(r1, r1))
+	d, m := new(big.Int).QuoRem(rest, big.NewInt(k), new(big.Int))
+	s := new(big.Int).Sqrt(d)
+	if m.Sign() != 0 || new(big.Int).Mul(s, s).Cmp(d) != 0 {
+		return nil, nil
+	}
+	return r1, s
+}
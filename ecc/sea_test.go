@@ -0,0 +1,129 @@
+package ecc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalModular(t *testing.T) {
+	q, _ := new(big.Int).SetString("18446744073709551557", 10) // 2^64 - 59
+	pf := polyField{q: q}
+	minusOne := new(big.Int).Sub(q, BigOne)
+	// Psi_3 = X^4 + 36X^3 + 270X^2 + (756 - J)X + 729
+	psi := canonicalModular(pf, 3)
+	assert.Equal(t, 5, len(psi))
+	for i, c := range []poly{{big.NewInt(int64(729))}, {big.NewInt(int64(756)), minusOne},
+		{big.NewInt(int64(270))}, {big.NewInt(int64(36))}, {BigOne}} {
+		assert.Equal(t, c, psi[i], "X^%d", i)
+	}
+	// Psi_5 = X^6 + 30X^5 + 315X^4 + 1300X^3 + 1575X^2 + (750 - J)X + 125
+	psi = canonicalModular(pf, 5)
+	assert.Equal(t, 7, len(psi))
+	for i, c := range []poly{{big.NewInt(int64(125))}, {big.NewInt(int64(750)), minusOne},
+		{big.NewInt(int64(1575))}, {big.NewInt(int64(1300))}, {big.NewInt(int64(315))},
+		{big.NewInt(int64(30))}, {BigOne}} {
+		assert.Equal(t, c, psi[i], "X^%d", i)
+	}
+	// the degree in J is v = s (l - 1) / 12
+	for _, l := range []int{7, 11, 13} {
+		psi = canonicalModular(pf, l)
+		assert.Equal(t, l+2, len(psi))
+		s := modularExponent(l)
+		assert.Equal(t, s*(l-1)/12+1, len(psi[1]), "l=%d", l)
+	}
+}
+
+func TestElkiesKernel(t *testing.T) {
+	// the Elkies primes have a kernel polynomial (checked to divide the division
+	// polynomial), which gives the trace mod l
+	q, _ := new(big.Int).SetString("18446744073709551557", 10)
+	pf := polyField{q: q}
+	a, b := big.NewInt(int64(1)), big.NewInt(int64(18))
+	f := pf.trim(poly{b, a, BigZero, BigOne})
+	e := newElkies(pf, a, b, f)
+	ec := NewEC(a, b, q)
+	n, err := ec.schoof(schoofSearchBits)
+	assert.Nil(t, err)
+	tr := new(big.Int).Add(q, BigOne)
+	tr.Sub(tr, n)
+	count := 0
+	for _, l := range []int{3, 5, 7, 11, 13, 17, 19, 23} {
+		psi := canonicalModular(pf, l)
+		roots := pf.roots(psi.evalJ(pf, e.j))
+		if len(roots) == 0 || len(roots) == l+1 {
+			continue
+		}
+		tl, ok := e.trace(l)
+		assert.True(t, ok, "l=%d", l)
+		assert.Equal(t, new(big.Int).Mod(tr, big.NewInt(int64(l))).Int64(), tl, "l=%d", l)
+		count++
+	}
+	assert.True(t, count > 0)
+}
+
+func TestSEA(t *testing.T) {
+	// compare SEA with the Schoof algorithm, including the CM curves
+	q, _ := new(big.Int).SetString("18446744073709551557", 10)
+	for _, ab := range [][2]int64{{1, 18}, {-3, 5}, {12345, 6789}, {0, 7}, {0, 5}, {2, 0}, {3, 0}} {
+		ec := NewEC(big.NewInt(ab[0]), big.NewInt(ab[1]), q)
+		n, err := ec.schoof(schoofSearchBits)
+		assert.Nil(t, err)
+		ns, err := ec.sea(schoofSearchBits)
+		assert.Nil(t, err)
+		assert.Equal(t, n, ns, "a=%d b=%d", ab[0], ab[1])
+	}
+}
+
+func TestSEACurves(t *testing.T) {
+	// secp256k1 has j = 0 and is counted with cmCardinality
+	c := Secp256k1()
+	n, err := c.EC.Cardinality()
+	assert.Nil(t, err)
+	assert.Equal(t, new(big.Int).Mul(c.N, c.H), n)
+	order, err := c.EC.Order(c.G)
+	assert.Nil(t, err)
+	assert.Equal(t, c.N, order)
+
+	if testing.Short() {
+		t.Skip("skipping the 256 bit SEA in short mode")
+	}
+	c = P256()
+	n, err = c.EC.Cardinality()
+	assert.Nil(t, err)
+	assert.Equal(t, new(big.Int).Mul(c.N, c.H), n)
+}
+
+func TestCornacchia(t *testing.T) {
+	for _, k := range []int64{1, 3} {
+		for _, q := range []int64{13, 37, 61, 65537, 1000000009} {
+			c, d := cornacchia(k, big.NewInt(q))
+			if big.Jacobi(big.NewInt(-k), big.NewInt(q)) != 1 {
+				assert.Nil(t, c)
+				continue
+			}
+			assert.Equal(t, q, c.Int64()*c.Int64()+k*d.Int64()*d.Int64(), "k=%d q=%d", k, q)
+		}
+	}
+	// 7 is not of the form c^2 + d^2
+	c, _ := cornacchia(1, big.NewInt(int64(7)))
+	assert.Nil(t, c)
+}
+
+func TestMulNTT(t *testing.T) {
+	q, _ := new(big.Int).SetString("115792089210356248762697446949407573530086143415290314195533631308867097853951", 10)
+	pf := polyField{q: q}
+	rnd := func(n int) poly {
+		a := make(poly, n)
+		for i := range a {
+			a[i] = new(big.Int).Exp(big.NewInt(int64(i+7)), big.NewInt(int64(3*i+101)), q)
+		}
+		return a
+	}
+	for _, n := range [][2]int{{1, 1}, {3, 700}, {600, 600}, {1000, 1537}} {
+		a, b := rnd(n[0]), rnd(n[1])
+		assert.Equal(t, pf.mulKronecker(a, b), pf.mulNTT(a, b), "%d x %d", n[0], n[1])
+		assert.Equal(t, pf.mulKronecker(a, a), pf.mulNTT(a, a), "%d squared", n[0])
+	}
+}
@@ -0,0 +1,230 @@
+package ecc
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/arnaucube/cryptofun/prime"
+)
+
+const (
+	// MinRhoBits is the minimum security in bits against Pollard's rho of the
+	// subgroup of the generator and of the twist for a curve to be considered
+	// secure, as the SafeCurves criteria
+	MinRhoBits = 100
+	// movMaxDegree is the maximum embedding degree searched by Analyze
+	movMaxDegree = 100
+	// movMinFieldBits is the minimum size of F_(q^k) where the MOV and
+	// Frey-Rück attacks move the discrete logarithm, for a security of
+	// about 128 bits in the finite field
+	movMinFieldBits = 3072
+	// twistFactorMaxBits is the maximum bit length of the part of the twist
+	// order without small factors that is fully factored
+	twistFactorMaxBits = 80
+)
+
+// twistTrialPrimes are the primes used for the trial division of the twist
+// order
+var twistTrialPrimes = prime.SieveOfEratosthenes(1 << 16)
+
+// SecurityReport is the result of the analysis of an elliptic curve and a
+// generator G of order N
+type SecurityReport struct {
+	// Discriminant is -16 (4a^3 + 27b^2) mod q, non zero for the non singular
+	// curves
+	Discriminant *big.Int
+	NonSingular  bool
+
+	// Cardinality is the number of points of the curve, q + 1 - Trace
+	Cardinality *big.Int
+	Trace       *big.Int
+	// Order is the order N of G, with its prime factors in OrderFactors, and
+	// Cofactor is Cardinality / N
+	Order        *big.Int
+	OrderFactors []*big.Int
+	LargestPrime *big.Int
+	Cofactor     *big.Int
+	// RhoBits is the security against Pollard's rho, log2(sqrt(pi l / 4))
+	// for the largest prime factor l of N
+	RhoBits float64
+
+	// EmbeddingDegree is the smallest k such that l divides q^k - 1, or 0 when
+	// it is bigger than 100. With a small k the MOV and Frey-Rück attacks
+	// reduce the discrete logarithm to the finite field F_(q^k)
+	EmbeddingDegree int
+	MOVVulnerable   bool
+	// Supersingular curves have a trace multiple of q, and embedding degree
+	// at most 2 (6 over the extension fields)
+	Supersingular bool
+	// Anomalous curves have q points, and Smart's attack solves the discrete
+	// logarithm in polynomial time
+	Anomalous bool
+
+	// TwistCardinality is the number of points of the quadratic twist,
+	// 2q + 2 - Cardinality, with its prime factors in TwistFactors. When the
+	// twist order is not fully factored, the last factor is composite, with no
+	// factors smaller than 2^16, and TwistRhoBits is only a lower bound, not
+	// reported as an issue. TwistRhoBits is the security against the invalid
+	// curve attacks over the twist, which use only the x coordinate
+	TwistCardinality *big.Int
+	TwistFactors     []*big.Int
+	TwistFactored    bool
+	TwistRhoBits     float64
+
+	// Issues lists the weaknesses found
+	Issues []string
+}
+
+// Secure returns true if no weaknesses were found
+func (r SecurityReport) Secure() bool {
+	return len(r.Issues) == 0
+}
+
+// Analyze returns the security report of the curve with the generator g,
+// computing the number of points of the curve and the order of g, which is only
+// feasible for small curves (see Curve.Analyze for the named curves). For
+// singular curves the report contains only the discriminant, together with an
+// error
+func (ec *EC) Analyze(g Point) (SecurityReport, error) {
+	if ec.isSingular() {
+		r := ec.discriminantReport()
+		return r, errors.New("singular curve")
+	}
+	card, err := ec.Cardinality()
+	if err != nil {
+		return SecurityReport{}, err
+	}
+	n, err := ec.Order(g)
+	if err != nil {
+		return SecurityReport{}, err
+	}
+	return ec.analyze(card, n), nil
+}
+
+// Analyze returns the security report of the named curve, using the known
+// order N and cofactor H of the generator
+func (c Curve) Analyze() (SecurityReport, error) {
+	if c.EC.isSingular() {
+		r := c.EC.discriminantReport()
+		return r, errors.New("singular curve")
+	}
+	nG, err := c.EC.Mul(c.G, c.N)
+	if err != nil {
+		return SecurityReport{}, err
+	}
+	if !nG.Equal(ZeroPoint) {
+		return SecurityReport{}, errors.New("N is not the order of G")
+	}
+	return c.EC.analyze(new(big.Int).Mul(c.N, c.H), c.N), nil
+}
+
+// discriminantReport returns the report with only the discriminant
+func (ec *EC) discriminantReport() SecurityReport {
+	a3 := new(big.Int).Exp(ec.A, big.NewInt(int64(3)), nil)
+	b2 := new(big.Int).Mul(ec.B, ec.B)
+	d := new(big.Int).Add(a3.Mul(a3, big.NewInt(int64(4))), b2.Mul(b2, big.NewInt(int64(27))))
+	d.Mul(d, big.NewInt(int64(-16)))
+	d.Mod(d, ec.Q)
+	r := SecurityReport{Discriminant: d, NonSingular: d.Sign() != 0}
+	if !r.NonSingular {
+		r.Issues = append(r.Issues, "singular curve: the discriminant is zero")
+	}
+	return r
+}
+
+// analyze returns the report of the curve with card points and a generator of
+// order n
+func (ec *EC) analyze(card, n *big.Int) SecurityReport {
+	r := ec.discriminantReport()
+	r.Cardinality = card
+	r.Trace = new(big.Int).Sub(new(big.Int).Add(ec.Q, BigOne), card)
+	r.Order = n
+	r.Cofactor = new(big.Int).Div(card, n)
+	r.OrderFactors = prime.Factor(n)
+	r.LargestPrime = big.NewInt(int64(1))
+	if len(r.OrderFactors) > 0 {
+		r.LargestPrime = r.OrderFactors[len(r.OrderFactors)-1]
+	}
+	r.RhoBits = rhoBits(r.LargestPrime)
+	if r.RhoBits < MinRhoBits {
+		r.Issues = append(r.Issues, "the largest prime factor of the order of G gives "+
+			strconv.FormatFloat(r.RhoBits, 'f', 1, 64)+" bits of security against Pollard's rho")
+	}
+
+	if k, err := EmbeddingDegree(ec.Q, r.LargestPrime, movMaxDegree); err == nil && r.LargestPrime.Cmp(ec.Q) != 0 {
+		r.EmbeddingDegree = k
+		r.MOVVulnerable = k*ec.Q.BitLen() < movMinFieldBits
+		if r.MOVVulnerable {
+			r.Issues = append(r.Issues, "embedding degree "+strconv.Itoa(k)+
+				": vulnerable to the MOV and Frey-Rück attacks")
+		}
+	}
+	r.Supersingular = new(big.Int).Mod(r.Trace, ec.Q).Sign() == 0
+	if r.Supersingular {
+		r.Issues = append(r.Issues, "supersingular curve")
+	}
+	r.Anomalous = card.Cmp(ec.Q) == 0 || r.LargestPrime.Cmp(ec.Q) == 0
+	if r.Anomalous {
+		r.Issues = append(r.Issues, "anomalous curve: vulnerable to Smart's attack")
+	}
+
+	r.TwistCardinality = new(big.Int).Lsh(new(big.Int).Add(ec.Q, BigOne), 1)
+	r.TwistCardinality.Sub(r.TwistCardinality, card)
+	r.TwistFactors, r.TwistFactored = factorPartial(r.TwistCardinality)
+	largest := big.NewInt(int64(1))
+	if len(r.TwistFactors) > 0 {
+		largest = r.TwistFactors[len(r.TwistFactors)-1]
+	}
+	if !r.TwistFactored {
+		// the largest prime factor of the composite part is at least its
+		// square root, which is only a lower bound of the security
+		r.TwistRhoBits = rhoBits(new(big.Int).Sqrt(largest))
+		return r
+	}
+	r.TwistRhoBits = rhoBits(largest)
+	if r.TwistRhoBits < MinRhoBits {
+		r.Issues = append(r.Issues, "the twist gives "+
+			strconv.FormatFloat(r.TwistRhoBits, 'f', 1, 64)+" bits of security against Pollard's rho")
+	}
+	return r
+}
+
+// rhoBits returns log2(sqrt(pi l / 4)), the expected work in bits of Pollard's
+// rho with the negation map over a group of order l
+func rhoBits(l *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(l).Float64()
+	if math.IsInf(f, 0) {
+		// beyond the float64 range, log2(l) from the bit length
+		return (float64(l.BitLen()) + math.Log2(math.Pi/4)) / 2
+	}
+	return math.Log2(math.Pi*f/4) / 2
+}
+
+// factorPartial returns the prime factors of n found with trial division and,
+// when the remaining part is small enough, Pollard's rho, and true if n is fully
+// factored. Otherwise the last factor is the composite part
+func factorPartial(n *big.Int) ([]*big.Int, bool) {
+	var factors []*big.Int
+	m := new(big.Int).Set(n)
+	r := new(big.Int)
+	for _, p := range twistTrialPrimes {
+		bp := big.NewInt(int64(p))
+		for {
+			q, rem := new(big.Int).QuoRem(m, bp, r)
+			if rem.Sign() != 0 {
+				break
+			}
+			factors = append(factors, bp)
+			m = q
+		}
+	}
+	if m.Cmp(BigOne) == 0 {
+		return factors, true
+	}
+	if m.ProbablyPrime(20) || m.BitLen() <= twistFactorMaxBits {
+		return append(factors, prime.Factor(m)...), true
+	}
+	return append(factors, m), false
+}
@@ -0,0 +1,90 @@
+package ecc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeNamedCurves(t *testing.T) {
+	for _, c := range []Curve{Secp256k1(), P256(), P384(), P521(), BrainpoolP256r1()} {
+		r, err := c.Analyze()
+		assert.Nil(t, err)
+		assert.True(t, r.NonSingular, c.Name)
+		assert.True(t, r.Secure(), "%s: %v", c.Name, r.Issues)
+		assert.Equal(t, 0, r.Cofactor.Cmp(BigOne), c.Name)
+		assert.Equal(t, 1, len(r.OrderFactors), c.Name)
+		assert.True(t, r.RhoBits > float64(c.N.BitLen()/2-1), c.Name)
+		assert.Equal(t, 0, r.EmbeddingDegree, c.Name)
+		assert.False(t, r.Anomalous, c.Name)
+		assert.False(t, r.Supersingular, c.Name)
+		// the twist order is the product of its factors
+		prod := big.NewInt(int64(1))
+		for _, f := range r.TwistFactors {
+			prod.Mul(prod, f)
+		}
+		assert.Equal(t, 0, prod.Cmp(r.TwistCardinality), c.Name)
+	}
+
+	// the twist of secp256k1 has order
+	// 3^2 13^2 3319 22639 1013176677300131846900870239606035638738100997248092069256697437031,
+	// with about 109 bits of security
+	r, err := Secp256k1().Analyze()
+	assert.Nil(t, err)
+	assert.True(t, r.TwistFactored)
+	assert.Equal(t, 7, len(r.TwistFactors))
+	assert.True(t, r.TwistRhoBits > 108 && r.TwistRhoBits < 110)
+
+	// the twist of brainpoolP256r1 has a composite factor of 251 bits
+	r, err = BrainpoolP256r1().Analyze()
+	assert.Nil(t, err)
+	assert.False(t, r.TwistFactored)
+	assert.Equal(t, 251, r.TwistFactors[len(r.TwistFactors)-1].BitLen())
+}
+
+func TestAnalyzeWeakCurves(t *testing.T) {
+	// toy19 has 19 points over F_19
+	r, err := Toy19().Analyze()
+	assert.Nil(t, err)
+	assert.True(t, r.Anomalous)
+	assert.False(t, r.Secure())
+
+	// y^2 = x^3 + 7 over F_11 is supersingular, as 11 = 2 mod 3, with
+	// embedding degree 2 for the subgroup of order 3
+	ec := NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11)))
+	r, err = ec.Analyze(Point{big.NewInt(int64(7)), big.NewInt(int64(8))})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), r.Cardinality.Int64())
+	assert.Equal(t, int64(0), r.Trace.Int64())
+	assert.True(t, r.Supersingular)
+	assert.Equal(t, 2, r.EmbeddingDegree)
+	assert.True(t, r.MOVVulnerable)
+	assert.Equal(t, int64(12), r.TwistCardinality.Int64())
+
+	// a generator of smooth order
+	ec = NewEC(big.NewInt(int64(3)), big.NewInt(int64(39)), big.NewInt(int64(281474976710677)))
+	r, err = ec.Analyze(Point{big.NewInt(int64(249523015268590)), big.NewInt(int64(48391171724063))})
+	assert.Nil(t, err)
+	assert.Equal(t, "140737482791900", r.Order.String())
+	assert.Equal(t, int64(66463), r.LargestPrime.Int64())
+	assert.Equal(t, int64(2), r.Cofactor.Int64())
+	assert.True(t, r.RhoBits < 8)
+	assert.False(t, r.Anomalous)
+	assert.False(t, r.Secure())
+
+	// y^2 = x^3 is singular
+	ec = NewEC(big.NewInt(int64(0)), big.NewInt(int64(0)), big.NewInt(int64(11)))
+	r, err = ec.Analyze(Point{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+	assert.False(t, r.NonSingular)
+	assert.Equal(t, int64(0), r.Discriminant.Int64())
+	assert.False(t, r.Secure())
+}
+
+func TestRhoBits(t *testing.T) {
+	// sqrt(pi 2^256 / 4) = 2^127.83
+	l := new(big.Int).Lsh(big.NewInt(int64(1)), 256)
+	assert.InDelta(t, 127.83, rhoBits(l), 0.01)
+	assert.InDelta(t, 4.83, rhoBits(big.NewInt(int64(1024))), 0.01)
+}
@@ -0,0 +1,72 @@
+package ecdlp
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/arnaucube/cryptofun/ecc"
+)
+
+// bsgsMaxBits is the maximum bit length of N for BSGS, which stores sqrt(N)
+// points
+const bsgsMaxBits = 40
+
+// BSGS returns k such that k G = P with the baby-step giant-step algorithm: the
+// baby steps j G for j in [0, m), with m = ceil(sqrt(N)), are stored, and then
+// the giant steps P - i m G are computed until one of them is a baby step, so
+// k = i m + j. It takes O(sqrt(N)) time and memory
+func (pr Problem) BSGS() (*big.Int, Work, error) {
+	start := time.Now()
+	w := Work{Method: "baby-step giant-step", Workers: 1}
+	if pr.N.BitLen() > bsgsMaxBits {
+		return nil, w, errors.New("the order is too big for baby-step giant-step")
+	}
+	m := new(big.Int).Sqrt(pr.N)
+	if new(big.Int).Mul(m, m).Cmp(pr.N) < 0 {
+		m.Add(m, bigOne)
+	}
+
+	baby := make(map[string]int64, m.Int64())
+	p := ecc.ZeroPoint
+	var err error
+	for j := int64(0); j < m.Int64(); j++ {
+		if _, ok := baby[pointKey(p)]; !ok {
+			baby[pointKey(p)] = j
+		}
+		p, err = pr.EC.Add(p, pr.G)
+		if err != nil {
+			return nil, w, err
+		}
+		w.Adds++
+	}
+	w.Stored = uint64(len(baby))
+
+	// -m G
+	step, err := pr.EC.Mul(pr.G, m)
+	if err != nil {
+		return nil, w, err
+	}
+	w.Muls++
+	step, err = pr.EC.Neg(step)
+	if err != nil {
+		return nil, w, err
+	}
+	giant := pr.P
+	for i := int64(0); i <= m.Int64(); i++ {
+		if j, ok := baby[pointKey(giant)]; ok {
+			k := new(big.Int).Mul(big.NewInt(i), m)
+			k.Add(k, big.NewInt(j))
+			k.Mod(k, pr.N)
+			w.Elapsed = time.Since(start)
+			return k, w, nil
+		}
+		giant, err = pr.EC.Add(giant, step)
+		if err != nil {
+			return nil, w, err
+		}
+		w.Adds++
+	}
+	w.Elapsed = time.Since(start)
+	return nil, w, errors.New("no solution: P is not a multiple of G")
+}
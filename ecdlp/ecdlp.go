@@ -0,0 +1,181 @@
+// Package ecdlp solves the elliptic curve discrete logarithm problem over the
+// curves of the ecc package, with baby-step giant-step, the parallel Pollard's
+// rho method with distinguished points, and Pohlig-Hellman for smooth orders.
+// It is meant to show that the private keys over small curves (or curves with
+// smooth orders) are easily recovered: the work grows with the square root of
+// the biggest prime factor of the order of the generator
+package ecdlp
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
+)
+
+var bigOne = big.NewInt(int64(1))
+
+// maxCandidates is the maximum number of candidate solutions checked when a
+// linear congruence has more than one solution
+const maxCandidates = 1 << 16
+
+// Problem is an instance of the discrete logarithm problem: find k in [0, N)
+// such that k G = P, where N is the order of G
+type Problem struct {
+	EC ecc.EC
+	G  ecc.Point
+	P  ecc.Point
+	N  *big.Int
+}
+
+// Work reports the work done to solve a Problem
+type Work struct {
+	Method string
+	// Adds is the number of point additions, and Muls the number of scalar
+	// multiplications
+	Adds uint64
+	Muls uint64
+	// Stored is the number of points stored: the baby steps for
+	// baby-step giant-step, the distinguished points for Pollard's rho
+	Stored uint64
+	// Walks is the number of random walks of Pollard's rho
+	Walks   uint64
+	Workers int
+	Elapsed time.Duration
+	// Sub is the work of the subproblems of Pohlig-Hellman
+	Sub []Work
+}
+
+// String returns a summary of the work
+func (w Work) String() string {
+	s := w.Method + ": " + strconv.FormatUint(w.Adds, 10) + " additions, " +
+		strconv.FormatUint(w.Muls, 10) + " multiplications, " +
+		strconv.FormatUint(w.Stored, 10) + " stored points"
+	if w.Walks > 0 {
+		s += ", " + strconv.FormatUint(w.Walks, 10) + " walks over " + strconv.Itoa(w.Workers) + " workers"
+	}
+	if len(w.Sub) > 0 {
+		s += ", " + strconv.Itoa(len(w.Sub)) + " subproblems"
+	}
+	return s + ", " + w.Elapsed.String()
+}
+
+// add accumulates the counters of w2 into w
+func (w *Work) add(w2 Work) {
+	w.Adds += w2.Adds
+	w.Muls += w2.Muls
+	w.Stored += w2.Stored
+	w.Walks += w2.Walks
+}
+
+// NewProblem defines the problem of finding k such that k g = p, computing the
+// order of g
+func NewProblem(ec ecc.EC, g, p ecc.Point) (Problem, error) {
+	n, err := ec.Order(g)
+	if err != nil {
+		return Problem{}, err
+	}
+	return newProblem(ec, g, p, n)
+}
+
+// NewProblemFromGroup defines the problem of finding the private key of the
+// public key pubK over the group, which must be an ecc.Curve, as the groups of
+// the DSA and EG returned by the ecdsa and elgamal packages
+func NewProblemFromGroup(g group.Group, pubK group.Element) (Problem, error) {
+	c, ok := g.(ecc.Curve)
+	if !ok {
+		return Problem{}, errors.New("the group is not an ecc.Curve")
+	}
+	p, ok := pubK.(ecc.Point)
+	if !ok {
+		return Problem{}, errors.New("the public key is not an ecc.Point")
+	}
+	return newProblem(c.EC, c.G, p, c.N)
+}
+
+func newProblem(ec ecc.EC, g, p ecc.Point, n *big.Int) (Problem, error) {
+	if err := ec.Validate(g); err != nil {
+		return Problem{}, err
+	}
+	if err := ec.Validate(p); err != nil {
+		return Problem{}, err
+	}
+	if n.Sign() <= 0 {
+		return Problem{}, errors.New("the order of G must be positive")
+	}
+	nP, err := ec.Mul(p, n)
+	if err != nil {
+		return Problem{}, err
+	}
+	if !nP.Equal(ecc.ZeroPoint) {
+		return Problem{}, errors.New("P is not in the subgroup generated by G")
+	}
+	return Problem{EC: ec, G: g, P: p, N: n}, nil
+}
+
+// Solve returns k such that k G = P with Pohlig-Hellman, which uses
+// baby-step giant-step or Pollard's rho for each prime factor of N
+func (pr Problem) Solve() (*big.Int, Work, error) {
+	return pr.PohligHellman(0)
+}
+
+// ExpectedRhoSteps returns the expected number of steps of Pollard's rho over a
+// group of prime order n, sqrt(pi n / 2), which is about 2^128 for the 256 bit
+// curves
+func ExpectedRhoSteps(n *big.Int) *big.Int {
+	// sqrt(pi n / 2) = sqrt(n) sqrt(pi / 2)
+	s := new(big.Float).SetInt(new(big.Int).Sqrt(n))
+	s.Mul(s, big.NewFloat(math.Sqrt(math.Pi/2)))
+	r, _ := s.Int(nil)
+	return r
+}
+
+// check returns true if k G = P
+func (pr Problem) check(k *big.Int, w *Work) bool {
+	kG, err := pr.EC.Mul(pr.G, k)
+	w.Muls++
+	return err == nil && kG.Equal(pr.P)
+}
+
+// solveLinear returns the k in [0, N) such that u + v k = 0 mod N and k G = P.
+// When gcd(v, N) = d > 1 there are d candidates, which are checked when d is
+// small
+func (pr Problem) solveLinear(u, v *big.Int, w *Work) (*big.Int, bool) {
+	v = new(big.Int).Mod(v, pr.N)
+	negU := new(big.Int).Neg(u)
+	negU.Mod(negU, pr.N)
+	d := new(big.Int).GCD(nil, nil, v, pr.N)
+	if v.Sign() == 0 || new(big.Int).Mod(negU, d).Sign() != 0 || d.Cmp(big.NewInt(int64(maxCandidates))) > 0 {
+		return nil, false
+	}
+	// k = (-u / d) (v / d)^-1 + i N / d
+	nd := new(big.Int).Div(pr.N, d)
+	k := new(big.Int).ModInverse(new(big.Int).Div(v, d), nd)
+	if k == nil {
+		k = new(big.Int)
+	}
+	k.Mul(k, new(big.Int).Div(negU, d))
+	k.Mod(k, nd)
+	for i := int64(0); i < d.Int64(); i++ {
+		if pr.check(k, w) {
+			return k, true
+		}
+		k = new(big.Int).Add(k, nd)
+	}
+	return nil, false
+}
+
+// randScalar returns a random integer in [0, N)
+func (pr Problem) randScalar() (*big.Int, error) {
+	return rand.Int(rand.Reader, pr.N)
+}
+
+// pointKey returns a map key of the point
+func pointKey(p ecc.Point) string {
+	return p.X.Text(16) + "," + p.Y.Text(16)
+}
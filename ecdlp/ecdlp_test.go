@@ -0,0 +1,210 @@
+package ecdlp
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/ecdsa"
+	"github.com/arnaucube/cryptofun/elgamal"
+	"github.com/arnaucube/cryptofun/group"
+	"github.com/stretchr/testify/assert"
+)
+
+func bigFromString(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// testCurve returns the curve y^2 = x^3 + 3x + b over F_q and the point (x, y)
+func testCurve(q string, b int64, x, y string) (ecc.EC, ecc.Point) {
+	ec := ecc.NewEC(big.NewInt(int64(3)), big.NewInt(b), bigFromString(q))
+	return ec, ecc.Point{X: bigFromString(x), Y: bigFromString(y)}
+}
+
+// curve20 has a generator of prime order 1047667, of 20 bits
+func curve20() (ecc.EC, ecc.Point) {
+	return testCurve("1048583", 6, "174467", "523746")
+}
+
+// curve32 has a generator of prime order 4294988963, of 32 bits
+func curve32() (ecc.EC, ecc.Point) {
+	return testCurve("4294967311", 74, "2693500225", "3458766954")
+}
+
+// curve48 has a generator of smooth order
+// 140737482791900 = 2^2 5^2 4241 4993 66463, of 47 bits
+func curve48() (ecc.EC, ecc.Point) {
+	return testCurve("281474976710677", 39, "249523015268590", "48391171724063")
+}
+
+// newTestProblem returns a problem with a random solution k
+func newTestProblem(t *testing.T, ec ecc.EC, g ecc.Point) (Problem, *big.Int) {
+	n, err := ec.Order(g)
+	assert.Nil(t, err)
+	k, err := rand.Int(rand.Reader, n)
+	assert.Nil(t, err)
+	p, err := ec.Mul(g, k)
+	assert.Nil(t, err)
+	pr, err := NewProblem(ec, g, p)
+	assert.Nil(t, err)
+	return pr, k
+}
+
+func TestBSGS(t *testing.T) {
+	ec, g := curve20()
+	for i := 0; i < 3; i++ {
+		pr, k := newTestProblem(t, ec, g)
+		s, w, err := pr.BSGS()
+		assert.Nil(t, err)
+		assert.Equal(t, k.String(), s.String())
+		// sqrt(1047667) = 1024, rounded up
+		assert.Equal(t, uint64(1024), w.Stored)
+		assert.True(t, w.Adds <= 2*1024+1)
+		t.Log(w)
+	}
+
+	// the order of the 48 bit curve is too big
+	ec, g = curve48()
+	pr, _ := newTestProblem(t, ec, g)
+	_, _, err := pr.BSGS()
+	assert.NotNil(t, err)
+}
+
+func TestRho(t *testing.T) {
+	ec, g := curve20()
+	pr, k := newTestProblem(t, ec, g)
+	s, w, err := pr.Rho(1)
+	assert.Nil(t, err)
+	assert.Equal(t, k.String(), s.String())
+	assert.Equal(t, 1, w.Workers)
+	t.Log(w)
+
+	ec, g = curve32()
+	pr, k = newTestProblem(t, ec, g)
+	s, w, err = pr.Rho(4)
+	assert.Nil(t, err)
+	assert.Equal(t, k.String(), s.String())
+	assert.True(t, w.Walks > 0)
+	assert.True(t, w.Stored > 0)
+	t.Log(w, ", expected steps:", ExpectedRhoSteps(pr.N))
+}
+
+func TestPohligHellman(t *testing.T) {
+	ec, g := curve48()
+	n, err := ec.Order(g)
+	assert.Nil(t, err)
+	assert.Equal(t, "140737482791900", n.String())
+	for i := 0; i < 3; i++ {
+		pr, k := newTestProblem(t, ec, g)
+		s, w, err := pr.PohligHellman(0)
+		assert.Nil(t, err)
+		assert.Equal(t, k.String(), s.String())
+		// 2 + 2 + 1 + 1 + 1 digits
+		assert.Equal(t, 7, len(w.Sub))
+		t.Log(w)
+	}
+
+	// the solution 0
+	pr, err := NewProblem(ec, g, ecc.ZeroPoint)
+	assert.Nil(t, err)
+	s, _, err := pr.Solve()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), s.Int64())
+}
+
+func TestCRT(t *testing.T) {
+	x, err := crt(big.NewInt(int64(2)), big.NewInt(int64(3)), big.NewInt(int64(3)), big.NewInt(int64(5)))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8), x.Int64())
+	_, err = crt(big.NewInt(int64(2)), big.NewInt(int64(4)), big.NewInt(int64(3)), big.NewInt(int64(6)))
+	assert.NotNil(t, err)
+}
+
+func TestNewProblem(t *testing.T) {
+	ec, g := curve20()
+	_, err := NewProblem(ec, g, ecc.Point{X: big.NewInt(int64(1)), Y: big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+
+	// a group which is not an elliptic curve
+	zp, err := group.NewZpGroup(big.NewInt(int64(23)), big.NewInt(int64(11)), big.NewInt(int64(4)))
+	assert.Nil(t, err)
+	_, err = NewProblemFromGroup(zp, big.NewInt(int64(2)))
+	assert.NotNil(t, err)
+}
+
+func TestRecoverDSAPrivK(t *testing.T) {
+	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(19)))
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(11))}
+	dsa, err := ecdsa.NewDSA(ec, g)
+	assert.Nil(t, err)
+	privK := big.NewInt(int64(5))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+
+	pr, err := NewProblemFromGroup(dsa.Group, pubK)
+	assert.Nil(t, err)
+	recovered, w, err := pr.Solve()
+	assert.Nil(t, err)
+	assert.Equal(t, privK.String(), recovered.String())
+	t.Log(w)
+
+	// the recovered key signs valid signatures
+	hashval := big.NewInt(int64(40))
+	sig, err := dsa.Sign(hashval, recovered, big.NewInt(int64(11)))
+	assert.Nil(t, err)
+	verified, err := dsa.Verify(hashval, sig, pubK)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	// over a 32 bit curve
+	ec, g = curve32()
+	dsa, err = ecdsa.NewDSA(ec, g)
+	assert.Nil(t, err)
+	privK, err = rand.Int(rand.Reader, dsa.N)
+	assert.Nil(t, err)
+	pubK, err = dsa.PubK(privK)
+	assert.Nil(t, err)
+	pr, err = NewProblemFromGroup(dsa.Group, pubK)
+	assert.Nil(t, err)
+	recovered, w, err = pr.Solve()
+	assert.Nil(t, err)
+	assert.Equal(t, privK.String(), recovered.String())
+	t.Log(w)
+}
+
+func TestRecoverEGPrivK(t *testing.T) {
+	ec, g := curve48()
+	eg, err := elgamal.NewEG(ec, g)
+	assert.Nil(t, err)
+	privK, err := rand.Int(rand.Reader, eg.N)
+	assert.Nil(t, err)
+	pubK, err := eg.PubK(privK)
+	assert.Nil(t, err)
+
+	m, err := ec.Mul(g, big.NewInt(int64(1234)))
+	assert.Nil(t, err)
+	c, err := eg.Encrypt(m, pubK, big.NewInt(int64(5678)))
+	assert.Nil(t, err)
+
+	pr, err := NewProblemFromGroup(eg.Group, pubK)
+	assert.Nil(t, err)
+	recovered, w, err := pr.Solve()
+	assert.Nil(t, err)
+	assert.Equal(t, privK.String(), recovered.String())
+	t.Log(w)
+
+	// the recovered key decrypts the messages
+	d, err := eg.Decrypt(c, recovered)
+	assert.Nil(t, err)
+	assert.True(t, eg.Group.Equal(m, d))
+}
+
+func TestExpectedRhoSteps(t *testing.T) {
+	// about 2^128.3 for P-256, which is out of reach
+	n := ExpectedRhoSteps(ecc.P256().N)
+	assert.Equal(t, 129, n.BitLen())
+	// sqrt(pi 10^6 / 2) = 1253.3
+	assert.Equal(t, int64(1253), ExpectedRhoSteps(big.NewInt(int64(1000000))).Int64())
+}
@@ -0,0 +1,135 @@
+package ecdlp
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/arnaucube/cryptofun/prime"
+)
+
+// phBSGSMaxBits is the maximum bit length of the prime factors solved with
+// baby-step giant-step by Pohlig-Hellman, the bigger ones use Pollard's rho
+const phBSGSMaxBits = 32
+
+// PohligHellman returns k such that k G = P with the Pohlig-Hellman algorithm:
+// for each prime power p^e dividing N, k mod p^e is computed digit by digit in
+// base p, solving e discrete logarithms in the subgroup of order p, and then k
+// is reconstructed with the chinese remainder theorem. The work is dominated by
+// the biggest prime factor of N, so the orders of the curves used in
+// cryptography must have a big prime factor. The subproblems are solved with
+// baby-step giant-step for small primes and Pollard's rho with the given
+// number of workers otherwise
+func (pr Problem) PohligHellman(workers int) (*big.Int, Work, error) {
+	start := time.Now()
+	w := Work{Method: "pohlig-hellman", Workers: 1}
+	factors := prime.Factor(pr.N)
+
+	k := new(big.Int)
+	m := big.NewInt(int64(1))
+	for i := 0; i < len(factors); {
+		p := factors[i]
+		e := 0
+		for ; i < len(factors) && factors[i].Cmp(p) == 0; i++ {
+			e++
+		}
+		ke, pe, err := pr.primePower(p, e, workers, &w)
+		if err != nil {
+			w.Elapsed = time.Since(start)
+			return nil, w, err
+		}
+		// k = ke mod p^e, and k = previous k mod m
+		k, err = crt(k, m, ke, pe)
+		if err != nil {
+			w.Elapsed = time.Since(start)
+			return nil, w, err
+		}
+		m.Mul(m, pe)
+	}
+	w.Elapsed = time.Since(start)
+	if !pr.check(k, &w) {
+		return nil, w, errors.New("no solution: P is not a multiple of G")
+	}
+	return k, w, nil
+}
+
+// primePower returns k mod p^e, where p^e divides N, and p^e
+func (pr Problem) primePower(p *big.Int, e, workers int, w *Work) (*big.Int, *big.Int, error) {
+	pe := new(big.Int).Exp(p, big.NewInt(int64(e)), nil)
+	cofactor := new(big.Int).Div(pr.N, pe)
+	// Ge = (N / p^e) G and Pe = (N / p^e) P, with Pe = (k mod p^e) Ge, and
+	// gamma = p^(e-1) Ge of order p
+	ge, err := pr.EC.Mul(pr.G, cofactor)
+	if err != nil {
+		return nil, nil, err
+	}
+	pePoint, err := pr.EC.Mul(pr.P, cofactor)
+	if err != nil {
+		return nil, nil, err
+	}
+	gamma, err := pr.EC.Mul(ge, new(big.Int).Div(pe, p))
+	if err != nil {
+		return nil, nil, err
+	}
+	w.Muls += 3
+
+	// k mod p^e = x_0 + x_1 p + ... + x_(e-1) p^(e-1), where
+	// x_i is the logarithm of p^(e-1-i) (Pe - (x_0 + ... + x_(i-1) p^(i-1)) Ge)
+	x := new(big.Int)
+	pi := big.NewInt(int64(1))
+	for i := 0; i < e; i++ {
+		xGe, err := pr.EC.Mul(ge, x)
+		if err != nil {
+			return nil, nil, err
+		}
+		xGe, err = pr.EC.Neg(xGe)
+		if err != nil {
+			return nil, nil, err
+		}
+		h, err := pr.EC.Add(pePoint, xGe)
+		if err != nil {
+			return nil, nil, err
+		}
+		h, err = pr.EC.Mul(h, new(big.Int).Exp(p, big.NewInt(int64(e-1-i)), nil))
+		if err != nil {
+			return nil, nil, err
+		}
+		w.Muls += 2
+		w.Adds++
+
+		sub := Problem{EC: pr.EC, G: gamma, P: h, N: p}
+		var xi *big.Int
+		var sw Work
+		if p.BitLen() <= phBSGSMaxBits {
+			xi, sw, err = sub.BSGS()
+		} else {
+			xi, sw, err = sub.Rho(workers)
+		}
+		w.add(sw)
+		w.Sub = append(w.Sub, sw)
+		if sw.Workers > w.Workers {
+			w.Workers = sw.Workers
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		x.Add(x, new(big.Int).Mul(xi, pi))
+		pi.Mul(pi, p)
+	}
+	return x, pe, nil
+}
+
+// crt returns the x in [0, m1 m2) such that x = a1 mod m1 and x = a2 mod m2, for
+// coprime m1 and m2
+func crt(a1, m1, a2, m2 *big.Int) (*big.Int, error) {
+	// x = a1 + m1 ((a2 - a1) m1^-1 mod m2)
+	inv := new(big.Int).ModInverse(m1, m2)
+	if inv == nil {
+		return nil, errors.New("the moduli are not coprime")
+	}
+	t := new(big.Int).Sub(a2, a1)
+	t.Mul(t, inv)
+	t.Mod(t, m2)
+	x := new(big.Int).Mul(m1, t)
+	return x.Add(x, a1), nil
+}
@@ -0,0 +1,222 @@
+package ecdlp
+
+import (
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arnaucube/cryptofun/ecc"
+)
+
+// rhoPartitions is the number of partitions of the r-adding walk
+const rhoPartitions = 32
+
+// rhoStep is a precomputed step of the r-adding walk, R = c G + d P
+type rhoStep struct {
+	R    ecc.Point
+	c, d *big.Int
+}
+
+// rhoPoint is a point of a walk, X = a G + b P
+type rhoPoint struct {
+	X    ecc.Point
+	a, b *big.Int
+}
+
+// Rho returns k such that k G = P with the parallel Pollard's rho method of van
+// Oorschot and Wiener: each worker starts random walks X = a G + b P, with the
+// steps X = X + R_j, where R_j is one of 32 precomputed random combinations of
+// G and P chosen by the x coordinate of X, until X is a distinguished point,
+// with the lower bits of its x coordinate equal to zero. Two walks reaching the
+// same distinguished point give a + b k = a' + b' k mod N. It takes
+// O(sqrt(N)) time and stores a fraction 2^-d of the points. If workers is not
+// positive, runtime.NumCPU() workers are used
+func (pr Problem) Rho(workers int) (*big.Int, Work, error) {
+	start := time.Now()
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	w := Work{Method: "pollard rho", Workers: workers}
+
+	steps := make([]rhoStep, rhoPartitions)
+	for i := range steps {
+		c, err := pr.randScalar()
+		if err != nil {
+			return nil, w, err
+		}
+		d, err := pr.randScalar()
+		if err != nil {
+			return nil, w, err
+		}
+		R, err := pr.combination(c, d, &w)
+		if err != nil {
+			return nil, w, err
+		}
+		steps[i] = rhoStep{R, c, d}
+	}
+
+	// the expected number of distinguished points is sqrt(N) / 2^dBits, and
+	// the walks are restarted after 20 2^dBits steps, as they can enter a cycle
+	// without distinguished points
+	dBits := uint(pr.N.BitLen() / 4)
+	maxWalk := uint64(20) << dBits
+	// the search stops after 16 sqrt(N) steps, when P is not a multiple of G
+	maxSteps := new(big.Int).Sqrt(pr.N)
+	maxSteps.Lsh(maxSteps, 4)
+	maxSteps.Add(maxSteps, big.NewInt(int64(1024)))
+
+	done := make(chan struct{})
+	points := make(chan rhoPoint)
+	errs := make(chan error, workers)
+	var c rhoCounters
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pr.rhoWorker(steps, dBits, maxWalk, points, done, &c); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	k, err := pr.rhoCollect(points, errs, maxSteps, &c, &w)
+	close(done)
+	wg.Wait()
+	w.Adds += c.adds.Load()
+	w.Muls += c.muls.Load()
+	w.Walks = c.walks.Load()
+	w.Elapsed = time.Since(start)
+	return k, w, err
+}
+
+// combination returns c G + d P
+func (pr Problem) combination(c, d *big.Int, w *Work) (ecc.Point, error) {
+	cG, err := pr.EC.Mul(pr.G, c)
+	if err != nil {
+		return ecc.Point{}, err
+	}
+	dP, err := pr.EC.Mul(pr.P, d)
+	if err != nil {
+		return ecc.Point{}, err
+	}
+	w.Muls += 2
+	w.Adds++
+	return pr.EC.Add(cG, dP)
+}
+
+// rhoCounters counts the work of the workers, which is added after each walk
+type rhoCounters struct {
+	adds, muls, walks atomic.Uint64
+}
+
+// rhoWorker runs random walks until done is closed, sending the distinguished
+// points, and the walks reaching the point at infinity, to points
+func (pr Problem) rhoWorker(steps []rhoStep, dBits uint, maxWalk uint64, points chan<- rhoPoint, done <-chan struct{}, c *rhoCounters) error {
+	mask := uint64(1)<<dBits - 1
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		var w Work
+		err := pr.rhoWalk(steps, mask, maxWalk, points, done, &w)
+		c.adds.Add(w.Adds)
+		c.muls.Add(w.Muls)
+		c.walks.Add(1)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rhoWalk runs a random walk from a random point, for at most maxWalk steps
+func (pr Problem) rhoWalk(steps []rhoStep, mask, maxWalk uint64, points chan<- rhoPoint, done <-chan struct{}, w *Work) error {
+	a, err := pr.randScalar()
+	if err != nil {
+		return err
+	}
+	b, err := pr.randScalar()
+	if err != nil {
+		return err
+	}
+	x, err := pr.combination(a, b, w)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < maxWalk; i++ {
+		// the bits 0-4 of x choose the step, the next dBits bits are zero
+		// for the distinguished points
+		lx := x.X.Uint64()
+		if x.Equal(ecc.ZeroPoint) || (lx>>5)&mask == 0 {
+			select {
+			case points <- rhoPoint{x, a, b}:
+			case <-done:
+			}
+			return nil
+		}
+		s := steps[lx%rhoPartitions]
+		x, err = pr.EC.Add(x, s.R)
+		if err != nil {
+			return err
+		}
+		w.Adds++
+		a = new(big.Int).Add(a, s.c)
+		a.Mod(a, pr.N)
+		b = new(big.Int).Add(b, s.d)
+		b.Mod(b, pr.N)
+	}
+	return nil
+}
+
+// rhoCollect stores the distinguished points until two walks collide in a point
+// which gives the solution, or until more than maxSteps additions are done
+func (pr Problem) rhoCollect(points <-chan rhoPoint, errs <-chan error, maxSteps *big.Int, c *rhoCounters, w *Work) (*big.Int, error) {
+	seen := make(map[string]rhoPoint)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if new(big.Int).SetUint64(c.adds.Load()).Cmp(maxSteps) > 0 {
+			return nil, errors.New("no solution found: P may not be a multiple of G")
+		}
+		var p rhoPoint
+		select {
+		case p = <-points:
+		case err := <-errs:
+			return nil, err
+		case <-ticker.C:
+			continue
+		}
+		if p.X.Equal(ecc.ZeroPoint) {
+			// a + b k = 0
+			if k, ok := pr.solveLinear(p.a, p.b, w); ok {
+				return k, nil
+			}
+			continue
+		}
+		key := p.X.X.Text(16)
+		p2, ok := seen[key]
+		if !ok {
+			seen[key] = p
+			w.Stored++
+			continue
+		}
+		// a + b k = a' + b' k for the same point, and a + b k = -(a' + b' k)
+		// for the opposite one
+		u, v := new(big.Int), new(big.Int)
+		if p.X.Y.Cmp(p2.X.Y) == 0 {
+			u.Sub(p.a, p2.a)
+			v.Sub(p.b, p2.b)
+		} else {
+			u.Add(p.a, p2.a)
+			v.Add(p.b, p2.b)
+		}
+		if k, ok := pr.solveLinear(u, v, w); ok {
+			return k, nil
+		}
+	}
+}
@@ -1,80 +1,196 @@
 package ecdsa
 
 import (
-	"bytes"
+	"errors"
 	"math/big"
 
-	// ecc "../ecc"
 	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
 )
 
-// DSA is the ECDSA data structure
+// DSA is the ECDSA data structure. It works over any group.Group, where the
+// group elements are converted to integers with Group.Int (the x coordinate for
+// the elliptic curves, which gives the classic DSA over Z_p*)
 type DSA struct {
-	EC ecc.EC
-	G  ecc.Point
-	N  *big.Int
+	Group group.Group
+	G     group.Element
+	N     *big.Int
+	// Strict selects the strict mode against the signature malleability: Sign
+	// returns the low-S signatures (s <= N/2, as BIP 62 & 146), and Verify and
+	// RecoverPubK reject the signatures with s > N/2, as (r, N - s) is also a
+	// valid signature of the same hash value. In the lenient mode (the default)
+	// the signatures are the ones of RFC 6979 and crypto/ecdsa, and both s and
+	// N - s verify
+	Strict bool
+	// gTable is the precomputed table for the multiplications of G, built by
+	// the constructors
+	gTable group.FixedBase
 }
 
 // NewDSA defines a new DSA data structure
 func NewDSA(ec ecc.EC, g ecc.Point) (DSA, error) {
-	var dsa DSA
-	var err error
-	dsa.EC = ec
-	dsa.G = g
-	dsa.N, err = ec.Order(g)
-	return dsa, err
+	n, err := ec.Order(g)
+	if err != nil {
+		return DSA{}, err
+	}
+	return NewDSAFromGroup(ecc.Curve{EC: ec, G: g, N: n}), nil
+}
+
+// NewDSAFromCurve defines a new DSA data structure over a named curve, using the
+// known order of its generator
+func NewDSAFromCurve(c ecc.Curve) DSA {
+	return NewDSAFromGroup(c)
+}
+
+// NewDSAFromGroup defines a new DSA data structure over the given group
+func NewDSAFromGroup(g group.Group) DSA {
+	dsa := DSA{
+		Group: g,
+		G:     g.Generator(),
+		N:     g.Order(),
+	}
+	// when the table can not be built, mulG uses Group.Mul
+	if gTable, err := group.NewFixedBase(g, dsa.G); err == nil {
+		dsa.gTable = gTable
+	}
+	return dsa
+}
+
+// mulG returns k x G, using the precomputed table of G when available
+func (dsa DSA) mulG(k *big.Int) (group.Element, error) {
+	if dsa.gTable == nil {
+		return dsa.Group.Mul(dsa.G, k)
+	}
+	return dsa.gTable.Mul(k)
 }
 
-// PubK returns the public key Point calculated from the private key over the elliptic curve
-func (dsa DSA) PubK(privK *big.Int) (ecc.Point, error) {
-	// privK: rand < ec.Q
-	privKCopy := new(big.Int).SetBytes(privK.Bytes())
-	pubK, err := dsa.EC.Mul(dsa.G, privKCopy)
+// PubK returns the public key element calculated from the private key
+func (dsa DSA) PubK(privK *big.Int) (group.Element, error) {
+	// privK: rand < dsa.N
+	pubK, err := dsa.mulG(privK)
 	return pubK, err
 }
 
-// Sign performs the ECDSA signature
+// errZeroSignature is returned by sign when r or s is zero, which is not a
+// valid signature, so the signature has to be done with another nonce
+var errZeroSignature = errors.New("invalid signature: r or s is zero, sign with another nonce")
+
+// Sign performs the ECDSA signature. It returns an error when the nonce r gives
+// r or s equal to zero, then the signature must be done with another nonce
 func (dsa DSA) Sign(hashval *big.Int, privK *big.Int, r *big.Int) ([2]*big.Int, error) {
-	rCopy := new(big.Int).SetBytes(r.Bytes())
-	m, err := dsa.EC.Mul(dsa.G, rCopy)
-	if err != nil {
-		return [2]*big.Int{}, err
-	}
+	sig, _, err := dsa.sign(hashval, privK, r)
+	return sig, err
+}
+
+// sign performs the ECDSA signature, returning also the point r x G (or its
+// negation when s is normalized to the low S, so the recovery id matches)
+func (dsa DSA) sign(hashval *big.Int, privK *big.Int, r *big.Int) ([2]*big.Int, group.Element, error) {
 	// inv(r) mod dsa.N
 	inv := new(big.Int).ModInverse(r, dsa.N)
+	if inv == nil {
+		return [2]*big.Int{}, nil, errors.New("the nonce is not invertible mod N")
+	}
+	m, err := dsa.mulG(r)
+	if err != nil {
+		return [2]*big.Int{}, nil, err
+	}
+	mX, err := dsa.Group.Int(m)
+	if err != nil {
+		return [2]*big.Int{}, nil, err
+	}
+	mX.Mod(mX, dsa.N)
 	// m.X * privK
-	privKCopy := new(big.Int).SetBytes(privK.Bytes())
-	xPrivK := new(big.Int).Mul(m.X, privKCopy)
+	xPrivK := new(big.Int).Mul(mX, privK)
 	// (hashval + m.X * privK)
 	hashvalXPrivK := new(big.Int).Add(hashval, xPrivK)
 	// inv * (hashval + m.X * privK) mod dsa.N
 	a := new(big.Int).Mul(inv, hashvalXPrivK)
 	r2 := new(big.Int).Mod(a, dsa.N)
-	return [2]*big.Int{m.X, r2}, err
+	if mX.Sign() == 0 || r2.Sign() == 0 {
+		return [2]*big.Int{}, nil, errZeroSignature
+	}
+	if dsa.Strict && !dsa.IsLowS(r2) {
+		// (r, N - s) is the signature with the nonce -r, of the point -(r x G)
+		r2.Sub(dsa.N, r2)
+		m, err = dsa.Group.Neg(m)
+		if err != nil {
+			return [2]*big.Int{}, nil, err
+		}
+	}
+	return [2]*big.Int{mX, r2}, m, nil
+}
+
+// IsLowS returns true if s <= N/2
+func (dsa DSA) IsLowS(s *big.Int) bool {
+	return s.Cmp(new(big.Int).Rsh(dsa.N, 1)) <= 0
+}
+
+// NormalizeS returns the low-S form of the signature, (r, N - s) when s > N/2.
+// The y parity of the recovery id of the signature changes when s is negated
+func (dsa DSA) NormalizeS(sig [2]*big.Int) [2]*big.Int {
+	if dsa.IsLowS(sig[1]) {
+		return sig
+	}
+	return [2]*big.Int{sig[0], new(big.Int).Sub(dsa.N, sig[1])}
+}
+
+// checkRange checks that r and s are in [1, N)
+func (dsa DSA) checkRange(sig [2]*big.Int) error {
+	for _, v := range sig {
+		if v == nil || v.Sign() <= 0 || v.Cmp(dsa.N) >= 0 {
+			return errors.New("invalid signature: r and s must be in [1, N)")
+		}
+	}
+	return nil
+}
+
+// checkSignature checks that r and s are in [1, N), and in the strict mode that
+// s is the low S
+func (dsa DSA) checkSignature(sig [2]*big.Int) error {
+	if err := dsa.checkRange(sig); err != nil {
+		return err
+	}
+	if dsa.Strict && !dsa.IsLowS(sig[1]) {
+		return errors.New("invalid signature: s > N/2 in strict mode")
+	}
+	return nil
 }
 
 // Verify validates the ECDSA signature
-func (dsa DSA) Verify(hashval *big.Int, sig [2]*big.Int, pubK ecc.Point) (bool, error) {
+func (dsa DSA) Verify(hashval *big.Int, sig [2]*big.Int, pubK group.Element) (bool, error) {
+	// the public key must be an element of the group generated by G, other than
+	// the identity
+	if err := dsa.Group.Validate(pubK); err != nil {
+		return false, errors.New("invalid public key: " + err.Error())
+	}
+	if dsa.Group.Equal(pubK, dsa.Group.Identity()) {
+		return false, errors.New("invalid public key")
+	}
+	if err := dsa.checkSignature(sig); err != nil {
+		return false, err
+	}
 	w := new(big.Int).ModInverse(sig[1], dsa.N)
-	wCopy := new(big.Int).SetBytes(w.Bytes())
-	u1raw := new(big.Int).Mul(hashval, wCopy)
+	if w == nil {
+		return false, errors.New("invalid signature: s is not invertible mod N")
+	}
+	u1raw := new(big.Int).Mul(hashval, w)
 	u1 := new(big.Int).Mod(u1raw, dsa.N)
-	wCopy = new(big.Int).SetBytes(w.Bytes())
-	u2raw := new(big.Int).Mul(sig[0], wCopy)
+	u2raw := new(big.Int).Mul(sig[0], w)
 	u2 := new(big.Int).Mod(u2raw, dsa.N)
 
-	gU1, err := dsa.EC.Mul(dsa.G, u1)
+	// u1 x G + u2 x pubK
+	p, err := group.MultiMul(dsa.Group, []group.Element{dsa.G, pubK}, []*big.Int{u1, u2})
 	if err != nil {
 		return false, err
 	}
-	pubKU2, err := dsa.EC.Mul(pubK, u2)
-	if err != nil {
-		return false, err
+	// the point at infinity has no x coordinate
+	if dsa.Group.Equal(p, dsa.Group.Identity()) {
+		return false, nil
 	}
-	p, err := dsa.EC.Add(gU1, pubKU2)
+	pX, err := dsa.Group.Int(p)
 	if err != nil {
 		return false, err
 	}
-	pXmodN := new(big.Int).Mod(p.X, dsa.N)
-	return bytes.Equal(pXmodN.Bytes(), sig[0].Bytes()), nil
+	pXmodN := new(big.Int).Mod(pX, dsa.N)
+	return pXmodN.Cmp(sig[0]) == 0, nil
 }
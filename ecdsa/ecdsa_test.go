@@ -1,16 +1,19 @@
 package ecdsa
 
 import (
+	"crypto"
+	"crypto/rand"
 	"math/big"
 	"testing"
 
 	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewECDSA(t *testing.T) {
 	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(19)))
-	g := ecc.Point{big.NewInt(int64(7)), big.NewInt(int64(11))}
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(11))}
 	dsa, err := NewDSA(ec, g)
 	assert.Nil(t, err)
 
@@ -18,14 +21,14 @@ func TestNewECDSA(t *testing.T) {
 	pubK, err := dsa.PubK(privK)
 	assert.Nil(t, err)
 
-	if !pubK.Equal(ecc.Point{big.NewInt(int64(13)), big.NewInt(int64(9))}) {
+	if !dsa.Group.Equal(pubK, ecc.Point{X: big.NewInt(int64(13)), Y: big.NewInt(int64(9))}) {
 		t.Errorf("pubK!=(13, 9)")
 	}
 }
 
 func TestECDSASignAndVerify(t *testing.T) {
 	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(19)))
-	g := ecc.Point{big.NewInt(int64(7)), big.NewInt(int64(11))}
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(11))}
 	dsa, err := NewDSA(ec, g)
 	assert.Nil(t, err)
 
@@ -42,3 +45,269 @@ func TestECDSASignAndVerify(t *testing.T) {
 	verified, err := dsa.Verify(hashval, sig, pubK)
 	assert.True(t, verified)
 }
+
+func TestSignZeroSignature(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.Toy19())
+	privK := big.NewInt(int64(5))
+	r := big.NewInt(int64(3))
+	m, err := dsa.Group.Mul(dsa.G, r)
+	assert.Nil(t, err)
+	mX, err := dsa.Group.Int(m)
+	assert.Nil(t, err)
+	mX.Mod(mX, dsa.N)
+
+	// hashval = -(m.X * privK) mod N, so s = 0
+	hashval := new(big.Int).Mul(mX, privK)
+	hashval.Neg(hashval).Mod(hashval, dsa.N)
+	_, err = dsa.Sign(hashval, privK, r)
+	assert.NotNil(t, err)
+
+	// the deterministic signature retries with the next nonce
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	sig, err := dsa.SignDeterministic(hashval, privK, crypto.SHA256, nil)
+	assert.Nil(t, err)
+	verified, err := dsa.Verify(hashval, sig, pubK)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
+
+func TestECDSANamedCurves(t *testing.T) {
+	for _, name := range []string{"secp256k1", "P-256", "brainpoolP256r1"} {
+		c, err := ecc.CurveByName(name)
+		assert.Nil(t, err)
+		dsa := NewDSAFromCurve(c)
+
+		privK, err := rand.Int(rand.Reader, dsa.N)
+		assert.Nil(t, err)
+		pubK, err := dsa.PubK(privK)
+		assert.Nil(t, err)
+
+		hashval := big.NewInt(int64(40))
+		r, err := rand.Int(rand.Reader, dsa.N)
+		assert.Nil(t, err)
+
+		sig, err := dsa.Sign(hashval, privK, r)
+		assert.Nil(t, err)
+
+		verified, err := dsa.Verify(hashval, sig, pubK)
+		assert.Nil(t, err)
+		assert.True(t, verified, name)
+
+		verified, err = dsa.Verify(big.NewInt(int64(41)), sig, pubK)
+		assert.Nil(t, err)
+		assert.False(t, verified, name)
+	}
+}
+
+func TestDSAOverGroups(t *testing.T) {
+	zp, err := group.GenerateZpGroup(512, 160)
+	assert.Nil(t, err)
+	bn, err := group.NewBN128G1()
+	assert.Nil(t, err)
+
+	for _, g := range []group.Group{zp, bn} {
+		dsa := NewDSAFromGroup(g)
+
+		privK, err := rand.Int(rand.Reader, dsa.N)
+		assert.Nil(t, err)
+		pubK, err := dsa.PubK(privK)
+		assert.Nil(t, err)
+
+		hashval := big.NewInt(int64(40))
+		r, err := rand.Int(rand.Reader, dsa.N)
+		assert.Nil(t, err)
+		sig, err := dsa.Sign(hashval, privK, r)
+		assert.Nil(t, err)
+
+		verified, err := dsa.Verify(hashval, sig, pubK)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+
+		verified, err = dsa.Verify(big.NewInt(int64(41)), sig, pubK)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+	}
+}
+
+func TestDSAWithoutTable(t *testing.T) {
+	// a DSA defined without the constructors, without the table of G
+	c := ecc.P256()
+	dsa := DSA{Group: c, G: c.G, N: c.N}
+	dsaTable := NewDSAFromCurve(c)
+
+	privK := big.NewInt(int64(1234))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	pubK2, err := dsaTable.PubK(privK)
+	assert.Nil(t, err)
+	assert.True(t, c.Equal(pubK, pubK2))
+
+	hashval := big.NewInt(int64(40))
+	sig, err := dsa.Sign(hashval, privK, big.NewInt(int64(5678)))
+	assert.Nil(t, err)
+	sig2, err := dsaTable.Sign(hashval, privK, big.NewInt(int64(5678)))
+	assert.Nil(t, err)
+	assert.Equal(t, sig, sig2)
+	verified, err := dsaTable.Verify(hashval, sig, pubK)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
+
+func TestVerifyInvalidPubK(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.P256())
+	privK := big.NewInt(int64(1234))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	hashval := big.NewInt(int64(40))
+	sig, err := dsa.Sign(hashval, privK, big.NewInt(int64(5678)))
+	assert.Nil(t, err)
+
+	pubKPoint := pubK.(ecc.Point)
+	offCurve := ecc.Point{X: pubKPoint.X, Y: new(big.Int).Add(pubKPoint.Y, big.NewInt(int64(1)))}
+	for _, p := range []interface{}{offCurve, ecc.ZeroPoint, ecc.Point{}, pubKPoint.X} {
+		verified, err := dsa.Verify(hashval, sig, p)
+		assert.NotNil(t, err)
+		assert.False(t, verified)
+	}
+}
+
+func TestVerifyMalleability(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	privK := big.NewInt(int64(1234))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	hashval := big.NewInt(int64(40))
+
+	// a lenient signature with s > N/2
+	var sig [2]*big.Int
+	for k := int64(1); ; k++ {
+		sig, err = dsa.Sign(hashval, privK, big.NewInt(k))
+		assert.Nil(t, err)
+		if !dsa.IsLowS(sig[1]) {
+			break
+		}
+	}
+	low := dsa.NormalizeS(sig)
+	assert.True(t, dsa.IsLowS(low[1]))
+	assert.Equal(t, sig[0], low[0])
+
+	// the lenient mode accepts both s and N - s
+	for _, s := range [][2]*big.Int{sig, low} {
+		verified, err := dsa.Verify(hashval, s, pubK)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+
+	// the strict mode only accepts the low S
+	strict := dsa
+	strict.Strict = true
+	verified, err := strict.Verify(hashval, sig, pubK)
+	assert.NotNil(t, err)
+	assert.False(t, verified)
+	verified, err = strict.Verify(hashval, low, pubK)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	_, err = strict.RecoverPubK(hashval, sig, 0)
+	assert.NotNil(t, err)
+}
+
+func TestSignStrict(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	dsa.Strict = true
+	lenient := NewDSAFromCurve(ecc.Secp256k1())
+	for i := 0; i < 10; i++ {
+		privK, err := rand.Int(rand.Reader, dsa.N)
+		assert.Nil(t, err)
+		pubK, err := dsa.PubK(privK)
+		assert.Nil(t, err)
+		hashval, err := rand.Int(rand.Reader, dsa.N)
+		assert.Nil(t, err)
+		k, err := rand.Int(rand.Reader, dsa.N)
+		assert.Nil(t, err)
+
+		sig, err := dsa.Sign(hashval, privK, k)
+		assert.Nil(t, err)
+		assert.True(t, dsa.IsLowS(sig[1]))
+		sigLenient, err := lenient.Sign(hashval, privK, k)
+		assert.Nil(t, err)
+		assert.Equal(t, lenient.NormalizeS(sigLenient), sig)
+		verified, err := dsa.Verify(hashval, sig, pubK)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+
+		// the recovery id follows the negation of s
+		sig, recid, err := dsa.SignRecoverable(hashval, privK, k)
+		assert.Nil(t, err)
+		recovered, err := dsa.RecoverPubK(hashval, sig, recid)
+		assert.Nil(t, err)
+		assert.True(t, dsa.Group.Equal(pubK, recovered))
+
+		b, err := dsa.SignCompact(hashval, privK, crypto.SHA256, nil)
+		assert.Nil(t, err)
+		recovered, err = dsa.RecoverPubKCompact(hashval, b)
+		assert.Nil(t, err)
+		assert.True(t, dsa.Group.Equal(pubK, recovered))
+	}
+}
+
+func TestVerifyInvalidSignature(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.P256())
+	privK := big.NewInt(int64(1234))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	hashval := big.NewInt(int64(40))
+	sig, err := dsa.Sign(hashval, privK, big.NewInt(int64(5678)))
+	assert.Nil(t, err)
+
+	zero := big.NewInt(int64(0))
+	for _, s := range [][2]*big.Int{
+		{zero, sig[1]},
+		{sig[0], zero},
+		{dsa.N, sig[1]},
+		{sig[0], dsa.N},
+		{new(big.Int).Neg(sig[0]), sig[1]},
+		{new(big.Int).Add(sig[0], dsa.N), sig[1]},
+		{sig[0], nil},
+	} {
+		verified, err := dsa.Verify(hashval, s, pubK)
+		assert.NotNil(t, err)
+		assert.False(t, verified)
+	}
+
+	// with hashval = -r privK, u1 x G + u2 x pubK is the point at infinity
+	hashval = new(big.Int).Mul(sig[0], privK)
+	hashval.Sub(dsa.N, hashval.Mod(hashval, dsa.N))
+	verified, err := dsa.Verify(hashval, sig, pubK)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	// the nonce must be invertible mod N
+	_, err = dsa.Sign(hashval, privK, zero)
+	assert.NotNil(t, err)
+	_, err = dsa.Sign(hashval, privK, dsa.N)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkSign(b *testing.B) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	privK := new(big.Int).Sub(dsa.N, big.NewInt(int64(12345)))
+	hashval := new(big.Int).Sub(dsa.N, big.NewInt(int64(54321)))
+	r := new(big.Int).Sub(dsa.N, big.NewInt(int64(67890)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = dsa.Sign(hashval, privK, r)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	privK := new(big.Int).Sub(dsa.N, big.NewInt(int64(12345)))
+	pubK, _ := dsa.PubK(privK)
+	hashval := new(big.Int).Sub(dsa.N, big.NewInt(int64(54321)))
+	sig, _ := dsa.Sign(hashval, privK, new(big.Int).Sub(dsa.N, big.NewInt(int64(67890))))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = dsa.Verify(hashval, sig, pubK)
+	}
+}
@@ -0,0 +1,363 @@
+package ecdsa
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
+)
+
+// oidPublicKeyECDSA is the id-ecPublicKey algorithm of the elliptic curve keys
+// (RFC 5480)
+var oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+// curveOIDs contains the object identifiers of the named curves (RFC 5480, SEC 2
+// and RFC 5639), used by the encodings of the keys
+var curveOIDs = map[string]asn1.ObjectIdentifier{
+	"secp256k1":       {1, 3, 132, 0, 10},
+	"P-256":           {1, 2, 840, 10045, 3, 1, 7},
+	"P-384":           {1, 3, 132, 0, 34},
+	"P-521":           {1, 3, 132, 0, 35},
+	"brainpoolP256r1": {1, 3, 36, 3, 3, 2, 8, 1, 1, 7},
+}
+
+// curveByOID returns the named curve of the object identifier
+func curveByOID(oid asn1.ObjectIdentifier) (ecc.Curve, error) {
+	for name, curveOID := range curveOIDs {
+		if oid.Equal(curveOID) {
+			return ecc.CurveByName(name)
+		}
+	}
+	return ecc.Curve{}, errors.New("unknown curve " + oid.String())
+}
+
+// curveOID returns the object identifier of the named curve of the DSA
+func (dsa DSA) curveOID() (ecc.Curve, asn1.ObjectIdentifier, error) {
+	c, ok := dsa.Group.(ecc.Curve)
+	if !ok {
+		return ecc.Curve{}, nil, errors.New("the key encodings need an ecc.Curve group")
+	}
+	oid, ok := curveOIDs[c.Name]
+	if !ok {
+		return ecc.Curve{}, nil, errors.New("the key encodings need a named curve, unknown curve " + c.Name)
+	}
+	return c, oid, nil
+}
+
+// ecdsaSignature is the ASN.1 structure of the signatures (RFC 3279)
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ecPrivateKey is the ASN.1 structure of the SEC1 private keys (SEC 1 v2,
+// appendix C.4, RFC 5915)
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 is the ASN.1 structure of the PKCS #8 private keys (RFC 5208)
+type pkcs8 struct {
+	Version    int
+	Algo       algorithmIdentifier
+	PrivateKey []byte
+}
+
+// algorithmIdentifier is the ASN.1 structure of the algorithm of the keys, with
+// the named curve as parameter
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	NamedCurve asn1.ObjectIdentifier
+}
+
+// publicKeyInfo is the ASN.1 structure of the SubjectPublicKeyInfo (RFC 5280)
+type publicKeyInfo struct {
+	Algorithm algorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// MarshalDER returns the ASN.1 DER encoding of the signature,
+// SEQUENCE { r INTEGER, s INTEGER }, the encoding of OpenSSL and
+// crypto/ecdsa.SignASN1
+func (dsa DSA) MarshalDER(sig [2]*big.Int) ([]byte, error) {
+	if err := dsa.checkRange(sig); err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ecdsaSignature{sig[0], sig[1]})
+}
+
+// ParseDER decodes an ASN.1 DER encoded signature, rejecting the trailing data
+// and the values of r and s out of [1, N)
+func (dsa DSA) ParseDER(b []byte) ([2]*big.Int, error) {
+	var s ecdsaSignature
+	rest, err := asn1.Unmarshal(b, &s)
+	if err != nil {
+		return [2]*big.Int{}, err
+	}
+	if len(rest) != 0 {
+		return [2]*big.Int{}, errors.New("invalid signature encoding: trailing data")
+	}
+	sig := [2]*big.Int{s.R, s.S}
+	if err := dsa.checkRange(sig); err != nil {
+		return [2]*big.Int{}, err
+	}
+	return sig, nil
+}
+
+// MarshalP1363 returns the IEEE P1363 encoding of the signature, r || s, with r
+// and s of the byte length of N (the encoding of the JWS & WebCrypto signatures)
+func (dsa DSA) MarshalP1363(sig [2]*big.Int) ([]byte, error) {
+	if err := dsa.checkRange(sig); err != nil {
+		return nil, err
+	}
+	size := (dsa.N.BitLen() + 7) / 8
+	b := make([]byte, 2*size)
+	sig[0].FillBytes(b[:size])
+	sig[1].FillBytes(b[size:])
+	return b, nil
+}
+
+// ParseP1363 decodes an IEEE P1363 encoded signature, r || s
+func (dsa DSA) ParseP1363(b []byte) ([2]*big.Int, error) {
+	size := (dsa.N.BitLen() + 7) / 8
+	if len(b) != 2*size {
+		return [2]*big.Int{}, errors.New("invalid signature encoding: length")
+	}
+	sig := [2]*big.Int{new(big.Int).SetBytes(b[:size]), new(big.Int).SetBytes(b[size:])}
+	if err := dsa.checkRange(sig); err != nil {
+		return [2]*big.Int{}, err
+	}
+	return sig, nil
+}
+
+// marshalECPrivateKey returns the SEC1 encoding of the private key, with the
+// public key and, when oid is not nil, the named curve
+func (dsa DSA) marshalECPrivateKey(c ecc.Curve, privK *big.Int, oid asn1.ObjectIdentifier) ([]byte, error) {
+	if privK == nil || privK.Sign() <= 0 || privK.Cmp(dsa.N) >= 0 {
+		return nil, errors.New("the private key must be in [1, N)")
+	}
+	pubK, err := dsa.PubK(privK)
+	if err != nil {
+		return nil, err
+	}
+	pubKBytes, err := c.Marshal(pubK)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    privK.FillBytes(make([]byte, (dsa.N.BitLen()+7)/8)),
+		NamedCurveOID: oid,
+		PublicKey:     asn1.BitString{Bytes: pubKBytes, BitLength: 8 * len(pubKBytes)},
+	})
+}
+
+// MarshalSEC1PrivateKey returns the SEC1 (RFC 5915) DER encoding of the private
+// key, with the named curve and the public key, the "EC PRIVATE KEY" of OpenSSL
+func (dsa DSA) MarshalSEC1PrivateKey(privK *big.Int) ([]byte, error) {
+	c, oid, err := dsa.curveOID()
+	if err != nil {
+		return nil, err
+	}
+	return dsa.marshalECPrivateKey(c, privK, oid)
+}
+
+// MarshalPKCS8PrivateKey returns the PKCS #8 DER encoding of the private key,
+// the "PRIVATE KEY" of OpenSSL. The named curve is in the algorithm identifier,
+// and the SEC1 private key inside omits it, as OpenSSL and crypto/x509
+func (dsa DSA) MarshalPKCS8PrivateKey(privK *big.Int) ([]byte, error) {
+	c, oid, err := dsa.curveOID()
+	if err != nil {
+		return nil, err
+	}
+	key, err := dsa.marshalECPrivateKey(c, privK, nil)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8{
+		Version:    0,
+		Algo:       algorithmIdentifier{oidPublicKeyECDSA, oid},
+		PrivateKey: key,
+	})
+}
+
+// MarshalPKIXPublicKey returns the SubjectPublicKeyInfo DER encoding of the
+// public key, with the SEC1 uncompressed point, the "PUBLIC KEY" of OpenSSL
+func (dsa DSA) MarshalPKIXPublicKey(pubK group.Element) ([]byte, error) {
+	c, oid, err := dsa.curveOID()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(pubK); err != nil {
+		return nil, err
+	}
+	if c.Equal(pubK, c.Identity()) {
+		return nil, errors.New("invalid public key")
+	}
+	b, err := c.Marshal(pubK)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(publicKeyInfo{
+		Algorithm: algorithmIdentifier{oidPublicKeyECDSA, oid},
+		PublicKey: asn1.BitString{Bytes: b, BitLength: 8 * len(b)},
+	})
+}
+
+// parseECPrivateKey decodes a SEC1 private key, over the curve of the named
+// curve oid when it is not nil (the curve of the PKCS #8 algorithm), and checks
+// that the public key, when present, is the one of the private key
+func parseECPrivateKey(b []byte, oid asn1.ObjectIdentifier) (DSA, *big.Int, error) {
+	var key ecPrivateKey
+	rest, err := asn1.Unmarshal(b, &key)
+	if err != nil {
+		return DSA{}, nil, err
+	}
+	if len(rest) != 0 {
+		return DSA{}, nil, errors.New("invalid private key encoding: trailing data")
+	}
+	if key.Version != 1 {
+		return DSA{}, nil, errors.New("invalid private key encoding: unknown version")
+	}
+	if oid == nil {
+		oid = key.NamedCurveOID
+	} else if key.NamedCurveOID != nil && !key.NamedCurveOID.Equal(oid) {
+		return DSA{}, nil, errors.New("invalid private key encoding: different curves")
+	}
+	c, err := curveByOID(oid)
+	if err != nil {
+		return DSA{}, nil, err
+	}
+	dsa := NewDSAFromCurve(c)
+	if len(key.PrivateKey) > (dsa.N.BitLen()+7)/8 {
+		return DSA{}, nil, errors.New("invalid private key encoding: length")
+	}
+	privK := new(big.Int).SetBytes(key.PrivateKey)
+	if privK.Sign() <= 0 || privK.Cmp(dsa.N) >= 0 {
+		return DSA{}, nil, errors.New("the private key must be in [1, N)")
+	}
+	if key.PublicKey.BitLength > 0 {
+		pubK, err := c.Unmarshal(key.PublicKey.RightAlign())
+		if err != nil {
+			return DSA{}, nil, err
+		}
+		pubK2, err := dsa.PubK(privK)
+		if err != nil {
+			return DSA{}, nil, err
+		}
+		if !c.Equal(pubK, pubK2) {
+			return DSA{}, nil, errors.New("the public key is not the one of the private key")
+		}
+	}
+	return dsa, privK, nil
+}
+
+// ParseSEC1PrivateKey decodes a SEC1 DER encoded private key, returning the DSA
+// over its named curve and the private key
+func ParseSEC1PrivateKey(b []byte) (DSA, *big.Int, error) {
+	return parseECPrivateKey(b, nil)
+}
+
+// ParsePKCS8PrivateKey decodes a PKCS #8 DER encoded elliptic curve private
+// key, returning the DSA over its named curve and the private key
+func ParsePKCS8PrivateKey(b []byte) (DSA, *big.Int, error) {
+	var key pkcs8
+	rest, err := asn1.Unmarshal(b, &key)
+	if err != nil {
+		return DSA{}, nil, err
+	}
+	if len(rest) != 0 {
+		return DSA{}, nil, errors.New("invalid private key encoding: trailing data")
+	}
+	if key.Version != 0 {
+		return DSA{}, nil, errors.New("invalid private key encoding: unknown version")
+	}
+	if !key.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return DSA{}, nil, errors.New("not an elliptic curve private key")
+	}
+	return parseECPrivateKey(key.PrivateKey, key.Algo.NamedCurve)
+}
+
+// ParsePKIXPublicKey decodes a SubjectPublicKeyInfo DER encoded elliptic curve
+// public key, returning the DSA over its named curve and the public key
+func ParsePKIXPublicKey(b []byte) (DSA, group.Element, error) {
+	var info publicKeyInfo
+	rest, err := asn1.Unmarshal(b, &info)
+	if err != nil {
+		return DSA{}, nil, err
+	}
+	if len(rest) != 0 {
+		return DSA{}, nil, errors.New("invalid public key encoding: trailing data")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return DSA{}, nil, errors.New("not an elliptic curve public key")
+	}
+	c, err := curveByOID(info.Algorithm.NamedCurve)
+	if err != nil {
+		return DSA{}, nil, err
+	}
+	pubK, err := c.Unmarshal(info.PublicKey.RightAlign())
+	if err != nil {
+		return DSA{}, nil, err
+	}
+	if c.Equal(pubK, c.Identity()) {
+		return DSA{}, nil, errors.New("invalid public key")
+	}
+	return NewDSAFromCurve(c), pubK, nil
+}
+
+// PrivateKeyPEM returns the PEM encoding of the PKCS #8 private key
+func (dsa DSA) PrivateKeyPEM(privK *big.Int) ([]byte, error) {
+	b, err := dsa.MarshalPKCS8PrivateKey(privK)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b}), nil
+}
+
+// PublicKeyPEM returns the PEM encoding of the SubjectPublicKeyInfo public key
+func (dsa DSA) PublicKeyPEM(pubK group.Element) ([]byte, error) {
+	b, err := dsa.MarshalPKIXPublicKey(pubK)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b}), nil
+}
+
+// ParsePrivateKeyPEM decodes the first private key of the PEM data, a PKCS #8
+// "PRIVATE KEY" or a SEC1 "EC PRIVATE KEY", skipping the other blocks (as the
+// "EC PARAMETERS" of openssl ecparam -genkey)
+func ParsePrivateKeyPEM(data []byte) (DSA, *big.Int, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return DSA{}, nil, errors.New("no private key found in the PEM data")
+		}
+		switch block.Type {
+		case "PRIVATE KEY":
+			return ParsePKCS8PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return ParseSEC1PrivateKey(block.Bytes)
+		}
+	}
+}
+
+// ParsePublicKeyPEM decodes the first "PUBLIC KEY" of the PEM data
+func ParsePublicKeyPEM(data []byte) (DSA, group.Element, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return DSA{}, nil, errors.New("no public key found in the PEM data")
+		}
+		if block.Type == "PUBLIC KEY" {
+			return ParsePKIXPublicKey(block.Bytes)
+		}
+	}
+}
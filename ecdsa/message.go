@@ -0,0 +1,47 @@
+package ecdsa
+
+import (
+	"crypto"
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/group"
+
+	// the SHA-3 functions are also available for the messages and the nonces
+	_ "crypto/sha3"
+)
+
+// HashToInt returns the hash value of msg with the hash function h, as FIPS
+// 186: the leftmost N.BitLen() bits of the digest, as an integer. It is the
+// hashval of Sign and Verify, also used by crypto/ecdsa
+func (dsa DSA) HashToInt(msg []byte, h crypto.Hash) (*big.Int, error) {
+	if !h.Available() {
+		return nil, errors.New("hash function not available")
+	}
+	hh := h.New()
+	hh.Write(msg)
+	return bits2int(hh.Sum(nil), dsa.N.BitLen()), nil
+}
+
+// SignMessage performs the ECDSA signature of the message msg, hashed with h
+// and truncated with HashToInt, with the deterministic nonce of RFC 6979 over
+// the same hash function. In the lenient mode the signatures are the ones of
+// the deterministic signatures of crypto/ecdsa (PrivateKey.Sign with a nil
+// random source) on the same curve
+func (dsa DSA) SignMessage(msg []byte, privK *big.Int, h crypto.Hash) ([2]*big.Int, error) {
+	hashval, err := dsa.HashToInt(msg, h)
+	if err != nil {
+		return [2]*big.Int{}, err
+	}
+	return dsa.SignDeterministic(hashval, privK, h, nil)
+}
+
+// VerifyMessage validates the ECDSA signature of the message msg, hashed with h
+// and truncated with HashToInt
+func (dsa DSA) VerifyMessage(msg []byte, sig [2]*big.Int, pubK group.Element, h crypto.Hash) (bool, error) {
+	hashval, err := dsa.HashToInt(msg, h)
+	if err != nil {
+		return false, err
+	}
+	return dsa.Verify(hashval, sig, pubK)
+}
@@ -0,0 +1,99 @@
+package ecdsa
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashToInt(t *testing.T) {
+	msg := []byte("sample")
+	digest := sha512.Sum512(msg)
+
+	// P-256 with SHA-512, the leftmost 256 bits of the digest
+	dsa := NewDSAFromCurve(ecc.P256())
+	hashval, err := dsa.HashToInt(msg, crypto.SHA512)
+	assert.Nil(t, err)
+	assert.Equal(t, new(big.Int).SetBytes(digest[:32]).String(), hashval.String())
+
+	// P-521 with SHA-512, the whole digest
+	dsa = NewDSAFromCurve(ecc.P521())
+	hashval, err = dsa.HashToInt(msg, crypto.SHA512)
+	assert.Nil(t, err)
+	assert.Equal(t, new(big.Int).SetBytes(digest[:]).String(), hashval.String())
+
+	_, err = dsa.HashToInt(msg, crypto.MD4)
+	assert.NotNil(t, err)
+}
+
+func TestSignMessage(t *testing.T) {
+	// the same signatures as the deterministic signatures of crypto/ecdsa
+	curves := []struct {
+		c   ecc.Curve
+		std elliptic.Curve
+	}{{ecc.P256(), elliptic.P256()}, {ecc.P384(), elliptic.P384()}, {ecc.P521(), elliptic.P521()}}
+	hashes := []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512,
+		crypto.SHA3_256, crypto.SHA3_384, crypto.SHA3_512}
+	msg := []byte("message to sign")
+	for _, curve := range curves {
+		dsa := NewDSAFromCurve(curve.c)
+		std, err := stdecdsa.GenerateKey(curve.std, rand.Reader)
+		assert.Nil(t, err)
+		privK := std.D
+		pubK := ecc.Point{X: std.X, Y: std.Y}
+		for _, h := range hashes {
+			name := curve.c.Name + " " + h.String()
+			sig, err := dsa.SignMessage(msg, privK, h)
+			assert.Nil(t, err)
+			verified, err := dsa.VerifyMessage(msg, sig, pubK, h)
+			assert.Nil(t, err)
+			assert.True(t, verified, name)
+			verified, err = dsa.VerifyMessage([]byte("another message"), sig, pubK, h)
+			assert.Nil(t, err)
+			assert.False(t, verified, name)
+
+			hh := h.New()
+			hh.Write(msg)
+			digest := hh.Sum(nil)
+			stdSig, err := std.Sign(nil, digest, h)
+			assert.Nil(t, err)
+			der, err := asn1.Marshal(struct{ R, S *big.Int }{sig[0], sig[1]})
+			assert.Nil(t, err)
+			assert.Equal(t, stdSig, der, name)
+			assert.True(t, stdecdsa.VerifyASN1(&std.PublicKey, digest, der), name)
+
+			// the random signatures of crypto/ecdsa verify
+			stdSig, err = stdecdsa.SignASN1(rand.Reader, std, digest)
+			assert.Nil(t, err)
+			var s struct{ R, S *big.Int }
+			_, err = asn1.Unmarshal(stdSig, &s)
+			assert.Nil(t, err)
+			verified, err = dsa.VerifyMessage(msg, [2]*big.Int{s.R, s.S}, pubK, h)
+			assert.Nil(t, err)
+			assert.True(t, verified, name)
+		}
+	}
+}
+
+func TestSignMessageErrors(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	privK := big.NewInt(int64(1234))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	_, err = dsa.SignMessage([]byte("msg"), privK, crypto.MD4)
+	assert.NotNil(t, err)
+	sig, err := dsa.SignMessage([]byte("msg"), privK, crypto.SHA256)
+	assert.Nil(t, err)
+	_, err = dsa.VerifyMessage([]byte("msg"), sig, pubK, crypto.MD4)
+	assert.NotNil(t, err)
+	_, err = dsa.SignMessage([]byte("msg"), big.NewInt(int64(0)), crypto.SHA256)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,193 @@
+package ecdsa
+
+import (
+	"crypto"
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
+)
+
+// curve returns the elliptic curve of the DSA, as the public key recovery is
+// only defined over elliptic curves
+func (dsa DSA) curve() (ecc.Curve, error) {
+	c, ok := dsa.Group.(ecc.Curve)
+	if !ok {
+		return ecc.Curve{}, errors.New("the public key recovery needs an ecc.Curve group")
+	}
+	return c, nil
+}
+
+// recoveryID returns the recovery id of the point m = k x G
+func (dsa DSA) recoveryID(m group.Element) (byte, error) {
+	p, ok := m.(ecc.Point)
+	if !ok {
+		return 0, errors.New("the public key recovery needs an ecc.Curve group")
+	}
+	recid := byte(p.Y.Bit(0))
+	if p.X.Cmp(dsa.N) >= 0 {
+		recid |= 2
+	}
+	// over the small curves with q > 2N, x can be r + 2N, ...
+	if p.X.Cmp(new(big.Int).Lsh(dsa.N, 1)) >= 0 {
+		return 0, errors.New("the x coordinate of k x G is not smaller than 2N, use another nonce")
+	}
+	return recid, nil
+}
+
+// SignRecoverable performs the ECDSA signature with the nonce k, as Sign, and
+// returns also the recovery id, which identifies the point R = k x G among the
+// points with x coordinate r or r + N: its bit 0 is the parity of the y
+// coordinate of R, and its bit 1 is set when the x coordinate of R is r + N
+// (which for the named curves happens with probability about (q - N) / q).
+// With it RecoverPubK computes the public key from the signature
+func (dsa DSA) SignRecoverable(hashval, privK, k *big.Int) ([2]*big.Int, byte, error) {
+	if _, err := dsa.curve(); err != nil {
+		return [2]*big.Int{}, 0, err
+	}
+	sig, m, err := dsa.sign(hashval, privK, k)
+	if err != nil {
+		return [2]*big.Int{}, 0, err
+	}
+	recid, err := dsa.recoveryID(m)
+	return sig, recid, err
+}
+
+// SignCompact performs the ECDSA signature with the deterministic nonce of
+// SignDeterministic, and returns the compact signature with the recovery id,
+// r || s || v (65 bytes for the 256 bit curves), from which the public key is
+// recovered with RecoverPubKCompact
+func (dsa DSA) SignCompact(hashval, privK *big.Int, h crypto.Hash, extra []byte) ([]byte, error) {
+	if _, err := dsa.curve(); err != nil {
+		return nil, err
+	}
+	sig, m, err := dsa.signDeterministic(hashval, privK, h, extra)
+	if err != nil {
+		return nil, err
+	}
+	recid, err := dsa.recoveryID(m)
+	if err != nil {
+		return nil, err
+	}
+	return dsa.CompactSignature(sig, recid)
+}
+
+// CompactSignature returns the compact encoding of the signature and its
+// recovery id, r || s || v, the P1363 encoding of the signature followed by
+// v = recid, as the Ethereum signatures
+func (dsa DSA) CompactSignature(sig [2]*big.Int, recid byte) ([]byte, error) {
+	if recid > 3 {
+		return nil, errors.New("invalid recovery id")
+	}
+	b, err := dsa.MarshalP1363(sig)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, recid), nil
+}
+
+// ParseCompactSignature decodes a compact signature r || s || v, accepting
+// also the legacy Ethereum values v = 27 + recid
+func (dsa DSA) ParseCompactSignature(b []byte) ([2]*big.Int, byte, error) {
+	size := (dsa.N.BitLen() + 7) / 8
+	if len(b) != 2*size+1 {
+		return [2]*big.Int{}, 0, errors.New("invalid compact signature length")
+	}
+	recid := b[2*size]
+	if recid >= 27 {
+		recid -= 27
+	}
+	if recid > 3 {
+		return [2]*big.Int{}, 0, errors.New("invalid recovery id")
+	}
+	sig, err := dsa.ParseP1363(b[:2*size])
+	if err != nil {
+		return [2]*big.Int{}, 0, err
+	}
+	return sig, recid, nil
+}
+
+// RecoverPubK returns the public key of the signature of hashval with the
+// recovery id recid: R is the point with x coordinate r + (recid >> 1) N and
+// the y parity of the bit 0 of recid, obtained with ecc.EC.At, and the public
+// key is r^-1 (s R - hashval G)
+func (dsa DSA) RecoverPubK(hashval *big.Int, sig [2]*big.Int, recid byte) (group.Element, error) {
+	c, err := dsa.curve()
+	if err != nil {
+		return nil, err
+	}
+	if recid > 3 {
+		return nil, errors.New("invalid recovery id")
+	}
+	if err := dsa.checkSignature(sig); err != nil {
+		return nil, err
+	}
+	x := new(big.Int).Set(sig[0])
+	if recid&2 != 0 {
+		x.Add(x, dsa.N)
+	}
+	if x.Cmp(c.EC.Q) >= 0 {
+		return nil, errors.New("invalid recovery id: r + N is not in the field")
+	}
+	p0, p1, err := c.EC.At(x)
+	if err != nil {
+		return nil, err
+	}
+	R := p0
+	if p0.Y.Bit(0) != uint(recid&1) {
+		R = p1
+	}
+	// R must be in the subgroup of order N
+	if err := c.Validate(R); err != nil {
+		return nil, err
+	}
+
+	// u1 = -hashval r^-1, u2 = s r^-1
+	rInv := new(big.Int).ModInverse(sig[0], dsa.N)
+	if rInv == nil {
+		return nil, errors.New("invalid signature: r is not invertible mod N")
+	}
+	u1 := new(big.Int).Mul(hashval, rInv)
+	u1.Neg(u1)
+	u1.Mod(u1, dsa.N)
+	u2 := new(big.Int).Mul(sig[1], rInv)
+	u2.Mod(u2, dsa.N)
+	pubK, err := group.MultiMul(dsa.Group, []group.Element{dsa.G, R}, []*big.Int{u1, u2})
+	if err != nil {
+		return nil, err
+	}
+	if dsa.Group.Equal(pubK, dsa.Group.Identity()) {
+		return nil, errors.New("invalid signature: the recovered public key is the identity")
+	}
+	return pubK, nil
+}
+
+// RecoverPubKs returns the candidate public keys of the signature of hashval
+// for all the recovery ids, when the recovery id is unknown. The signature
+// verifies with all of them
+func (dsa DSA) RecoverPubKs(hashval *big.Int, sig [2]*big.Int) ([]group.Element, error) {
+	if _, err := dsa.curve(); err != nil {
+		return nil, err
+	}
+	var pubKs []group.Element
+	for recid := byte(0); recid < 4; recid++ {
+		if pubK, err := dsa.RecoverPubK(hashval, sig, recid); err == nil {
+			pubKs = append(pubKs, pubK)
+		}
+	}
+	if len(pubKs) == 0 {
+		return nil, errors.New("no public key recovered from the signature")
+	}
+	return pubKs, nil
+}
+
+// RecoverPubKCompact returns the public key of the compact signature of
+// hashval returned by SignCompact
+func (dsa DSA) RecoverPubKCompact(hashval *big.Int, b []byte) (group.Element, error) {
+	sig, recid, err := dsa.ParseCompactSignature(b)
+	if err != nil {
+		return nil, err
+	}
+	return dsa.RecoverPubK(hashval, sig, recid)
+}
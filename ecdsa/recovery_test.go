@@ -0,0 +1,181 @@
+package ecdsa
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPubK(t *testing.T) {
+	for _, c := range []ecc.Curve{ecc.Secp256k1(), ecc.P256(), ecc.P384()} {
+		dsa := NewDSAFromCurve(c)
+		for i := 0; i < 5; i++ {
+			privK, err := rand.Int(rand.Reader, dsa.N)
+			assert.Nil(t, err)
+			pubK, err := dsa.PubK(privK)
+			assert.Nil(t, err)
+			hashval, err := rand.Int(rand.Reader, dsa.N)
+			assert.Nil(t, err)
+			k, err := rand.Int(rand.Reader, dsa.N)
+			assert.Nil(t, err)
+
+			sig, recid, err := dsa.SignRecoverable(hashval, privK, k)
+			assert.Nil(t, err)
+			assert.True(t, recid < 4)
+			recovered, err := dsa.RecoverPubK(hashval, sig, recid)
+			assert.Nil(t, err)
+			assert.True(t, c.Equal(pubK, recovered), c.Name)
+
+			// with the other y parity, another public key for which the
+			// signature is also valid
+			other, err := dsa.RecoverPubK(hashval, sig, recid^1)
+			assert.Nil(t, err)
+			assert.False(t, c.Equal(pubK, other))
+			verified, err := dsa.Verify(hashval, sig, other)
+			assert.Nil(t, err)
+			assert.True(t, verified)
+
+			candidates, err := dsa.RecoverPubKs(hashval, sig)
+			assert.Nil(t, err)
+			found := false
+			for _, p := range candidates {
+				found = found || c.Equal(pubK, p)
+			}
+			assert.True(t, found)
+		}
+	}
+}
+
+func TestSignCompact(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	privK := hexToInt("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	hashval := hashInt(crypto.SHA256, "message", dsa.N)
+
+	b, err := dsa.SignCompact(hashval, privK, crypto.SHA256, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 65, len(b))
+	assert.True(t, b[64] < 4)
+	recovered, err := dsa.RecoverPubKCompact(hashval, b)
+	assert.Nil(t, err)
+	assert.True(t, dsa.Group.Equal(pubK, recovered))
+
+	// the same r and s as SignDeterministic
+	sig, err := dsa.SignDeterministic(hashval, privK, crypto.SHA256, nil)
+	assert.Nil(t, err)
+	sig2, recid, err := dsa.ParseCompactSignature(b)
+	assert.Nil(t, err)
+	assert.Equal(t, sig, sig2)
+	assert.Equal(t, b[64], recid)
+
+	// the legacy v = 27 + recid
+	b[64] += 27
+	recovered, err = dsa.RecoverPubKCompact(hashval, b)
+	assert.Nil(t, err)
+	assert.True(t, dsa.Group.Equal(pubK, recovered))
+
+	// another hash value recovers another public key
+	recovered, err = dsa.RecoverPubKCompact(big.NewInt(int64(40)), b)
+	assert.Nil(t, err)
+	assert.False(t, dsa.Group.Equal(pubK, recovered))
+
+	b[64] = 4
+	_, err = dsa.RecoverPubKCompact(hashval, b)
+	assert.NotNil(t, err)
+	_, err = dsa.RecoverPubKCompact(hashval, b[:64])
+	assert.NotNil(t, err)
+}
+
+func TestRecoverPubKSmallCurve(t *testing.T) {
+	// over F_23 with N = 13 the x coordinate of k x G is often bigger than N
+	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(23)))
+	g := ecc.Point{X: big.NewInt(int64(0)), Y: big.NewInt(int64(8))}
+	dsa, err := NewDSA(ec, g)
+	assert.Nil(t, err)
+	privK := big.NewInt(int64(5))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	hashval := big.NewInt(int64(3))
+
+	ids := make(map[byte]bool)
+	for k := int64(1); k < 13; k++ {
+		sig, recid, err := dsa.SignRecoverable(hashval, privK, big.NewInt(k))
+		if err == errZeroSignature {
+			continue
+		}
+		assert.Nil(t, err)
+		ids[recid] = true
+		recovered, err := dsa.RecoverPubK(hashval, sig, recid)
+		assert.Nil(t, err)
+		assert.True(t, dsa.Group.Equal(pubK, recovered), "k=%d", k)
+	}
+	assert.True(t, ids[2] || ids[3])
+}
+
+func TestRecoverStdSignatures(t *testing.T) {
+	// the public key of the signatures of crypto/ecdsa is among the candidates
+	std, err := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	dsa := NewDSAFromCurve(ecc.P256())
+	pubK := ecc.Point{X: std.X, Y: std.Y}
+	for i := 0; i < 5; i++ {
+		digest := make([]byte, 32)
+		_, err = rand.Read(digest)
+		assert.Nil(t, err)
+		der, err := stdecdsa.SignASN1(rand.Reader, std, digest)
+		assert.Nil(t, err)
+		var sig struct{ R, S *big.Int }
+		_, err = asn1.Unmarshal(der, &sig)
+		assert.Nil(t, err)
+
+		candidates, err := dsa.RecoverPubKs(new(big.Int).SetBytes(digest), [2]*big.Int{sig.R, sig.S})
+		assert.Nil(t, err)
+		found := false
+		for _, p := range candidates {
+			found = found || dsa.Group.Equal(pubK, p)
+		}
+		assert.True(t, found)
+	}
+}
+
+func TestRecoverPubKErrors(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	hashval := big.NewInt(int64(40))
+	sig, recid, err := dsa.SignRecoverable(hashval, big.NewInt(int64(1234)), big.NewInt(int64(5678)))
+	assert.Nil(t, err)
+	for _, s := range [][2]*big.Int{
+		{big.NewInt(int64(0)), sig[1]},
+		{sig[0], big.NewInt(int64(0))},
+		{sig[0], dsa.N},
+		{nil, sig[1]},
+	} {
+		_, err = dsa.RecoverPubK(hashval, s, recid)
+		assert.NotNil(t, err)
+	}
+	_, err = dsa.RecoverPubK(hashval, sig, 4)
+	assert.NotNil(t, err)
+	_, err = dsa.CompactSignature(sig, 4)
+	assert.NotNil(t, err)
+	_, err = dsa.CompactSignature([2]*big.Int{sig[0], dsa.N}, recid)
+	assert.NotNil(t, err)
+
+	// the recovery is only defined over elliptic curves
+	zp, err := group.GenerateZpGroup(512, 160)
+	assert.Nil(t, err)
+	dsaZp := NewDSAFromGroup(zp)
+	_, _, err = dsaZp.SignRecoverable(hashval, big.NewInt(int64(1234)), big.NewInt(int64(5678)))
+	assert.NotNil(t, err)
+	_, err = dsaZp.RecoverPubK(hashval, sig, 0)
+	assert.NotNil(t, err)
+	_, err = dsaZp.SignCompact(hashval, big.NewInt(int64(1234)), crypto.SHA256, nil)
+	assert.NotNil(t, err)
+}
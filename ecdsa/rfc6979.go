@@ -0,0 +1,148 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"errors"
+	"hash"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/group"
+
+	// the SHA-2 functions are available for the nonces (and SHA-3, linked by
+	// message.go), other hashes must be linked by the caller
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// bits2int returns the integer of the leftmost qlen bits of b (RFC 6979,
+// section 2.3.2)
+func bits2int(b []byte, qlen int) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if blen := len(b) * 8; blen > qlen {
+		v.Rsh(v, uint(blen-qlen))
+	}
+	return v
+}
+
+// int2octets returns x as a big endian byte string of rlen = ceil(qlen / 8)
+// bytes (RFC 6979, section 2.3.3)
+func int2octets(x *big.Int, rlen int) []byte {
+	return x.FillBytes(make([]byte, rlen))
+}
+
+// hmacDRBG is the HMAC_DRBG of RFC 6979 (section 3.2), which generates the
+// candidate nonces
+type hmacDRBG struct {
+	h    func() hash.Hash
+	k, v []byte
+	n    *big.Int
+	qlen int
+}
+
+// newHMACDRBG initializes the HMAC_DRBG with the private key, the hash value
+// and the additional data extra (section 3.6)
+func newHMACDRBG(h crypto.Hash, n, privK, hashval *big.Int, extra []byte) *hmacDRBG {
+	qlen := n.BitLen()
+	rlen := (qlen + 7) / 8
+	d := &hmacDRBG{
+		h:    h.New,
+		k:    make([]byte, h.Size()),
+		v:    make([]byte, h.Size()),
+		n:    n,
+		qlen: qlen,
+	}
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	// bits2octets(h1) = int2octets(bits2int(h1) mod q)
+	x := int2octets(privK, rlen)
+	h1 := int2octets(new(big.Int).Mod(hashval, n), rlen)
+	for _, b := range []byte{0x00, 0x01} {
+		// K = HMAC_K(V || b || int2octets(x) || bits2octets(h1) || k')
+		d.k = d.mac(d.v, []byte{b}, x, h1, extra)
+		// V = HMAC_K(V)
+		d.v = d.mac(d.v)
+	}
+	return d
+}
+
+// mac returns the HMAC with the key K of the concatenation of data
+func (d *hmacDRBG) mac(data ...[]byte) []byte {
+	m := hmac.New(d.h, d.k)
+	for _, b := range data {
+		m.Write(b)
+	}
+	return m.Sum(nil)
+}
+
+// next returns the next candidate nonce in [1, N), skipping the values out of
+// the range, and updates the state so the following call returns a new one
+func (d *hmacDRBG) next() *big.Int {
+	for {
+		var t []byte
+		for len(t)*8 < d.qlen {
+			d.v = d.mac(d.v)
+			t = append(t, d.v...)
+		}
+		k := bits2int(t, d.qlen)
+		// K = HMAC_K(V || 0x00), V = HMAC_K(V), for the next candidate
+		d.k = d.mac(d.v, []byte{0x00})
+		d.v = d.mac(d.v)
+		if k.Sign() > 0 && k.Cmp(d.n) < 0 {
+			return k
+		}
+	}
+}
+
+// Nonce returns the deterministic nonce of RFC 6979 for the private key and the
+// hash value, generated with HMAC_DRBG over the hash function h. The hash value
+// is the integer used by Sign, so for hash functions bigger than N it must be
+// the leftmost bits of the hash (bits2int). The optional extra data (nil for
+// the deterministic nonces) is the additional input k' of section 3.6, which
+// mixes fresh entropy into the nonces while keeping them safe with a bad random
+// generator
+func (dsa DSA) Nonce(hashval, privK *big.Int, h crypto.Hash, extra []byte) (*big.Int, error) {
+	if err := dsa.checkNonceParams(privK, h); err != nil {
+		return nil, err
+	}
+	return newHMACDRBG(h, dsa.N, privK, hashval, extra).next(), nil
+}
+
+// SignDeterministic performs the ECDSA signature with the nonce of RFC 6979,
+// derived from the private key and the hash value with the hash function h and
+// the optional extra data, so the same nonce is never used for different
+// messages. When a nonce gives r = 0 or s = 0, the next nonce of the HMAC_DRBG
+// is used
+func (dsa DSA) SignDeterministic(hashval, privK *big.Int, h crypto.Hash, extra []byte) ([2]*big.Int, error) {
+	sig, _, err := dsa.signDeterministic(hashval, privK, h, extra)
+	return sig, err
+}
+
+// signDeterministic performs the signature of SignDeterministic, returning
+// also the point k x G of the nonce
+func (dsa DSA) signDeterministic(hashval, privK *big.Int, h crypto.Hash, extra []byte) ([2]*big.Int, group.Element, error) {
+	if err := dsa.checkNonceParams(privK, h); err != nil {
+		return [2]*big.Int{}, nil, err
+	}
+	d := newHMACDRBG(h, dsa.N, privK, hashval, extra)
+	for {
+		sig, m, err := dsa.sign(hashval, privK, d.next())
+		if err == errZeroSignature {
+			continue
+		}
+		return sig, m, err
+	}
+}
+
+// checkNonceParams checks that the private key is in [1, N) and that the hash
+// function is available
+func (dsa DSA) checkNonceParams(privK *big.Int, h crypto.Hash) error {
+	if privK == nil || privK.Sign() <= 0 || privK.Cmp(dsa.N) >= 0 {
+		return errors.New("the private key must be in [1, N)")
+	}
+	if !h.Available() {
+		return errors.New("hash function not available")
+	}
+	return nil
+}
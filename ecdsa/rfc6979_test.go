@@ -0,0 +1,228 @@
+package ecdsa
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func hexToInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+// hashInt returns the leftmost bits of the hash of msg as an integer
+func hashInt(h crypto.Hash, msg string, n *big.Int) *big.Int {
+	hh := h.New()
+	hh.Write([]byte(msg))
+	return bits2int(hh.Sum(nil), n.BitLen())
+}
+
+func TestNonceRFC6979(t *testing.T) {
+	// RFC 6979, appendix A.1: qlen = 163, and the hash is bigger than q
+	dsa := DSA{N: hexToInt("4000000000000000000020108A2E0CC0D99F8A5EF")}
+	privK := hexToInt("09A4D6792295A7F730FC3F2B49CBC0F62E862272F")
+	h1 := sha256.Sum256([]byte("sample"))
+	hashval := bits2int(h1[:], dsa.N.BitLen())
+	assert.Equal(t, "1795edf0d54db760f156d0dac04c0322b3a204224",
+		new(big.Int).Mod(hashval, dsa.N).Text(16))
+	k, err := dsa.Nonce(hashval, privK, crypto.SHA256, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "23af4074c90a02b3fe61d286d5c87f425e6bdd81b", k.Text(16))
+
+	// RFC 6979, appendix A.2.5: P-256 with SHA-256
+	dsa = NewDSAFromCurve(ecc.P256())
+	privK = hexToInt("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	k, err = dsa.Nonce(hashInt(crypto.SHA256, "sample", dsa.N), privK, crypto.SHA256, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "a6e3c57dd01abe90086538398355dd4c3b17aa873382b0f24d6129493d8aad60", k.Text(16))
+}
+
+func TestSignDeterministic(t *testing.T) {
+	// the RFC 6979 test vectors of crypto/ecdsa, with SHA-256
+	vectors := []struct {
+		curve     ecc.Curve
+		d, x, y   string
+		msg, r, s string
+	}{
+		{ecc.P256(),
+			"C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721",
+			"60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6",
+			"7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299",
+			// the first nonce is not smaller than N
+			"wv[vnX",
+			"EFD9073B652E76DA1B5A019C0E4A2E3FA529B035A6ABB91EF67F0ED7A1F21234",
+			"3DB4706C9D9F4A4FE13BB5E08EF0FAB53A57DBAB2061C83A35FA411C68D2BA33"},
+		{ecc.P256(),
+			"C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721",
+			"60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6",
+			"7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299",
+			"sample",
+			"EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+			"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8"},
+		{ecc.P256(),
+			"C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721",
+			"60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6",
+			"7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299",
+			"test",
+			"F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+			"019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083"},
+		{ecc.P384(),
+			"6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5",
+			"EC3A4E415B4E19A4568618029F427FA5DA9A8BC4AE92E02E06AAE5286B300C64DEF8F0EA9055866064A254515480BC13",
+			"8015D9B72D7D57244EA8EF9AC0C621896708A59367F9DFB9F54CA84B3F1C9DB1288B231C3AE0D4FE7344FD2533264720",
+			"sample",
+			"21B13D1E013C7FA1392D03C5F99AF8B30C570C6F98D4EA8E354B63A21D3DAA33BDE1E888E63355D92FA2B3C36D8FB2CD",
+			"F3AA443FB107745BF4BD77CB3891674632068A10CA67E3D45DB2266FA7D1FEEBEFDC63ECCD1AC42EC0CB8668A4FA0AB0"},
+		{ecc.P384(),
+			"6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5",
+			"EC3A4E415B4E19A4568618029F427FA5DA9A8BC4AE92E02E06AAE5286B300C64DEF8F0EA9055866064A254515480BC13",
+			"8015D9B72D7D57244EA8EF9AC0C621896708A59367F9DFB9F54CA84B3F1C9DB1288B231C3AE0D4FE7344FD2533264720",
+			"test",
+			"6D6DEFAC9AB64DABAFE36C6BF510352A4CC27001263638E5B16D9BB51D451559F918EEDAF2293BE5B475CC8F0188636B",
+			"2D46F3BECBCC523D5F1A1256BF0C9B024D879BA9E838144C8BA6BAEB4B53B47D51AB373F9845C0514EEFB14024787265"},
+		{ecc.P521(),
+			"0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538",
+			"1894550D0785932E00EAA23B694F213F8C3121F86DC97A04E5A7167DB4E5BCD371123D46E45DB6B5D5370A7F20FB633155D38FFA16D2BD761DCAC474B9A2F5023A4",
+			"0493101C962CD4D2FDDF782285E64584139C2F91B47F87FF82354D6630F746A28A0DB25741B5B34A828008B22ACC23F924FAAFBD4D33F81EA66956DFEAA2BFDFCF5",
+			"sample",
+			"1511BB4D675114FE266FC4372B87682BAECC01D3CC62CF2303C92B3526012659D16876E25C7C1E57648F23B73564D67F61C6F14D527D54972810421E7D87589E1A7",
+			"04A171143A83163D6DF460AAF61522695F207A58B95C0644D87E52AA1A347916E4F7A72930B1BC06DBE22CE3F58264AFD23704CBB63B29B931F7DE6C9D949A7ECFC"},
+		{ecc.P521(),
+			"0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538",
+			"1894550D0785932E00EAA23B694F213F8C3121F86DC97A04E5A7167DB4E5BCD371123D46E45DB6B5D5370A7F20FB633155D38FFA16D2BD761DCAC474B9A2F5023A4",
+			"0493101C962CD4D2FDDF782285E64584139C2F91B47F87FF82354D6630F746A28A0DB25741B5B34A828008B22ACC23F924FAAFBD4D33F81EA66956DFEAA2BFDFCF5",
+			"test",
+			"00E871C4A14F993C6C7369501900C4BC1E9C7B0B4BA44E04868B30B41D8071042EB28C4C250411D0CE08CD197E4188EA4876F279F90B3D8D74A3C76E6F1E4656AA8",
+			"0CD52DBAA33B063C3A6CD8058A1FB0A46A4754B034FCC644766CA14DA8CA5CA9FDE00E88C1AD60CCBA759025299079D7A427EC3CC5B619BFBC828E7769BCD694E86"},
+	}
+	for _, v := range vectors {
+		dsa := NewDSAFromCurve(v.curve)
+		privK := hexToInt(v.d)
+		pubK, err := dsa.PubK(privK)
+		assert.Nil(t, err)
+		assert.True(t, dsa.Group.Equal(pubK, ecc.Point{X: hexToInt(v.x), Y: hexToInt(v.y)}))
+
+		hashval := hashInt(crypto.SHA256, v.msg, dsa.N)
+		sig, err := dsa.SignDeterministic(hashval, privK, crypto.SHA256, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, sig[0].Cmp(hexToInt(v.r)), v.curve.Name+" "+v.msg)
+		assert.Equal(t, 0, sig[1].Cmp(hexToInt(v.s)), v.curve.Name+" "+v.msg)
+		verified, err := dsa.Verify(hashval, sig, pubK)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+}
+
+func TestSignDeterministicHashes(t *testing.T) {
+	// the same signatures as the deterministic signatures of crypto/ecdsa, for
+	// the hashes bigger and smaller than N
+	curves := []struct {
+		c   ecc.Curve
+		std elliptic.Curve
+	}{{ecc.P256(), elliptic.P256()}, {ecc.P384(), elliptic.P384()}}
+	for _, curve := range curves {
+		dsa := NewDSAFromCurve(curve.c)
+		privK := hexToInt("1234567890abcdef1234567890abcdef")
+		pubK, err := dsa.PubK(privK)
+		assert.Nil(t, err)
+		p := pubK.(ecc.Point)
+		std := &stdecdsa.PrivateKey{
+			PublicKey: stdecdsa.PublicKey{Curve: curve.std, X: p.X, Y: p.Y},
+			D:         privK,
+		}
+		for _, h := range []crypto.Hash{crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+			hh := h.New()
+			hh.Write([]byte("sample"))
+			digest := hh.Sum(nil)
+			stdSig, err := std.Sign(nil, digest, h)
+			assert.Nil(t, err)
+
+			sig, err := dsa.SignDeterministic(bits2int(digest, dsa.N.BitLen()), privK, h, nil)
+			assert.Nil(t, err)
+			var der struct{ R, S *big.Int }
+			_, err = asn1.Unmarshal(stdSig, &der)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, sig[0].Cmp(der.R), curve.c.Name+" "+h.String())
+			assert.Equal(t, 0, sig[1].Cmp(der.S), curve.c.Name+" "+h.String())
+		}
+	}
+}
+
+func TestNonceExtraEntropy(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	privK := big.NewInt(int64(1234))
+	hashval := hashInt(crypto.SHA256, "message", dsa.N)
+
+	k0, err := dsa.Nonce(hashval, privK, crypto.SHA256, nil)
+	assert.Nil(t, err)
+	k1, err := dsa.Nonce(hashval, privK, crypto.SHA256, []byte("entropy 1"))
+	assert.Nil(t, err)
+	k2, err := dsa.Nonce(hashval, privK, crypto.SHA256, []byte("entropy 2"))
+	assert.Nil(t, err)
+	k1b, err := dsa.Nonce(hashval, privK, crypto.SHA256, []byte("entropy 1"))
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, k0.Cmp(k1))
+	assert.NotEqual(t, 0, k1.Cmp(k2))
+	assert.Equal(t, 0, k1.Cmp(k1b))
+
+	// other messages, keys and hashes give other nonces
+	k, err := dsa.Nonce(hashInt(crypto.SHA256, "message 2", dsa.N), privK, crypto.SHA256, nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, k0.Cmp(k))
+	k, err = dsa.Nonce(hashval, big.NewInt(int64(1235)), crypto.SHA256, nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, k0.Cmp(k))
+	k, err = dsa.Nonce(hashval, privK, crypto.SHA512, nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, k0.Cmp(k))
+
+	// the signatures with extra entropy are valid
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	sig, err := dsa.SignDeterministic(hashval, privK, crypto.SHA256, []byte("entropy 1"))
+	assert.Nil(t, err)
+	verified, err := dsa.Verify(hashval, sig, pubK)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
+
+func TestSignDeterministicSmallGroup(t *testing.T) {
+	// over the toy curve of order 19, where the 5 bit candidates are often bigger than N
+	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(19)))
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(11))}
+	dsa, err := NewDSA(ec, g)
+	assert.Nil(t, err)
+	privK := big.NewInt(int64(5))
+	pubK, err := dsa.PubK(privK)
+	assert.Nil(t, err)
+	for i := int64(1); i < 20; i++ {
+		hashval := big.NewInt(i)
+		sig, err := dsa.SignDeterministic(hashval, privK, crypto.SHA256, nil)
+		assert.Nil(t, err)
+		verified, err := dsa.Verify(hashval, sig, pubK)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+}
+
+func TestNonceErrors(t *testing.T) {
+	dsa := NewDSAFromCurve(ecc.P256())
+	hashval := big.NewInt(int64(40))
+	for _, privK := range []*big.Int{nil, big.NewInt(int64(0)), big.NewInt(int64(-1)), dsa.N} {
+		_, err := dsa.Nonce(hashval, privK, crypto.SHA256, nil)
+		assert.NotNil(t, err)
+		_, err = dsa.SignDeterministic(hashval, privK, crypto.SHA256, nil)
+		assert.NotNil(t, err)
+	}
+	// the MD4 implementation is not linked
+	_, err := dsa.Nonce(hashval, big.NewInt(int64(1)), crypto.MD4, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,140 @@
+package ecdsa
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
+)
+
+// stdCurves contains the named curves of crypto/elliptic, for which the public
+// keys of the Signer are crypto/ecdsa public keys
+var stdCurves = map[string]func() elliptic.Curve{
+	"P-256": elliptic.P256,
+	"P-384": elliptic.P384,
+	"P-521": elliptic.P521,
+}
+
+// PublicKey is the public key returned by Signer.Public over the curves not
+// supported by crypto/ecdsa
+type PublicKey struct {
+	DSA DSA
+	Q   group.Element
+}
+
+// Equal returns true if x is the same public key, over the same group
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	if !ok || pk.DSA.N.Cmp(other.DSA.N) != 0 || !pk.DSA.Group.Equal(pk.DSA.G, other.DSA.G) {
+		return false
+	}
+	return pk.DSA.Group.Equal(pk.Q, other.Q)
+}
+
+// Signer implements crypto.Signer with a private key of the DSA, for
+// crypto/tls, crypto/x509 and the other users of the standard interfaces. The
+// signatures are ASN.1 DER encoded, as the ones of crypto/ecdsa
+type Signer struct {
+	DSA   DSA
+	PrivK *big.Int
+	pubK  group.Element
+}
+
+// NewSigner returns the Signer of the private key
+func NewSigner(dsa DSA, privK *big.Int) (*Signer, error) {
+	if privK == nil || privK.Sign() <= 0 || privK.Cmp(dsa.N) >= 0 {
+		return nil, errors.New("the private key must be in [1, N)")
+	}
+	pubK, err := dsa.PubK(privK)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{DSA: dsa, PrivK: privK, pubK: pubK}, nil
+}
+
+// Public returns the public key, a *crypto/ecdsa.PublicKey over P-256, P-384
+// and P-521 (so crypto/tls and crypto/x509 accept the Signer), and a *PublicKey
+// over the other groups
+func (s *Signer) Public() crypto.PublicKey {
+	if c, ok := s.DSA.Group.(ecc.Curve); ok {
+		if stdCurve, ok := stdCurves[c.Name]; ok {
+			p := s.pubK.(ecc.Point)
+			return &stdecdsa.PublicKey{Curve: stdCurve(), X: new(big.Int).Set(p.X), Y: new(big.Int).Set(p.Y)}
+		}
+	}
+	return &PublicKey{DSA: s.DSA, Q: s.pubK}
+}
+
+// Sign signs the digest, truncated to the bit length of N, returning the DER
+// encoded signature. With a nil random source the signature is the
+// deterministic signature of RFC 6979 with the hash function of opts (the
+// signature of SignDeterministic and crypto/ecdsa). Otherwise 32 bytes of
+// random are the extra entropy of the nonce, as the hedged signatures of
+// crypto/ecdsa
+func (s *Signer) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	h := hashFunc(opts)
+	if h != 0 && len(digest) != h.Size() {
+		return nil, errors.New("the digest length does not match the hash function")
+	}
+	hashval := bits2int(digest, s.DSA.N.BitLen())
+	var extra []byte
+	if random == nil {
+		if h == 0 {
+			return nil, errors.New("the deterministic signatures need a hash function")
+		}
+	} else {
+		extra = make([]byte, 32)
+		if _, err := io.ReadFull(random, extra); err != nil {
+			return nil, err
+		}
+		if h == 0 || !h.Available() {
+			h = crypto.SHA256
+		}
+	}
+	sig, err := s.DSA.SignDeterministic(hashval, s.PrivK, h, extra)
+	if err != nil {
+		return nil, err
+	}
+	return s.DSA.MarshalDER(sig)
+}
+
+// VerifySignature validates the DER encoded signature of the digest with the
+// public key returned by Signer.Public (a *PublicKey, or a *crypto/ecdsa.PublicKey
+// over P-256, P-384 or P-521)
+func VerifySignature(pub crypto.PublicKey, digest, sig []byte, opts crypto.SignerOpts) (bool, error) {
+	var dsa DSA
+	var pubK group.Element
+	switch pk := pub.(type) {
+	case *PublicKey:
+		dsa, pubK = pk.DSA, pk.Q
+	case *stdecdsa.PublicKey:
+		c, err := ecc.CurveByName(pk.Curve.Params().Name)
+		if err != nil {
+			return false, err
+		}
+		dsa, pubK = NewDSAFromCurve(c), ecc.Point{X: pk.X, Y: pk.Y}
+	default:
+		return false, errors.New("not an ECDSA public key")
+	}
+	if h := hashFunc(opts); h != 0 && len(digest) != h.Size() {
+		return false, errors.New("the digest length does not match the hash function")
+	}
+	rs, err := dsa.ParseDER(sig)
+	if err != nil {
+		return false, err
+	}
+	return dsa.Verify(bits2int(digest, dsa.N.BitLen()), rs, pubK)
+}
+
+// hashFunc returns the hash function of opts, or 0 when opts is nil
+func hashFunc(opts crypto.SignerOpts) crypto.Hash {
+	if opts == nil {
+		return 0
+	}
+	return opts.HashFunc()
+}
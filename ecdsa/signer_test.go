@@ -0,0 +1,140 @@
+package ecdsa
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	std, err := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	signer, err := NewSigner(NewDSAFromCurve(ecc.P256()), std.D)
+	assert.Nil(t, err)
+	var _ crypto.Signer = signer
+	assert.True(t, std.PublicKey.Equal(signer.Public()))
+
+	digest := sha256.Sum256([]byte("message"))
+	// the deterministic signatures of crypto/ecdsa
+	sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	stdSig, err := std.Sign(nil, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	assert.Equal(t, stdSig, sig)
+
+	sig, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	assert.True(t, stdecdsa.VerifyASN1(&std.PublicKey, digest[:], sig))
+	verified, err := VerifySignature(signer.Public(), digest[:], sig, crypto.SHA256)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	verified, err = VerifySignature(&std.PublicKey, digest[:], stdSig, crypto.SHA256)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	digest2 := sha256.Sum256([]byte("another message"))
+	verified, err = VerifySignature(signer.Public(), digest2[:], sig, crypto.SHA256)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	// the hedged signatures are different each time
+	sig2, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	assert.NotEqual(t, sig, sig2)
+}
+
+func TestSignerCurves(t *testing.T) {
+	// over secp256k1 the public key is a *PublicKey
+	dsa := NewDSAFromCurve(ecc.Secp256k1())
+	dsa.Strict = true
+	signer, err := NewSigner(dsa, big.NewInt(int64(1234)))
+	assert.Nil(t, err)
+	pub, ok := signer.Public().(*PublicKey)
+	assert.True(t, ok)
+	assert.True(t, pub.Equal(signer.Public()))
+	other, err := NewSigner(dsa, big.NewInt(int64(4321)))
+	assert.Nil(t, err)
+	assert.False(t, pub.Equal(other.Public()))
+
+	digest := sha256.Sum256([]byte("message"))
+	for _, random := range []io.Reader{nil, rand.Reader} {
+		sig, err := signer.Sign(random, digest[:], crypto.SHA256)
+		assert.Nil(t, err)
+		rs, err := dsa.ParseDER(sig)
+		assert.Nil(t, err)
+		assert.True(t, dsa.IsLowS(rs[1]))
+		verified, err := VerifySignature(pub, digest[:], sig, crypto.SHA256)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+
+	_, err = signer.Sign(nil, digest[:], crypto.Hash(0))
+	assert.NotNil(t, err)
+	_, err = signer.Sign(rand.Reader, digest[:20], crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = VerifySignature(std256PublicKey(t), digest[:], []byte{0x30}, crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = VerifySignature("key", digest[:], []byte{0x30}, crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = NewSigner(dsa, big.NewInt(int64(0)))
+	assert.NotNil(t, err)
+}
+
+func std256PublicKey(t *testing.T) *stdecdsa.PublicKey {
+	std, err := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	return &std.PublicKey
+}
+
+func TestSignerTLS(t *testing.T) {
+	// a self-signed certificate signed by the Signer, used by a TLS server
+	privK, err := rand.Int(rand.Reader, ecc.P256().N)
+	assert.Nil(t, err)
+	signer, err := NewSigner(NewDSAFromCurve(ecc.P256()), privK)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(1)),
+		Subject:      pkix.Name{CommonName: "cryptofun"},
+		DNSNames:     []string{"cryptofun"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	assert.Nil(t, cert.CheckSignatureFrom(cert))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	serverConn, clientConn := net.Pipe()
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: signer}},
+	})
+	client := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "cryptofun"})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Handshake()
+	}()
+	assert.Nil(t, client.Handshake())
+	assert.Nil(t, <-errs)
+	_ = serverConn.Close()
+	_ = clientConn.Close()
+}
@@ -0,0 +1,88 @@
+package ecm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/ecc"
+)
+
+// suyama returns the parameter a24 = (A + 2) / 4 mod n of the Montgomery curve
+// of Suyama's parametrization with sigma, and its starting point (x : z):
+// u = sigma^2 - 5, v = 4 sigma, the point (u^3 : v^3), and
+// a24 = (v - u)^3 (3u + v) / (16 u^3 v). The curves have a torsion subgroup of
+// order 12, so their orders are more likely smooth. When 16 u^3 v is not
+// invertible mod n, it returns gcd(16 u^3 v, n), which can be a factor
+func suyama(n, sigma *big.Int) (a24, x, z, g *big.Int) {
+	u := new(big.Int).Mul(sigma, sigma)
+	u.Sub(u, big.NewInt(int64(5)))
+	u.Mod(u, n)
+	v := new(big.Int).Lsh(sigma, 2)
+	v.Mod(v, n)
+	x = new(big.Int).Exp(u, big.NewInt(int64(3)), n)
+	z = new(big.Int).Exp(v, big.NewInt(int64(3)), n)
+
+	num := new(big.Int).Sub(v, u)
+	num.Exp(num.Mod(num, n), big.NewInt(int64(3)), n)
+	t := new(big.Int).Mul(u, big.NewInt(int64(3)))
+	t.Add(t, v)
+	num.Mul(num, t)
+	den := new(big.Int).Lsh(x, 4)
+	den.Mul(den, v)
+	den.Mod(den, n)
+	inv := new(big.Int).ModInverse(den, n)
+	if inv == nil {
+		return nil, nil, nil, new(big.Int).GCD(nil, nil, den, n)
+	}
+	a24 = num.Mul(num, inv)
+	return a24.Mod(a24, n), x, z, nil
+}
+
+// suyamaXZ returns the x-only arithmetic mod n of the curve of Suyama's
+// parametrization with sigma, and its starting point, or the gcd of suyama
+func suyamaXZ(n, sigma *big.Int) (*ecc.MontgomeryXZ, ecc.XZPoint, *big.Int, error) {
+	a24, x, z, g := suyama(n, sigma)
+	if g != nil {
+		return nil, ecc.XZPoint{}, g, nil
+	}
+	c, err := ecc.NewMontgomeryXZ(n, a24)
+	if err != nil {
+		return nil, ecc.XZPoint{}, nil, err
+	}
+	return c, c.Point(x, z), nil, nil
+}
+
+// Curve returns the curve of Suyama's parametrization with sigma over F_p, as
+// a Montgomery curve B v^2 = u^3 + A u^2 + u, together with its starting point,
+// to analyze the curves found by FindFactor over the prime factors: the
+// factor p is found in stage 1 when the order of the point is B1-smooth. As
+// only u is defined by the parametrization, B is chosen so that the point has
+// v = 1
+func Curve(sigma, p *big.Int) (ecc.Montgomery, ecc.Point, error) {
+	if !p.ProbablyPrime(20) || p.Cmp(big.NewInt(int64(3))) <= 0 {
+		return ecc.Montgomery{}, ecc.Point{}, errors.New("p must be a prime bigger than 3")
+	}
+	a24, x, z, g := suyama(p, sigma)
+	if g != nil {
+		return ecc.Montgomery{}, ecc.Point{}, errors.New("singular curve for sigma " + sigma.String())
+	}
+	// A = 4 a24 - 2
+	a := new(big.Int).Lsh(a24, 2)
+	a.Sub(a, big.NewInt(int64(2)))
+	a.Mod(a, p)
+	u := new(big.Int).Mul(x, new(big.Int).ModInverse(z, p))
+	u.Mod(u, p)
+	// B = u^3 + A u^2 + u
+	b := new(big.Int).Add(u, a)
+	b.Mul(b, u)
+	b.Add(b, bigOne)
+	b.Mul(b, u)
+	b.Mod(b, p)
+	// A^2 = 4 for the singular curves, and B = 0 when the point has order 2
+	d := new(big.Int).Mul(a, a)
+	d.Sub(d, big.NewInt(int64(4)))
+	if b.Sign() == 0 || d.Mod(d, p).Sign() == 0 {
+		return ecc.Montgomery{}, ecc.Point{}, errors.New("singular curve for sigma " + sigma.String())
+	}
+	return ecc.NewMontgomery(a, b, new(big.Int).Set(p)), ecc.Point{X: u, Y: big.NewInt(int64(1))}, nil
+}
@@ -0,0 +1,392 @@
+// Package ecm implements Lenstra's elliptic curve factorization method (ECM),
+// with Montgomery curves in Suyama's parametrization, the Montgomery ladder
+// over the (X : Z) coordinates of ecc.MontgomeryXZ (with the arithmetic mod n
+// of the field package), stage 1 and the baby-step giant-step stage 2, running
+// curves in parallel. A factor p of n is found when the order of the
+// random curve over F_p is smooth, so the work depends on the size of the
+// smallest factor and not on the size of n: RSA and Paillier moduli with small
+// prime factors are easily factored
+package ecm
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/field"
+	"github.com/arnaucube/cryptofun/prime"
+)
+
+var bigOne = big.NewInt(int64(1))
+
+const (
+	// stage2D is the size of the giant steps of stage 2, 2 3 5 7 11, so only
+	// the baby steps j coprime with it are needed
+	stage2D = 2310
+	// checkEvery is the number of primes after which the workers check if
+	// another worker found a factor
+	checkEvery = 1024
+	// trialPrimes is the bound of the primes used for trial division by Factor
+	trialPrimes = 1 << 12
+)
+
+// Params are the parameters of ECM: the stage 1 bound B1, the stage 2 bound B2,
+// the maximum number of curves, and the number of workers running curves in
+// parallel (runtime.NumCPU() when it is not positive)
+type Params struct {
+	B1      uint64
+	B2      uint64
+	Curves  int
+	Workers int
+}
+
+// levels are the parameters to find factors of 15, 20, 25, 30 and 35 decimal
+// digits, with B2 = 100 B1, as the usual ECM tables
+var levels = []struct {
+	digits int
+	params Params
+}{
+	{15, Params{B1: 2000, B2: 200000, Curves: 25}},
+	{20, Params{B1: 11000, B2: 1100000, Curves: 90}},
+	{25, Params{B1: 50000, B2: 5000000, Curves: 300}},
+	{30, Params{B1: 250000, B2: 25000000, Curves: 700}},
+	{35, Params{B1: 1000000, B2: 100000000, Curves: 1800}},
+}
+
+// ParamsFor returns the parameters to find, with high probability, a factor of
+// up to the given number of decimal digits (at most 35)
+func ParamsFor(digits int) Params {
+	for _, l := range levels {
+		if digits <= l.digits {
+			return l.params
+		}
+	}
+	return levels[len(levels)-1].params
+}
+
+// Work reports the work done by FindFactor
+type Work struct {
+	// Curves is the number of curves tried
+	Curves uint64
+	// Stage is the stage in which the factor was found, 0 when it was found
+	// before stage 1 (n even, or a non invertible curve parameter)
+	Stage int
+	// Sigma is the parameter of the curve which found the factor
+	Sigma   *big.Int
+	Workers int
+	Elapsed time.Duration
+}
+
+// String returns a summary of the work
+func (w Work) String() string {
+	s := "ecm: " + strconv.FormatUint(w.Curves, 10) + " curves over " + strconv.Itoa(w.Workers) + " workers"
+	if w.Sigma != nil {
+		s += ", found in stage " + strconv.Itoa(w.Stage) + " with sigma " + w.Sigma.String()
+	}
+	return s + ", " + w.Elapsed.String()
+}
+
+// result is a factor found by a worker
+type result struct {
+	f     *big.Int
+	stage int
+	sigma *big.Int
+}
+
+// FindFactor returns a non trivial factor of the composite n, running up to
+// params.Curves random curves with the bounds B1 and B2
+func FindFactor(n *big.Int, params Params) (*big.Int, Work, error) {
+	start := time.Now()
+	workers := params.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	w := Work{Workers: workers}
+	if n.Cmp(big.NewInt(int64(4))) < 0 {
+		return nil, w, errors.New("n must be bigger than 3")
+	}
+	if n.Bit(0) == 0 {
+		w.Elapsed = time.Since(start)
+		return big.NewInt(int64(2)), w, nil
+	}
+	if n.ProbablyPrime(20) {
+		return nil, w, errors.New("n is prime")
+	}
+	// the primes up to 11 divide D, so stage 2 starts after them
+	if params.B1 < 11 || params.Curves <= 0 {
+		return nil, w, errors.New("B1 must be at least 11, and Curves positive")
+	}
+	b2 := params.B2
+	if b2 < params.B1 {
+		b2 = params.B1
+	}
+
+	var curves atomic.Uint64
+	done := make(chan struct{})
+	results := make(chan result, workers)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for curves.Add(1) <= uint64(params.Curves) {
+				r, err := runCurve(n, params.B1, b2, done)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if r != nil {
+					results <- *r
+					return
+				}
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var f *big.Int
+	var err error
+	select {
+	case r, ok := <-results:
+		if ok {
+			f = r.f
+			w.Stage = r.stage
+			w.Sigma = r.sigma
+		} else {
+			err = errors.New("no factor found with " + strconv.Itoa(params.Curves) + " curves")
+		}
+	case err = <-errs:
+	}
+	close(done)
+	wg.Wait()
+	w.Curves = curves.Load()
+	if w.Curves > uint64(params.Curves) {
+		w.Curves = uint64(params.Curves)
+	}
+	w.Elapsed = time.Since(start)
+	return f, w, err
+}
+
+// runCurve runs stage 1 and stage 2 over a random curve, returning nil when no
+// factor is found or done is closed
+func runCurve(n *big.Int, b1, b2 uint64, done <-chan struct{}) (*result, error) {
+	// sigma in [6, n)
+	sigma, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(int64(6))))
+	if err != nil {
+		return nil, err
+	}
+	sigma.Add(sigma, big.NewInt(int64(6)))
+	c, p, g, err := suyamaXZ(n, sigma)
+	if err != nil {
+		return nil, err
+	}
+	if g != nil {
+		if isFactor(g, n) {
+			return &result{g, 0, sigma}, nil
+		}
+		return nil, nil
+	}
+
+	// stage 1: Q = k P, with k the product of the prime powers up to B1
+	i := 0
+	for q := range prime.Primes(2, b1+1) {
+		if i%checkEvery == 0 && isDone(done) {
+			return nil, nil
+		}
+		i++
+		pe := q
+		for pe <= b1/q {
+			pe *= q
+		}
+		k := new(big.Int).SetUint64(pe)
+		p = c.Ladder(p, k, k.BitLen())
+	}
+	g = new(big.Int).GCD(nil, nil, p.Z.BigInt(), n)
+	if isFactor(g, n) {
+		return &result{g, 1, sigma}, nil
+	}
+	if g.Cmp(n) == 0 || b2 <= b1 {
+		// the point is the infinity modulo all the factors
+		return nil, nil
+	}
+
+	g = stage2(c, p, b1, b2, done)
+	if g != nil && isFactor(g, n) {
+		return &result{g, 2, sigma}, nil
+	}
+	return nil, nil
+}
+
+// stage2 finds the factors p for which the order of Q is B1-smooth except for
+// one prime l in (B1, B2]. Writing l = m D +- j, with j < D/2 coprime with D,
+// l Q = 0 mod p when m D Q = +-j Q, which have the same u coordinate, so
+// X_T Z_S - X_S Z_T = 0 mod p for T = m D Q and S = j Q. The baby steps S are
+// precomputed, and the giant steps T are computed with T_(m+1) = T_m + D Q,
+// with the difference T_(m-1). The primes l are walked with the segmented
+// sieve of prime.Primes. It returns the gcd of the product of all the
+// differences with n
+func stage2(c *ecc.MontgomeryXZ, q ecc.XZPoint, b1, b2 uint64, done <-chan struct{}) *big.Int {
+	// baby steps j Q for the odd j < D/2, S_(j+2) = S_j + 2Q, with difference
+	// S_(j-2)
+	baby := make([]ecc.XZPoint, stage2D/2)
+	q2 := c.Double(q)
+	prev, cur := q, q
+	baby[1] = q
+	for j := 3; j < stage2D/2; j += 2 {
+		var next ecc.XZPoint
+		if j == 3 {
+			next = c.Add(q2, q, q)
+		} else {
+			next = c.Add(cur, q2, prev)
+		}
+		prev, cur = cur, next
+		if gcdInt(j, stage2D) == 1 {
+			baby[j] = next
+		}
+	}
+
+	d := big.NewInt(int64(stage2D))
+	r := c.Ladder(q, d, d.BitLen())
+	// T_m and T_(m+1), where T_0 is the point at infinity, set at the first
+	// prime
+	var t, tNext ecc.XZPoint
+	m := int64(-1)
+	acc := *c.F.One()
+	var diff, tmp field.Element
+	i := 0
+	for l := range prime.Primes(b1+1, b2+1) {
+		if i%checkEvery == 0 && isDone(done) {
+			return nil
+		}
+		i++
+		lm := (int64(l) + stage2D/2) / stage2D
+		if m < 0 {
+			m = lm
+			if m == 0 {
+				t, tNext = c.Infinity(), r
+			} else {
+				k := new(big.Int).Mul(d, big.NewInt(m))
+				t = c.Ladder(q, k, k.BitLen())
+				k.Add(k, d)
+				tNext = c.Ladder(q, k, k.BitLen())
+			}
+		}
+		for m < lm {
+			if m == 0 {
+				t, tNext = tNext, c.Double(r)
+			} else {
+				t, tNext = tNext, c.Add(tNext, r, t)
+			}
+			m++
+		}
+		j := int64(l) - m*stage2D
+		if j < 0 {
+			j = -j
+		}
+		s := &baby[j]
+		diff.Mul(&t.X, &s.Z)
+		diff.Sub(&diff, tmp.Mul(&s.X, &t.Z))
+		acc.Mul(&acc, &diff)
+	}
+	return new(big.Int).GCD(nil, nil, acc.BigInt(), c.F.P)
+}
+
+// isFactor returns true if 1 < f < n
+func isFactor(f, n *big.Int) bool {
+	return f.Cmp(bigOne) > 0 && f.Cmp(n) < 0
+}
+
+// isDone returns true if done is closed
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// gcdInt returns the greatest common divisor of a and b
+func gcdInt(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Factor returns the prime factors of n in ascending order, each one repeated
+// as many times as it divides n. After trial division by the small primes, it
+// runs ECM with the parameters of ParamsFor for increasing factor sizes, up to
+// 35 digits, returning an error with the factors found so far, the last one
+// composite, when a factor is not found
+func Factor(n *big.Int, workers int) ([]*big.Int, error) {
+	if n.Sign() <= 0 {
+		return nil, errors.New("n must be positive")
+	}
+	var factors []*big.Int
+	m := new(big.Int).Set(n)
+	r := new(big.Int)
+	for _, p := range prime.SieveOfEratosthenes(trialPrimes) {
+		bp := big.NewInt(int64(p))
+		for {
+			q, rem := new(big.Int).QuoRem(m, bp, r)
+			if rem.Sign() != 0 {
+				break
+			}
+			factors = append(factors, bp)
+			m = q
+		}
+	}
+	rest, err := factorECM(m, workers)
+	factors = append(factors, rest...)
+	sort.Slice(factors, func(i, j int) bool {
+		return factors[i].Cmp(factors[j]) < 0
+	})
+	return factors, err
+}
+
+// factorECM returns the prime factors of n, which has no small factors
+func factorECM(n *big.Int, workers int) ([]*big.Int, error) {
+	if n.Cmp(bigOne) == 0 {
+		return nil, nil
+	}
+	if n.ProbablyPrime(20) {
+		return []*big.Int{n}, nil
+	}
+	// ECM does not split p^2 when the curve is smooth modulo p and p^2
+	if s := new(big.Int).Sqrt(n); new(big.Int).Mul(s, s).Cmp(n) == 0 {
+		f, err := factorECM(s, workers)
+		return append(f, f...), err
+	}
+	for _, l := range levels {
+		params := l.params
+		params.Workers = workers
+		f, _, err := FindFactor(n, params)
+		if err != nil {
+			continue
+		}
+		f1, err := factorECM(f, workers)
+		if err != nil {
+			return append(f1, new(big.Int).Quo(n, f)), err
+		}
+		f2, err := factorECM(new(big.Int).Quo(n, f), workers)
+		return append(f1, f2...), err
+	}
+	return []*big.Int{n}, errors.New("no factor of " + n.String() + " found with ECM")
+}
@@ -0,0 +1,173 @@
+package ecm
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/paillier"
+	"github.com/arnaucube/cryptofun/prime"
+	"github.com/arnaucube/cryptofun/rsa"
+	"github.com/stretchr/testify/assert"
+)
+
+// randPrime returns a random prime of the given bit length
+func randPrime(t *testing.T, bits int) *big.Int {
+	p, err := rand.Prime(rand.Reader, bits)
+	assert.Nil(t, err)
+	return p
+}
+
+func TestFindFactor(t *testing.T) {
+	for _, bits := range []int{24, 36, 48} {
+		p := randPrime(t, bits)
+		q := randPrime(t, bits)
+		n := new(big.Int).Mul(p, q)
+		f, w, err := FindFactor(n, ParamsFor(20))
+		assert.Nil(t, err, n.String())
+		if err != nil {
+			continue
+		}
+		assert.True(t, f.Cmp(p) == 0 || f.Cmp(q) == 0, n.String())
+		t.Log(bits, "bits:", w)
+	}
+
+	// the work depends on the smallest factor: a 40 bit factor of a 552 bit n,
+	// and of a 680 bit n, bigger than field.MaxBits
+	for _, bits := range []int{512, 640} {
+		p := randPrime(t, 40)
+		n := new(big.Int).Mul(p, randPrime(t, bits))
+		f, w, err := FindFactor(n, ParamsFor(20))
+		assert.Nil(t, err)
+		assert.Equal(t, p.String(), f.String())
+		t.Log(w)
+	}
+}
+
+func TestFindFactorErrors(t *testing.T) {
+	_, _, err := FindFactor(big.NewInt(int64(3)), ParamsFor(15))
+	assert.NotNil(t, err)
+	_, _, err = FindFactor(randPrime(t, 64), ParamsFor(15))
+	assert.NotNil(t, err)
+	n := new(big.Int).Mul(randPrime(t, 32), randPrime(t, 32))
+	_, _, err = FindFactor(n, Params{B1: 7, B2: 100, Curves: 1})
+	assert.NotNil(t, err)
+	// two 128 bit factors are out of reach of a few small curves
+	n = new(big.Int).Mul(randPrime(t, 128), randPrime(t, 128))
+	_, w, err := FindFactor(n, Params{B1: 100, B2: 1000, Curves: 4, Workers: 2})
+	assert.NotNil(t, err)
+	assert.Equal(t, uint64(4), w.Curves)
+
+	f, _, err := FindFactor(big.NewInt(int64(1000)), ParamsFor(15))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), f.Int64())
+}
+
+func TestCurve(t *testing.T) {
+	params := ParamsFor(15)
+	for i := 0; i < 5; i++ {
+		n := new(big.Int).Mul(randPrime(t, 28), randPrime(t, 28))
+		f, w, err := FindFactor(n, params)
+		assert.Nil(t, err)
+		if err != nil || w.Stage == 0 {
+			continue
+		}
+		// the order of the point over F_f is B1-smooth, except for one
+		// prime up to B2 when the factor is found in stage 2
+		m, p, err := Curve(w.Sigma, f)
+		assert.Nil(t, err)
+		ec := m.ToWeierstrass()
+		g, err := m.PointToWeierstrass(p)
+		assert.Nil(t, err)
+		order, err := ec.Order(g)
+		assert.Nil(t, err)
+		factors := prime.Factor(order)
+		largest := factors[len(factors)-1]
+		if w.Stage == 1 {
+			assert.True(t, largest.Uint64() <= params.B1, "%s: %v", order, factors)
+		} else {
+			assert.True(t, largest.Uint64() <= params.B2, "%s: %v", order, factors)
+			assert.True(t, len(factors) == 1 || factors[len(factors)-2].Uint64() <= params.B1)
+		}
+		// the order of the curves of Suyama's parametrization is a
+		// multiple of 12
+		card, err := ec.Cardinality()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), new(big.Int).Mod(card, big.NewInt(int64(12))).Int64())
+	}
+
+	_, _, err := Curve(big.NewInt(int64(7)), big.NewInt(int64(15)))
+	assert.NotNil(t, err)
+}
+
+func TestFactor(t *testing.T) {
+	p := randPrime(t, 40)
+	q := randPrime(t, 48)
+	r := randPrime(t, 36)
+	// 2^3 3 p^2 q r
+	n := big.NewInt(int64(24))
+	for _, f := range []*big.Int{p, p, q, r} {
+		n.Mul(n, f)
+	}
+	factors, err := Factor(n, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(factors))
+	m := big.NewInt(int64(1))
+	for i, f := range factors {
+		assert.True(t, f.ProbablyPrime(20))
+		if i > 0 {
+			assert.True(t, factors[i-1].Cmp(f) <= 0)
+		}
+		m.Mul(m, f)
+	}
+	assert.Equal(t, n.String(), m.String())
+
+	factors, err = Factor(big.NewInt(int64(1)), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(factors))
+	_, err = Factor(big.NewInt(int64(0)), 0)
+	assert.NotNil(t, err)
+}
+
+func TestRSAPrivK(t *testing.T) {
+	// an undersized RSA key, with a 96 bit modulus
+	var pubK rsa.PublicKey
+	var p, q *big.Int
+	e := big.NewInt(int64(65537))
+	for {
+		p = randPrime(t, 48)
+		q = randPrime(t, 48)
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, bigOne), new(big.Int).Sub(q, bigOne))
+		if p.Cmp(q) != 0 && new(big.Int).GCD(nil, nil, e, phi).Cmp(bigOne) == 0 {
+			break
+		}
+	}
+	pubK = rsa.PublicKey{E: e, N: new(big.Int).Mul(p, q)}
+
+	privK, err := RSAPrivK(pubK, 0)
+	assert.Nil(t, err)
+	m := big.NewInt(int64(123456789))
+	c := rsa.Encrypt(m, pubK)
+	assert.Equal(t, m.String(), rsa.Decrypt(c, privK).String())
+	// the recovered key signs
+	sig := rsa.BlindSign(m, privK)
+	assert.True(t, rsa.Verify(m, sig, pubK))
+
+	_, err = RSAPrivK(rsa.PublicKey{E: e, N: randPrime(t, 64)}, 0)
+	assert.NotNil(t, err)
+}
+
+func TestPaillierPrivK(t *testing.T) {
+	key, err := paillier.GenerateKeyPair()
+	for err != nil {
+		key, err = paillier.GenerateKeyPair()
+	}
+	privK, err := PaillierPrivK(key.PubK, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, key.PrivK.Lambda.String(), privK.Lambda.String())
+	assert.Equal(t, key.PrivK.Mu.String(), privK.Mu.String())
+
+	// the recovered key decrypts as the original one
+	c := paillier.Encrypt(big.NewInt(int64(42)), key.PubK)
+	assert.Equal(t, paillier.Decrypt(c, key.PubK, key.PrivK).String(), paillier.Decrypt(c, key.PubK, privK).String())
+}
@@ -0,0 +1,59 @@
+package ecm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/cryptofun/paillier"
+	"github.com/arnaucube/cryptofun/rsa"
+)
+
+// factorModulus returns the two prime factors p <= q of the modulus n = p q
+func factorModulus(n *big.Int, workers int) (*big.Int, *big.Int, error) {
+	factors, err := Factor(n, workers)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(factors) != 2 {
+		return nil, nil, errors.New("the modulus is not the product of two primes")
+	}
+	return factors[0], factors[1], nil
+}
+
+// RSAPrivK factors the modulus of the RSA public key and returns the private
+// key, d = e^-1 mod (p - 1)(q - 1)
+func RSAPrivK(pubK rsa.PublicKey, workers int) (rsa.PrivateKey, error) {
+	p, q, err := factorModulus(pubK.N, workers)
+	if err != nil {
+		return rsa.PrivateKey{}, err
+	}
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, bigOne), new(big.Int).Sub(q, bigOne))
+	d := new(big.Int).ModInverse(pubK.E, phi)
+	if d == nil {
+		return rsa.PrivateKey{}, errors.New("e is not invertible mod phi(n)")
+	}
+	return rsa.PrivateKey{D: d, N: new(big.Int).Set(pubK.N)}, nil
+}
+
+// PaillierPrivK factors the modulus of the Paillier public key and returns the
+// private key, lambda = lcm(p - 1, q - 1) and mu = L(g^lambda mod n^2)^-1 mod n
+func PaillierPrivK(pubK paillier.PublicKey, workers int) (paillier.PrivateKey, error) {
+	p, q, err := factorModulus(pubK.N, workers)
+	if err != nil {
+		return paillier.PrivateKey{}, err
+	}
+	p1 := new(big.Int).Sub(p, bigOne)
+	q1 := new(big.Int).Sub(q, bigOne)
+	lambda := new(big.Int).Mul(p1, q1)
+	lambda.Quo(lambda, new(big.Int).GCD(nil, nil, p1, q1))
+	n2 := new(big.Int).Mul(pubK.N, pubK.N)
+	u := new(big.Int).Exp(pubK.G, lambda, n2)
+	// L(u) = (u - 1) / n
+	u.Sub(u, bigOne)
+	u.Quo(u, pubK.N)
+	mu := new(big.Int).ModInverse(u, pubK.N)
+	if mu == nil {
+		return paillier.PrivateKey{}, errors.New("invalid g: L(g^lambda) is not invertible mod n")
+	}
+	return paillier.PrivateKey{Lambda: lambda, Mu: mu}, nil
+}
@@ -1,66 +1,119 @@
 package elgamal
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
 )
 
-// EG is the ElGamal data structure
+// EG is the ElGamal data structure. It works over any group.Group, where the
+// messages are elements of the group
 type EG struct {
-	EC ecc.EC
-	G  ecc.Point
-	N  *big.Int
+	Group group.Group
+	G     group.Element
+	N     *big.Int
+	// gTable is the precomputed table for the multiplications of G, built by
+	// the constructors
+	gTable group.FixedBase
 }
 
 // NewEG defines a new EG data structure
 func NewEG(ec ecc.EC, g ecc.Point) (EG, error) {
-	var eg EG
-	var err error
-	eg.EC = ec
-	eg.G = g
-	eg.N, err = ec.Order(g)
-	return eg, err
+	n, err := ec.Order(g)
+	if err != nil {
+		return EG{}, err
+	}
+	return NewEGFromGroup(ecc.Curve{EC: ec, G: g, N: n}), nil
+}
+
+// NewEGFromCurve defines a new EG data structure over a named curve, using the
+// known order of its generator
+func NewEGFromCurve(c ecc.Curve) EG {
+	return NewEGFromGroup(c)
+}
+
+// NewEGFromGroup defines a new EG data structure over the given group
+func NewEGFromGroup(g group.Group) EG {
+	eg := EG{
+		Group: g,
+		G:     g.Generator(),
+		N:     g.Order(),
+	}
+	// when the table can not be built, mulG uses Group.Mul
+	if gTable, err := group.NewFixedBase(g, eg.G); err == nil {
+		eg.gTable = gTable
+	}
+	return eg
+}
+
+// mulG returns k x G, using the precomputed table of G when available
+func (eg EG) mulG(k *big.Int) (group.Element, error) {
+	if eg.gTable == nil {
+		return eg.Group.Mul(eg.G, k)
+	}
+	return eg.gTable.Mul(k)
 }
 
-// PubK returns the public key Point calculated from the private key over the elliptic curve
-func (eg EG) PubK(privK *big.Int) (ecc.Point, error) {
-	// privK: rand < ec.Q
-	privKCopy := new(big.Int).SetBytes(privK.Bytes())
-	pubK, err := eg.EC.Mul(eg.G, privKCopy)
+// PubK returns the public key element calculated from the private key
+func (eg EG) PubK(privK *big.Int) (group.Element, error) {
+	// privK: rand < eg.N
+	pubK, err := eg.mulG(privK)
 	return pubK, err
 }
 
-// Encrypt encrypts a point m with the public key point, returns two points
-func (eg EG) Encrypt(m ecc.Point, pubK ecc.Point, r *big.Int) ([2]ecc.Point, error) {
-	rCopy := new(big.Int).SetBytes(r.Bytes())
-	p1, err := eg.EC.Mul(eg.G, rCopy)
+// Encrypt encrypts an element m with the public key, returns two elements. The
+// message m can be any element of the group, also out of the subgroup generated
+// by G, while pubK must be in the subgroup
+func (eg EG) Encrypt(m group.Element, pubK group.Element, r *big.Int) ([2]group.Element, error) {
+	if err := group.ValidateElement(eg.Group, m); err != nil {
+		return [2]group.Element{}, err
+	}
+	if err := eg.Group.Validate(pubK); err != nil {
+		return [2]group.Element{}, err
+	}
+	if eg.Group.Equal(pubK, eg.Group.Identity()) {
+		return [2]group.Element{}, errors.New("invalid public key")
+	}
+	p1, err := eg.mulG(r)
 	if err != nil {
-		return [2]ecc.Point{}, err
+		return [2]group.Element{}, err
 	}
-	rCopy = new(big.Int).SetBytes(r.Bytes())
-	p2, err := eg.EC.Mul(pubK, rCopy)
+	p2, err := eg.Group.Mul(pubK, r)
 	if err != nil {
-		return [2]ecc.Point{}, err
+		return [2]group.Element{}, err
 	}
-	p3, err := eg.EC.Add(m, p2)
+	p3, err := eg.Group.Add(m, p2)
 	if err != nil {
-		return [2]ecc.Point{}, err
+		return [2]group.Element{}, err
 	}
-	c := [2]ecc.Point{p1, p3}
+	c := [2]group.Element{p1, p3}
 	return c, err
 }
 
-// Decrypt decrypts c (two points) with the private key, returns the point decrypted
-func (eg EG) Decrypt(c [2]ecc.Point, privK *big.Int) (ecc.Point, error) {
+// Decrypt decrypts c (two elements) with the private key, returns the element
+// decrypted
+func (eg EG) Decrypt(c [2]group.Element, privK *big.Int) (group.Element, error) {
 	c1 := c[0]
 	c2 := c[1]
-	privKCopy := new(big.Int).SetBytes(privK.Bytes())
-	c1PrivK, err := eg.EC.Mul(c1, privKCopy)
+	// reject c1 out of the group generated by G, that could leak information
+	// about the private key. c2 contains the message, which can be out of the
+	// subgroup
+	if err := eg.Group.Validate(c1); err != nil {
+		return nil, err
+	}
+	if err := group.ValidateElement(eg.Group, c2); err != nil {
+		return nil, err
+	}
+	c1PrivK, err := eg.Group.Mul(c1, privK)
+	if err != nil {
+		return nil, err
+	}
+	c1PrivKNeg, err := eg.Group.Neg(c1PrivK)
 	if err != nil {
-		return ecc.Point{}, err
+		return nil, err
 	}
-	c1PrivKNeg := eg.EC.Neg(c1PrivK)
-	d, err := eg.EC.Add(c2, c1PrivKNeg)
+	d, err := eg.Group.Add(c2, c1PrivKNeg)
 	return d, err
 }
@@ -5,12 +5,13 @@ import (
 	"testing"
 
 	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewEG(t *testing.T) {
 	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(19)))
-	g := ecc.Point{big.NewInt(int64(7)), big.NewInt(int64(11))}
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(11))}
 	eg, err := NewEG(ec, g)
 	assert.Nil(t, err)
 
@@ -18,13 +19,13 @@ func TestNewEG(t *testing.T) {
 	pubK, err := eg.PubK(privK)
 	assert.Nil(t, err)
 
-	if !pubK.Equal(ecc.Point{big.NewInt(int64(13)), big.NewInt(int64(9))}) {
+	if !eg.Group.Equal(pubK, ecc.Point{X: big.NewInt(int64(13)), Y: big.NewInt(int64(9))}) {
 		t.Errorf("pubK!=(13, 9)")
 	}
 }
 func TestEGEncrypt(t *testing.T) {
 	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(19)))
-	g := ecc.Point{big.NewInt(int64(7)), big.NewInt(int64(11))}
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(11))}
 	eg, err := NewEG(ec, g)
 	assert.Nil(t, err)
 
@@ -33,21 +34,21 @@ func TestEGEncrypt(t *testing.T) {
 	assert.Nil(t, err)
 
 	// m: point to encrypt
-	m := ecc.Point{big.NewInt(int64(11)), big.NewInt(int64(12))}
+	m := ecc.Point{X: big.NewInt(int64(11)), Y: big.NewInt(int64(12))}
 	c, err := eg.Encrypt(m, pubK, big.NewInt(int64(15)))
 	assert.Nil(t, err)
 
-	if !c[0].Equal(ecc.Point{big.NewInt(int64(8)), big.NewInt(int64(5))}) {
+	if !eg.Group.Equal(c[0], ecc.Point{X: big.NewInt(int64(8)), Y: big.NewInt(int64(5))}) {
 		t.Errorf("c[0] != (8, 5), encryption failed")
 	}
-	if !c[1].Equal(ecc.Point{big.NewInt(int64(2)), big.NewInt(int64(16))}) {
+	if !eg.Group.Equal(c[1], ecc.Point{X: big.NewInt(int64(2)), Y: big.NewInt(int64(16))}) {
 		t.Errorf("c[1] != (2, 16), encryption failed")
 	}
 }
 
 func TestEGDecrypt(t *testing.T) {
 	ec := ecc.NewEC(big.NewInt(int64(1)), big.NewInt(int64(18)), big.NewInt(int64(19)))
-	g := ecc.Point{big.NewInt(int64(7)), big.NewInt(int64(11))}
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(11))}
 	eg, err := NewEG(ec, g)
 	assert.Nil(t, err)
 
@@ -56,14 +57,132 @@ func TestEGDecrypt(t *testing.T) {
 	assert.Nil(t, err)
 
 	// m: point to encrypt
-	m := ecc.Point{big.NewInt(int64(11)), big.NewInt(int64(12))}
+	m := ecc.Point{X: big.NewInt(int64(11)), Y: big.NewInt(int64(12))}
 	c, err := eg.Encrypt(m, pubK, big.NewInt(int64(15)))
 	assert.Nil(t, err)
 
 	d, err := eg.Decrypt(c, privK)
 	assert.Nil(t, err)
 
-	if !m.Equal(d) {
+	if !eg.Group.Equal(m, d) {
 		t.Errorf("m != d, decrypting failed")
 	}
 }
+
+func TestEGNamedCurve(t *testing.T) {
+	eg := NewEGFromCurve(ecc.P256())
+
+	privK := big.NewInt(int64(123456789))
+	pubK, err := eg.PubK(privK)
+	assert.Nil(t, err)
+
+	// m: point to encrypt
+	m, err := eg.Group.Mul(eg.G, big.NewInt(int64(42)))
+	assert.Nil(t, err)
+	c, err := eg.Encrypt(m, pubK, big.NewInt(int64(987654321)))
+	assert.Nil(t, err)
+
+	d, err := eg.Decrypt(c, privK)
+	assert.Nil(t, err)
+	assert.True(t, eg.Group.Equal(m, d))
+}
+
+func TestEGOverGroups(t *testing.T) {
+	zp, err := group.GenerateZpGroup(512, 160)
+	assert.Nil(t, err)
+	bn, err := group.NewBN128G1()
+	assert.Nil(t, err)
+
+	for _, g := range []group.Group{zp, bn} {
+		eg := NewEGFromGroup(g)
+
+		privK := big.NewInt(int64(123456789))
+		pubK, err := eg.PubK(privK)
+		assert.Nil(t, err)
+
+		m, err := eg.Group.Mul(eg.G, big.NewInt(int64(42)))
+		assert.Nil(t, err)
+		c, err := eg.Encrypt(m, pubK, big.NewInt(int64(987654321)))
+		assert.Nil(t, err)
+
+		d, err := eg.Decrypt(c, privK)
+		assert.Nil(t, err)
+		assert.True(t, eg.Group.Equal(m, d))
+	}
+}
+
+func TestEGDecryptInvalidPoint(t *testing.T) {
+	eg := NewEGFromCurve(ecc.P256())
+	privK := big.NewInt(int64(123456789))
+	pubK, err := eg.PubK(privK)
+	assert.Nil(t, err)
+	m, err := eg.Group.Mul(eg.G, big.NewInt(int64(42)))
+	assert.Nil(t, err)
+	c, err := eg.Encrypt(m, pubK, big.NewInt(int64(987654321)))
+	assert.Nil(t, err)
+
+	// point out of the curve, as used in invalid curve attacks
+	offCurve := ecc.Point{X: big.NewInt(int64(1)), Y: big.NewInt(int64(1))}
+	_, err = eg.Decrypt([2]group.Element{offCurve, c[1]}, privK)
+	assert.NotNil(t, err)
+	_, err = eg.Decrypt([2]group.Element{c[0], offCurve}, privK)
+	assert.NotNil(t, err)
+}
+
+func TestEGMessageOutOfSubgroup(t *testing.T) {
+	// y^2 = x^3 + 7 mod 29 has 30 points, G generates the subgroup of order 5
+	c := ecc.Curve{
+		EC: ecc.NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(29))),
+		G:  ecc.Point{X: big.NewInt(int64(6)), Y: big.NewInt(int64(7))},
+		N:  big.NewInt(int64(5)),
+		H:  big.NewInt(int64(6)),
+	}
+	eg := NewEGFromCurve(c)
+	privK := big.NewInt(int64(3))
+	pubK, err := eg.PubK(privK)
+	assert.Nil(t, err)
+
+	// m has order 3, so it is not in the subgroup of G
+	m := ecc.Point{X: big.NewInt(int64(0)), Y: big.NewInt(int64(6))}
+	ct, err := eg.Encrypt(m, pubK, big.NewInt(int64(2)))
+	assert.Nil(t, err)
+	d, err := eg.Decrypt(ct, privK)
+	assert.Nil(t, err)
+	assert.True(t, eg.Group.Equal(m, d))
+
+	// c1 must be in the subgroup
+	_, err = eg.Decrypt([2]group.Element{m, ct[1]}, privK)
+	assert.NotNil(t, err)
+
+	// the message must be on the curve and the public key in the subgroup
+	offCurve := ecc.Point{X: big.NewInt(int64(1)), Y: big.NewInt(int64(1))}
+	_, err = eg.Encrypt(offCurve, pubK, big.NewInt(int64(2)))
+	assert.NotNil(t, err)
+	_, err = eg.Encrypt(m, m, big.NewInt(int64(2)))
+	assert.NotNil(t, err)
+	_, err = eg.Encrypt(m, ecc.ZeroPoint, big.NewInt(int64(2)))
+	assert.NotNil(t, err)
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	eg := NewEGFromCurve(ecc.P256())
+	pubK, _ := eg.PubK(new(big.Int).Sub(eg.N, big.NewInt(int64(12345))))
+	m, _ := eg.Group.Mul(eg.G, big.NewInt(int64(42)))
+	r := new(big.Int).Sub(eg.N, big.NewInt(int64(67890)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = eg.Encrypt(m, pubK, r)
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	eg := NewEGFromCurve(ecc.P256())
+	privK := new(big.Int).Sub(eg.N, big.NewInt(int64(12345)))
+	pubK, _ := eg.PubK(privK)
+	m, _ := eg.Group.Mul(eg.G, big.NewInt(int64(42)))
+	c, _ := eg.Encrypt(m, pubK, new(big.Int).Sub(eg.N, big.NewInt(int64(67890))))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = eg.Decrypt(c, privK)
+	}
+}
@@ -0,0 +1,468 @@
+package field
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// MaxBits is the maximum size in bits of the modulus of a Field
+const MaxBits = maxLimbs * 64
+
+// maxLimbs is the number of 64 bit words of an Element, enough for P-521
+const maxLimbs = 9
+
+// Field is the prime field F_p, containing the constants of the Montgomery
+// representation with R = 2^(64 n), where n is the number of 64 bit words of p.
+// The fields of NewBigField store the elements as big.Int values instead
+type Field struct {
+	P *big.Int
+
+	// isBig is set by NewBigField, the elements are stored in Element.b
+	isBig bool
+
+	n    int              // number of words of p
+	p    [maxLimbs]uint64 // p in little endian words
+	pInv uint64           // -p^-1 mod 2^64
+	r2   [maxLimbs]uint64 // R^2 mod p
+	r3   [maxLimbs]uint64 // R^3 mod p
+	one  Element          // R mod p
+	// sqrtExp is (p + 1) / 4 when p = 3 mod 4, nil otherwise
+	sqrtExp *big.Int
+}
+
+// Element is an element of a Field, stored in Montgomery representation
+// x R mod p. The zero value is not usable until it is set by one of the
+// methods, which take the Field from their arguments (as z.Add(x, y))
+type Element struct {
+	f *Field
+	v [maxLimbs]uint64
+	// b is the value in [0, p) of the elements of the fields of NewBigField,
+	// where nil is zero. It is never modified once set, as the copies of the
+	// Element share it
+	b *big.Int
+}
+
+// NewField returns the Field of integers modulo p. p must be an odd prime, only
+// its size and parity are checked
+func NewField(p *big.Int) (*Field, error) {
+	if p.Sign() <= 0 || p.Bit(0) == 0 || p.Cmp(big.NewInt(int64(3))) < 0 {
+		return nil, errors.New("field: the modulus must be an odd prime")
+	}
+	if p.BitLen() > MaxBits {
+		return nil, errors.New("field: the modulus is too big")
+	}
+	f := &Field{P: new(big.Int).Set(p)}
+	f.n = (p.BitLen() + 63) / 64
+	f.p = toWords(p, f.n)
+
+	// -p^-1 mod 2^64 with Newton iterations: each doubles the correct bits
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - f.p[0]*inv
+	}
+	f.pInv = -inv
+
+	r := new(big.Int).Lsh(big.NewInt(int64(1)), uint(64*f.n))
+	r2 := new(big.Int).Mul(r, r)
+	r2.Mod(r2, p)
+	f.r2 = toWords(r2, f.n)
+	r3 := new(big.Int).Mul(r2, r)
+	f.r3 = toWords(r3.Mod(r3, p), f.n)
+	f.one = Element{f: f, v: toWords(new(big.Int).Mod(r, p), f.n)}
+	if p.Bit(1) == 1 {
+		f.sqrtExp = new(big.Int).Add(p, big.NewInt(int64(1)))
+		f.sqrtExp.Rsh(f.sqrtExp, 2)
+	}
+	return f, nil
+}
+
+// NewBigField returns the Field of integers modulo p with the elements stored
+// as big.Int values, for the moduli bigger than MaxBits that NewField rejects.
+// It is slower than the Montgomery representation, and its operations are not
+// constant time. p must be an odd prime, only its parity is checked
+func NewBigField(p *big.Int) (*Field, error) {
+	if p.Sign() <= 0 || p.Bit(0) == 0 || p.Cmp(big.NewInt(int64(3))) < 0 {
+		return nil, errors.New("field: the modulus must be an odd prime")
+	}
+	f := &Field{P: new(big.Int).Set(p), isBig: true}
+	f.one = Element{f: f, b: big.NewInt(int64(1))}
+	if p.Bit(1) == 1 {
+		f.sqrtExp = new(big.Int).Add(p, big.NewInt(int64(1)))
+		f.sqrtExp.Rsh(f.sqrtExp, 2)
+	}
+	return f, nil
+}
+
+// setBig sets z = x mod p, in the Field f of NewBigField, and returns z. x is
+// not modified when it is already reduced, so it must not be modified later
+func (z *Element) setBig(f *Field, x *big.Int) *Element {
+	z.f = f
+	if x.Sign() < 0 || x.Cmp(f.P) >= 0 {
+		x = new(big.Int).Mod(x, f.P)
+	}
+	z.b = x
+	return z
+}
+
+// bigValue returns the value of an element of a Field of NewBigField
+func (z *Element) bigValue() *big.Int {
+	if z.b == nil {
+		return new(big.Int)
+	}
+	return z.b
+}
+
+// toWords returns the little endian 64 bit words of x, which must be in [0, 2^(64 n))
+func toWords(x *big.Int, n int) [maxLimbs]uint64 {
+	var b [maxLimbs * 8]byte
+	x.FillBytes(b[:8*n])
+	var w [maxLimbs]uint64
+	for i := 0; i < n; i++ {
+		w[i] = binary.BigEndian.Uint64(b[8*(n-1-i):])
+	}
+	return w
+}
+
+// fromWords returns the integer of the little endian 64 bit words
+func fromWords(w *[maxLimbs]uint64, n int) *big.Int {
+	var b [maxLimbs * 8]byte
+	for i := 0; i < n; i++ {
+		binary.BigEndian.PutUint64(b[8*(n-1-i):], w[i])
+	}
+	return new(big.Int).SetBytes(b[:8*n])
+}
+
+// NewElement returns the element x mod p
+func (f *Field) NewElement(x *big.Int) *Element {
+	return new(Element).SetBigInt(f, x)
+}
+
+// Zero returns the element 0
+func (f *Field) Zero() *Element {
+	return &Element{f: f}
+}
+
+// One returns the element 1
+func (f *Field) One() *Element {
+	e := f.one
+	return &e
+}
+
+// Field returns the Field of the element
+func (z *Element) Field() *Field {
+	return z.f
+}
+
+// SetBigInt sets z = x mod p, in the Field f, and returns z
+func (z *Element) SetBigInt(f *Field, x *big.Int) *Element {
+	xr := new(big.Int).Mod(x, f.P)
+	if f.isBig {
+		return z.setBig(f, xr)
+	}
+	w := toWords(xr, f.n)
+	z.f = f
+	f.mul(&z.v, &w, &f.r2)
+	return z
+}
+
+// BigInt returns the value of z as an integer in [0, p)
+func (z *Element) BigInt() *big.Int {
+	if z.f.isBig {
+		return new(big.Int).Set(z.bigValue())
+	}
+	var one [maxLimbs]uint64
+	one[0] = 1
+	var r [maxLimbs]uint64
+	z.f.mul(&r, &z.v, &one)
+	return fromWords(&r, z.f.n)
+}
+
+// String returns the decimal representation of z
+func (z *Element) String() string {
+	return z.BigInt().String()
+}
+
+// Set sets z = x and returns z
+func (z *Element) Set(x *Element) *Element {
+	*z = *x
+	return z
+}
+
+// SetZero sets z = 0 and returns z
+func (z *Element) SetZero() *Element {
+	z.v = [maxLimbs]uint64{}
+	z.b = nil
+	return z
+}
+
+// SetOne sets z = 1 and returns z
+func (z *Element) SetOne() *Element {
+	z.v = z.f.one.v
+	z.b = z.f.one.b
+	return z
+}
+
+// IsZero returns true if z = 0
+func (z *Element) IsZero() bool {
+	if z.b != nil {
+		return z.b.Sign() == 0
+	}
+	return z.v == [maxLimbs]uint64{}
+}
+
+// IsOne returns true if z = 1
+func (z *Element) IsOne() bool {
+	if z.f.isBig {
+		return z.bigValue().Cmp(z.f.one.b) == 0
+	}
+	return z.v == z.f.one.v
+}
+
+// Equal returns true if z = x
+func (z *Element) Equal(x *Element) bool {
+	if z.f.isBig {
+		return z.bigValue().Cmp(x.bigValue()) == 0
+	}
+	return z.v == x.v
+}
+
+// Add sets z = x + y and returns z
+func (z *Element) Add(x, y *Element) *Element {
+	f := x.f
+	if f.isBig {
+		return z.setBig(f, new(big.Int).Add(x.bigValue(), y.bigValue()))
+	}
+	z.f = f
+	var carry uint64
+	for i := 0; i < f.n; i++ {
+		z.v[i], carry = bits.Add64(x.v[i], y.v[i], carry)
+	}
+	f.reduceOnce(&z.v, carry)
+	return z
+}
+
+// Double sets z = 2 x and returns z
+func (z *Element) Double(x *Element) *Element {
+	return z.Add(x, x)
+}
+
+// Sub sets z = x - y and returns z
+func (z *Element) Sub(x, y *Element) *Element {
+	f := x.f
+	if f.isBig {
+		return z.setBig(f, new(big.Int).Sub(x.bigValue(), y.bigValue()))
+	}
+	z.f = f
+	var borrow uint64
+	for i := 0; i < f.n; i++ {
+		z.v[i], borrow = bits.Sub64(x.v[i], y.v[i], borrow)
+	}
+	// add p if there is borrow, without branches
+	mask := -borrow
+	var carry uint64
+	for i := 0; i < f.n; i++ {
+		z.v[i], carry = bits.Add64(z.v[i], f.p[i]&mask, carry)
+	}
+	return z
+}
+
+// Neg sets z = -x and returns z
+func (z *Element) Neg(x *Element) *Element {
+	if x.IsZero() {
+		z.f = x.f
+		return z.SetZero()
+	}
+	if x.f.isBig {
+		return z.setBig(x.f, new(big.Int).Sub(x.f.P, x.b))
+	}
+	return z.Sub(&Element{f: x.f, v: x.f.p}, x)
+}
+
+// Mul sets z = x y and returns z
+func (z *Element) Mul(x, y *Element) *Element {
+	if x.f.isBig {
+		return z.setBig(x.f, new(big.Int).Mul(x.bigValue(), y.bigValue()))
+	}
+	z.f = x.f
+	x.f.mul(&z.v, &x.v, &y.v)
+	return z
+}
+
+// Square sets z = x^2 and returns z
+func (z *Element) Square(x *Element) *Element {
+	return z.Mul(x, x)
+}
+
+// Exp sets z = x^e and returns z. For negative e it is the inverse of x^|e|,
+// and z is not modified and nil is returned if x = 0
+func (z *Element) Exp(x *Element, e *big.Int) *Element {
+	b := *x
+	if e.Sign() < 0 {
+		if b.Inverse(x) == nil {
+			return nil
+		}
+		// the bits of a negative big.Int are the ones of its two's complement
+		e = new(big.Int).Abs(e)
+	}
+	r := b.f.one
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r.Mul(&r, &r)
+		if e.Bit(i) == 1 {
+			r.Mul(&r, &b)
+		}
+	}
+	*z = r
+	return z
+}
+
+// CondSwap swaps x and y if b = 1, and leaves them unchanged if b = 0, without
+// branches on b (except for the fields of NewBigField). x and y must be of the
+// same Field
+func CondSwap(x, y *Element, b uint64) {
+	if x.f.isBig {
+		if b == 1 {
+			x.b, y.b = y.b, x.b
+		}
+		return
+	}
+	mask := -b
+	for i := range x.v {
+		t := (x.v[i] ^ y.v[i]) & mask
+		x.v[i] ^= t
+		y.v[i] ^= t
+	}
+}
+
+// Inverse sets z = 1/x and returns z. If x = 0, z is not modified and nil is
+// returned
+func (z *Element) Inverse(x *Element) *Element {
+	if x.IsZero() {
+		return nil
+	}
+	f := x.f
+	if f.isBig {
+		return z.setBig(f, new(big.Int).ModInverse(x.b, f.P))
+	}
+	// (x R)^-1 = x^-1 R^-1, and the Montgomery product with R^3 gives x^-1 R
+	a := fromWords(&x.v, f.n)
+	a.ModInverse(a, f.P)
+	w := toWords(a, f.n)
+	z.f = f
+	f.mul(&z.v, &w, &f.r3)
+	return z
+}
+
+// Sqrt sets z to a square root of x and returns z. If x is not a square, z is
+// not modified and nil is returned
+func (z *Element) Sqrt(x *Element) *Element {
+	f := x.f
+	if f.sqrtExp != nil {
+		// p = 3 mod 4: the root is x^((p+1)/4) when it exists
+		var r, r2 Element
+		r.Exp(x, f.sqrtExp)
+		if !r2.Square(&r).Equal(x) {
+			return nil
+		}
+		return z.Set(&r)
+	}
+	r := new(big.Int).ModSqrt(x.BigInt(), f.P)
+	if r == nil {
+		return nil
+	}
+	return z.SetBigInt(f, r)
+}
+
+// Legendre returns the Legendre symbol of z: 1 if it is a non zero square, -1
+// if it is not a square and 0 if it is zero
+func (z *Element) Legendre() int {
+	return big.Jacobi(z.BigInt(), z.f.P)
+}
+
+// BatchInverse inverts all the elements in place with Montgomery's trick, which
+// uses a single field inversion and 3 (n-1) multiplications. If any of the
+// elements is zero, an error is returned and the elements are not modified
+func BatchInverse(elems []*Element) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	// prefix[i] = elems[0] * ... * elems[i-1]
+	prefix := make([]Element, len(elems))
+	acc := elems[0].f.one
+	for i, e := range elems {
+		if e.IsZero() {
+			return errors.New("field: batch inverse of zero")
+		}
+		prefix[i] = acc
+		acc.Mul(&acc, e)
+	}
+	// acc = (elems[0] * ... * elems[n-1])^-1
+	acc.Inverse(&acc)
+	for i := len(elems) - 1; i >= 0; i-- {
+		var inv Element
+		inv.Mul(&acc, &prefix[i])
+		acc.Mul(&acc, elems[i])
+		*elems[i] = inv
+	}
+	return nil
+}
+
+// reduceOnce subtracts p from the n words of z (plus the carry word) if the
+// result is not negative, for values of z lower than 2p. The result is selected
+// with a mask, without branches
+func (f *Field) reduceOnce(z *[maxLimbs]uint64, carry uint64) {
+	var d [maxLimbs]uint64
+	var borrow uint64
+	for i := 0; i < f.n; i++ {
+		d[i], borrow = bits.Sub64(z[i], f.p[i], borrow)
+	}
+	// mask is all ones if carry != 0 or borrow == 0
+	mask := -(carry | (borrow ^ 1))
+	for i := 0; i < f.n; i++ {
+		z[i] = d[i]&mask | z[i]&^mask
+	}
+}
+
+// mul sets z = x y R^-1 mod p, with the Coarsely Integrated Operand Scanning
+// (CIOS) method
+func (f *Field) mul(z, x, y *[maxLimbs]uint64) {
+	n := f.n
+	var t [maxLimbs + 2]uint64
+	for i := 0; i < n; i++ {
+		// t += x * y[i]
+		var c uint64
+		for j := 0; j < n; j++ {
+			hi, lo := bits.Mul64(x[j], y[i])
+			var cc uint64
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j], c = lo, hi
+		}
+		var cc uint64
+		t[n], cc = bits.Add64(t[n], c, 0)
+		t[n+1] = cc
+
+		// t = (t + m p) / 2^64, with m such that the lowest word is zero
+		m := t[0] * f.pInv
+		hi, lo := bits.Mul64(m, f.p[0])
+		_, cc = bits.Add64(lo, t[0], 0)
+		c = hi + cc
+		for j := 1; j < n; j++ {
+			hi, lo = bits.Mul64(m, f.p[j])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j-1], c = lo, hi
+		}
+		t[n-1], cc = bits.Add64(t[n], c, 0)
+		t[n] = t[n+1] + cc
+	}
+	var r [maxLimbs]uint64
+	copy(r[:n], t[:n])
+	f.reduceOnce(&r, t[n])
+	*z = r
+}
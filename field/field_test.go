@@ -0,0 +1,211 @@
+package field
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPrimes are moduli of 1 to 9 words, with p = 1 mod 4 and p = 3 mod 4
+var testPrimes = []string{
+	"11",
+	"13",
+	"18446744073709551557", // 2^64 - 59
+	// P-192, P-256 and secp256k1 fields
+	"6277101735386680763835789423207666416083908700390324961279",
+	"115792089210356248762697446949407573530086143415290314195533631308867097853951",
+	"115792089237316195423570985008687907853269984665640564039457584007908834671663",
+	// 2^255 - 19, with p = 5 mod 8
+	"57896044618658097711785492504343953926634992332820282019728792003956564819949",
+	// P-521 field
+	"6864797660130609714981900799081393217269435300143305409394463459185543183397656052122559640661454554977296311391480858037121987999716643812574028291115057151",
+}
+
+// assertBigEqual compares the values of two integers, as the internal
+// representation of the zero big.Int values can differ
+func assertBigEqual(t *testing.T, expected, actual *big.Int) {
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+// bigTestPrime returns 2^640 + 115, a prime bigger than MaxBits
+func bigTestPrime() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(int64(1)), 640)
+	return p.Add(p, big.NewInt(int64(115)))
+}
+
+// testFields returns the fields of the test primes, followed by the fields of
+// NewBigField of the test primes and of bigTestPrime
+func testFields(t *testing.T) []*Field {
+	var fields, bigFields []*Field
+	for _, s := range testPrimes {
+		p, ok := new(big.Int).SetString(s, 10)
+		assert.True(t, ok)
+		assert.True(t, p.ProbablyPrime(20), s)
+		f, err := NewField(p)
+		assert.Nil(t, err)
+		fields = append(fields, f)
+		f, err = NewBigField(p)
+		assert.Nil(t, err)
+		bigFields = append(bigFields, f)
+	}
+	assert.True(t, bigTestPrime().ProbablyPrime(20))
+	f, err := NewBigField(bigTestPrime())
+	assert.Nil(t, err)
+	return append(append(fields, bigFields...), f)
+}
+
+func TestNewField(t *testing.T) {
+	_, err := NewField(big.NewInt(int64(10)))
+	assert.NotNil(t, err)
+	_, err = NewField(big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+	_, err = NewField(new(big.Int).Lsh(big.NewInt(int64(1)), MaxBits+1))
+	assert.NotNil(t, err)
+
+	// the moduli bigger than MaxBits are supported by NewBigField
+	_, err = NewBigField(big.NewInt(int64(10)))
+	assert.NotNil(t, err)
+	_, err = NewBigField(bigTestPrime())
+	assert.Nil(t, err)
+}
+
+func TestArithmetic(t *testing.T) {
+	for _, f := range testFields(t) {
+		p := f.P
+		for i := 0; i < 50; i++ {
+			a, err := rand.Int(rand.Reader, p)
+			assert.Nil(t, err)
+			b, err := rand.Int(rand.Reader, p)
+			assert.Nil(t, err)
+			if i == 0 {
+				// edge values
+				a.Sub(p, big.NewInt(int64(1)))
+				b.Sub(p, big.NewInt(int64(1)))
+			}
+			x, y := f.NewElement(a), f.NewElement(b)
+			assertBigEqual(t, a, x.BigInt())
+
+			var z Element
+			assertBigEqual(t, new(big.Int).Mod(new(big.Int).Add(a, b), p), z.Add(x, y).BigInt())
+			assertBigEqual(t, new(big.Int).Mod(new(big.Int).Sub(a, b), p), z.Sub(x, y).BigInt())
+			assertBigEqual(t, new(big.Int).Mod(new(big.Int).Neg(a), p), z.Neg(x).BigInt())
+			assertBigEqual(t, new(big.Int).Mod(new(big.Int).Mul(a, b), p), z.Mul(x, y).BigInt())
+			assertBigEqual(t, new(big.Int).Mod(new(big.Int).Mul(a, a), p), z.Square(x).BigInt())
+			assertBigEqual(t, new(big.Int).Exp(a, b, p), z.Exp(x, b).BigInt())
+			if a.Sign() != 0 {
+				assertBigEqual(t, new(big.Int).ModInverse(a, p), z.Inverse(x).BigInt())
+				assert.True(t, z.Mul(&z, x).IsOne())
+			}
+
+			// aliasing
+			z.Set(x)
+			z.Mul(&z, &z)
+			assertBigEqual(t, new(big.Int).Mod(new(big.Int).Mul(a, a), p), z.BigInt())
+		}
+		// conversion of values out of [0, p)
+		assert.True(t, f.NewElement(p).IsZero())
+		assert.True(t, f.NewElement(big.NewInt(int64(-1))).Equal(new(Element).Neg(f.One())))
+		assert.Nil(t, new(Element).Inverse(f.Zero()))
+		assert.Nil(t, new(Element).Exp(f.Zero(), big.NewInt(int64(-1))))
+	}
+}
+
+func TestExpNegative(t *testing.T) {
+	p := big.NewInt(int64(1000003))
+	f, err := NewField(p)
+	assert.Nil(t, err)
+	a := big.NewInt(int64(123456))
+	x := f.NewElement(a)
+	aInv := new(big.Int).ModInverse(a, p)
+	for _, e := range []int64{-1, -2, -3, -5, -7, -1000001} {
+		var z Element
+		expected := new(big.Int).Exp(aInv, big.NewInt(-e), p)
+		assertBigEqual(t, expected, z.Exp(x, big.NewInt(e)).BigInt())
+	}
+	// e is not modified
+	e := big.NewInt(int64(-3))
+	new(Element).Exp(x, e)
+	assert.Equal(t, "-3", e.String())
+}
+
+func TestSqrt(t *testing.T) {
+	for _, f := range testFields(t) {
+		squares, nonSquares := 0, 0
+		for i := 0; i < 20; i++ {
+			a, err := rand.Int(rand.Reader, f.P)
+			assert.Nil(t, err)
+			x := f.NewElement(a)
+			var r Element
+			if x.Legendre() < 0 {
+				nonSquares++
+				assert.Nil(t, r.Sqrt(x))
+				continue
+			}
+			squares++
+			assert.NotNil(t, r.Sqrt(x))
+			assert.True(t, r.Square(&r).Equal(x))
+		}
+		if f.P.BitLen() > 8 {
+			assert.True(t, squares > 0 && nonSquares > 0)
+		}
+	}
+}
+
+func TestCondSwap(t *testing.T) {
+	fields := testFields(t)
+	for _, f := range []*Field{fields[4], fields[len(fields)-1]} {
+		x, y := f.NewElement(big.NewInt(int64(12))), f.NewElement(big.NewInt(int64(34)))
+		CondSwap(x, y, 0)
+		assertBigEqual(t, big.NewInt(int64(12)), x.BigInt())
+		assertBigEqual(t, big.NewInt(int64(34)), y.BigInt())
+		CondSwap(x, y, 1)
+		assertBigEqual(t, big.NewInt(int64(34)), x.BigInt())
+		assertBigEqual(t, big.NewInt(int64(12)), y.BigInt())
+	}
+}
+
+func TestBatchInverse(t *testing.T) {
+	for _, f := range testFields(t) {
+		elems := make([]*Element, 10)
+		expected := make([]*big.Int, len(elems))
+		for i := range elems {
+			a, err := rand.Int(rand.Reader, new(big.Int).Sub(f.P, big.NewInt(int64(1))))
+			assert.Nil(t, err)
+			a.Add(a, big.NewInt(int64(1)))
+			elems[i] = f.NewElement(a)
+			expected[i] = new(big.Int).ModInverse(a, f.P)
+		}
+		assert.Nil(t, BatchInverse(elems))
+		for i := range elems {
+			assertBigEqual(t, expected[i], elems[i].BigInt())
+		}
+
+		elems[3] = f.Zero()
+		before := *elems[0]
+		assert.NotNil(t, BatchInverse(elems))
+		assert.True(t, before.Equal(elems[0]))
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	p, _ := new(big.Int).SetString("115792089210356248762697446949407573530086143415290314195533631308867097853951", 10)
+	f, _ := NewField(p)
+	x := f.NewElement(big.NewInt(int64(123456789)))
+	y := f.NewElement(new(big.Int).Sub(p, big.NewInt(int64(2))))
+	b.Run("field", func(b *testing.B) {
+		var z Element
+		for i := 0; i < b.N; i++ {
+			z.Mul(x, y)
+		}
+	})
+	b.Run("big.Int", func(b *testing.B) {
+		xb, yb := x.BigInt(), y.BigInt()
+		z := new(big.Int)
+		for i := 0; i < b.N; i++ {
+			z.Mul(xb, yb)
+			z.Mod(z, p)
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package gf2m
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"math/bits"
+	"strconv"
+)
+
+// Field is the binary field GF(2^m) in polynomial basis: the elements are the
+// polynomials over GF(2) of degree lower than m, represented as *big.Int where
+// the bit i is the coefficient of x^i, and the product is reduced by the
+// irreducible trinomial or pentanomial x^m + x^k... + 1
+type Field struct {
+	M    int
+	Poly *big.Int // reduction polynomial
+
+	exps []int // exponents of the terms of the reduction polynomial below x^m
+	nw   int   // number of 64 bit words of the elements and of the reduction polynomial
+}
+
+// NewField returns the field GF(2^m) with the reduction polynomial
+// x^m + x^k[0] + ... + x^k[len(k)-1] + 1, where k are one (trinomial) or three
+// (pentanomial) exponents in (0, m). The polynomial is expected to be
+// irreducible, which is not checked
+func NewField(m int, k ...int) (*Field, error) {
+	if m < 2 {
+		return nil, errors.New("gf2m: m must be at least 2")
+	}
+	if len(k) != 1 && len(k) != 3 {
+		return nil, errors.New("gf2m: the reduction polynomial must be a trinomial or a pentanomial")
+	}
+	f := &Field{M: m, nw: m/64 + 1}
+	f.Poly = new(big.Int).SetBit(big.NewInt(int64(1)), m, 1)
+	for _, e := range k {
+		if e <= 0 || e >= m || f.Poly.Bit(e) == 1 {
+			return nil, errors.New("gf2m: invalid exponent " + strconv.Itoa(e) + " of the reduction polynomial")
+		}
+		f.Poly.SetBit(f.Poly, e, 1)
+		f.exps = append(f.exps, e)
+	}
+	f.exps = append(f.exps, 0)
+	return f, nil
+}
+
+// Contains returns true if a is an element of the field, a polynomial of degree
+// lower than m
+func (f *Field) Contains(a *big.Int) bool {
+	return a != nil && a.Sign() >= 0 && a.BitLen() <= f.M
+}
+
+// Add returns a + b, which is also a - b
+func (f *Field) Add(a, b *big.Int) *big.Int {
+	return new(big.Int).Xor(a, b)
+}
+
+// Mul returns a b
+func (f *Field) Mul(a, b *big.Int) *big.Int {
+	return f.fromWords(f.reduce(f.mul(f.toWords(a), f.toWords(b))))
+}
+
+// Square returns a^2
+func (f *Field) Square(a *big.Int) *big.Int {
+	return f.fromWords(f.reduce(f.square(f.toWords(a))))
+}
+
+// Exp returns a^e, for e >= 0
+func (f *Field) Exp(a, e *big.Int) *big.Int {
+	aw := f.toWords(a)
+	r := make([]uint64, f.nw)
+	r[0] = 1
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = f.reduce(f.square(r))
+		if e.Bit(i) == 1 {
+			r = f.reduce(f.mul(r, aw))
+		}
+	}
+	return f.fromWords(r)
+}
+
+// Inverse returns 1/a, computed with the extended Euclidean algorithm for
+// polynomials, or an error if a = 0
+func (f *Field) Inverse(a *big.Int) (*big.Int, error) {
+	if a.Sign() == 0 {
+		return nil, errors.New("gf2m: inverse of zero")
+	}
+	// invariants: g1 a = u and g2 a = v mod f
+	u, v := f.toWords(a), words(f.Poly, f.nw)
+	g1, g2 := make([]uint64, f.nw), make([]uint64, f.nw)
+	g1[0] = 1
+	du, dv := degree(u), degree(v)
+	for du != 0 {
+		j := du - dv
+		if j < 0 {
+			u, v = v, u
+			g1, g2 = g2, g1
+			du, dv = dv, du
+			j = -j
+		}
+		xorShifted(u, v, j)
+		xorShifted(g1, g2, j)
+		du = degree(u)
+	}
+	return f.fromWords(g1), nil
+}
+
+// Div returns a / b, or an error if b = 0
+func (f *Field) Div(a, b *big.Int) (*big.Int, error) {
+	bInv, err := f.Inverse(b)
+	if err != nil {
+		return nil, err
+	}
+	return f.Mul(a, bInv), nil
+}
+
+// Sqrt returns the square root of a, a^(2^(m-1)), which always exists
+func (f *Field) Sqrt(a *big.Int) *big.Int {
+	r := f.toWords(a)
+	for i := 0; i < f.M-1; i++ {
+		r = f.reduce(f.square(r))
+	}
+	return f.fromWords(r)
+}
+
+// Trace returns the trace of a, a + a^2 + a^4 + ... + a^(2^(m-1)), which is 0
+// or 1
+func (f *Field) Trace(a *big.Int) uint {
+	aw := f.toWords(a)
+	t := append([]uint64{}, aw...)
+	for i := 1; i < f.M; i++ {
+		aw = f.reduce(f.square(aw))
+		for j := range t {
+			t[j] ^= aw[j]
+		}
+	}
+	return uint(t[0] & 1)
+}
+
+// HalfTrace returns the half trace of a, a + a^4 + a^16 + ... + a^(2^(m-1)),
+// defined for odd m
+func (f *Field) HalfTrace(a *big.Int) *big.Int {
+	aw := f.toWords(a)
+	h := append([]uint64{}, aw...)
+	for i := 1; i <= (f.M-1)/2; i++ {
+		aw = f.reduce(f.square(f.reduce(f.square(aw))))
+		for j := range h {
+			h[j] ^= aw[j]
+		}
+	}
+	return f.fromWords(h)
+}
+
+// SolveQuadratic returns a solution z of z^2 + z = c (the other one is z + 1),
+// or an error if there is none, which happens when the trace of c is 1. Only
+// odd m are supported, where the solution is the half trace of c
+func (f *Field) SolveQuadratic(c *big.Int) (*big.Int, error) {
+	if f.M%2 == 0 {
+		return nil, errors.New("gf2m: quadratic equations are only supported for odd m")
+	}
+	if f.Trace(c) != 0 {
+		return nil, errors.New("gf2m: z^2 + z = c has no solution")
+	}
+	return f.HalfTrace(c), nil
+}
+
+// toWords returns the little endian 64 bit words of a, reduced modulo the
+// reduction polynomial when its degree is m or higher
+func (f *Field) toWords(a *big.Int) []uint64 {
+	n := 2 * f.nw
+	if l := (a.BitLen() + 63) / 64; l > n {
+		n = l
+	}
+	return f.reduce(words(a, n))
+}
+
+// words returns the n little endian 64 bit words of a
+func words(a *big.Int, n int) []uint64 {
+	b := make([]byte, 8*n)
+	a.FillBytes(b)
+	w := make([]uint64, n)
+	for i := range w {
+		w[i] = binary.BigEndian.Uint64(b[8*(n-1-i):])
+	}
+	return w
+}
+
+// fromWords returns the integer of the little endian 64 bit words
+func (f *Field) fromWords(w []uint64) *big.Int {
+	b := make([]byte, 8*len(w))
+	for i := range w {
+		binary.BigEndian.PutUint64(b[8*(len(w)-1-i):], w[i])
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// mul returns the product of the polynomials a and b, without reduction, with
+// 2 nw words
+func (f *Field) mul(a, b []uint64) []uint64 {
+	r := make([]uint64, 2*f.nw)
+	for j := 0; j < f.nw; j++ {
+		for i := 0; i < 64; i++ {
+			if b[j]>>uint(i)&1 == 1 {
+				xorShifted(r, a[:f.nw], 64*j+i)
+			}
+		}
+	}
+	return r
+}
+
+// square returns a^2 without reduction, spreading the bits of a, as the
+// square of a polynomial over GF(2) is the sum of the squares of its terms
+func (f *Field) square(a []uint64) []uint64 {
+	r := make([]uint64, 2*f.nw)
+	for i := 0; i < f.nw; i++ {
+		r[2*i] = spread(uint32(a[i]))
+		r[2*i+1] = spread(uint32(a[i] >> 32))
+	}
+	return r
+}
+
+// spread returns the 64 bit word with the bits of x in the even positions
+func spread(x uint32) uint64 {
+	v := uint64(x)
+	v = (v | v<<16) & 0x0000ffff0000ffff
+	v = (v | v<<8) & 0x00ff00ff00ff00ff
+	v = (v | v<<4) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v<<2) & 0x3333333333333333
+	v = (v | v<<1) & 0x5555555555555555
+	return v
+}
+
+// reduce reduces the polynomial a modulo the reduction polynomial, replacing
+// each term x^i with i >= m by x^(i-m) (x^k... + 1), and returns the nw
+// lower words
+func (f *Field) reduce(a []uint64) []uint64 {
+	for i := 64*len(a) - 1; i >= f.M; i-- {
+		if a[i/64]>>uint(i%64)&1 == 0 {
+			continue
+		}
+		a[i/64] ^= 1 << uint(i%64)
+		for _, e := range f.exps {
+			j := i - f.M + e
+			a[j/64] ^= 1 << uint(j%64)
+		}
+	}
+	return a[:f.nw]
+}
+
+// degree returns the degree of the polynomial a, -1 for the zero polynomial
+func degree(a []uint64) int {
+	for i := len(a) - 1; i >= 0; i-- {
+		if a[i] != 0 {
+			return 64*i + bits.Len64(a[i]) - 1
+		}
+	}
+	return -1
+}
+
+// xorShifted sets r = r + a x^s, the terms beyond the length of r are dropped
+func xorShifted(r, a []uint64, s int) {
+	ws, bs := s/64, uint(s%64)
+	for i := range a {
+		if i+ws >= len(r) {
+			break
+		}
+		r[i+ws] ^= a[i] << bs
+		if bs != 0 && i+ws+1 < len(r) {
+			r[i+ws+1] ^= a[i] >> (64 - bs)
+		}
+	}
+}
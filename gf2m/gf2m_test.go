@@ -0,0 +1,127 @@
+package gf2m
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// naiveMul returns a b mod p with shifts and xors over big.Int
+func naiveMul(a, b, p *big.Int, m int) *big.Int {
+	r := new(big.Int)
+	for i := 0; i < b.BitLen(); i++ {
+		if b.Bit(i) == 1 {
+			r.Xor(r, new(big.Int).Lsh(a, uint(i)))
+		}
+	}
+	for i := r.BitLen() - 1; i >= m; i-- {
+		if r.Bit(i) == 1 {
+			r.Xor(r, new(big.Int).Lsh(p, uint(i-m)))
+		}
+	}
+	return r
+}
+
+// assertBigEqual compares the values of two integers, as the internal
+// representation of the zero big.Int values can differ
+func assertBigEqual(t *testing.T, expected, actual *big.Int) {
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func testFields(t *testing.T) []*Field {
+	var fields []*Field
+	for _, params := range [][]int{
+		{4, 1},          // x^4 + x + 1
+		{63, 1},         // x^63 + x + 1
+		{64, 4, 3, 1},   // x^64 + x^4 + x^3 + x + 1
+		{163, 7, 6, 3},  // sect163k1 and sect163r2
+		{233, 74},       // sect233k1 and sect233r1
+		{571, 10, 5, 2}, // sect571k1
+	} {
+		f, err := NewField(params[0], params[1:]...)
+		assert.Nil(t, err)
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func randElement(t *testing.T, f *Field) *big.Int {
+	a, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(int64(1)), uint(f.M)))
+	assert.Nil(t, err)
+	return a
+}
+
+func TestNewField(t *testing.T) {
+	_, err := NewField(163, 7, 6)
+	assert.NotNil(t, err)
+	_, err = NewField(163, 163)
+	assert.NotNil(t, err)
+	_, err = NewField(1, 1)
+	assert.NotNil(t, err)
+}
+
+func TestSmallField(t *testing.T) {
+	f, err := NewField(4, 1)
+	assert.Nil(t, err)
+	// in GF(2^4) with x^4 + x + 1: x^3 * x = x + 1, and x^15 = 1
+	x := big.NewInt(int64(2))
+	assert.Equal(t, int64(3), f.Mul(big.NewInt(int64(8)), x).Int64())
+	assert.Equal(t, int64(1), f.Exp(x, big.NewInt(int64(15))).Int64())
+	inv, err := f.Inverse(x)
+	assert.Nil(t, err)
+	// x^-1 = x^3 + 1
+	assert.Equal(t, int64(9), inv.Int64())
+	_, err = f.Inverse(big.NewInt(int64(0)))
+	assert.NotNil(t, err)
+}
+
+func TestArithmetic(t *testing.T) {
+	for _, f := range testFields(t) {
+		for i := 0; i < 10; i++ {
+			a, b, c := randElement(t, f), randElement(t, f), randElement(t, f)
+			ab := f.Mul(a, b)
+			assertBigEqual(t, naiveMul(a, b, f.Poly, f.M), ab)
+			assert.True(t, f.Contains(ab))
+			assertBigEqual(t, f.Mul(a, a), f.Square(a))
+			// a (b + c) = a b + a c
+			assertBigEqual(t, f.Mul(a, f.Add(b, c)), f.Add(ab, f.Mul(a, c)))
+
+			if a.Sign() != 0 {
+				inv, err := f.Inverse(a)
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), f.Mul(a, inv).Int64())
+				d, err := f.Div(ab, a)
+				assert.Nil(t, err)
+				assertBigEqual(t, b, d)
+			}
+			assertBigEqual(t, a, f.Square(f.Sqrt(a)))
+			// a^(2^m) = a
+			assertBigEqual(t, a, f.Exp(a, new(big.Int).Lsh(big.NewInt(int64(1)), uint(f.M))))
+		}
+	}
+}
+
+func TestSolveQuadratic(t *testing.T) {
+	for _, f := range testFields(t) {
+		if f.M%2 == 0 {
+			_, err := f.SolveQuadratic(big.NewInt(int64(1)))
+			assert.NotNil(t, err)
+			continue
+		}
+		for i := 0; i < 10; i++ {
+			c := randElement(t, f)
+			z, err := f.SolveQuadratic(c)
+			if f.Trace(c) == 1 {
+				assert.NotNil(t, err)
+				continue
+			}
+			assert.Nil(t, err)
+			assertBigEqual(t, c, f.Add(f.Square(z), z))
+		}
+		// z^2 + z = z (z + 1) has always trace 0
+		z := randElement(t, f)
+		assert.Equal(t, uint(0), f.Trace(f.Add(f.Square(z), z)))
+	}
+}
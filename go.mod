@@ -1,6 +1,13 @@
 module github.com/arnaucube/cryptofun
 
+go 1.24
+
 require (
 	github.com/arnaucube/go-snark v0.0.0-20181207210027-19f7216d0e3d
 	github.com/stretchr/testify v1.2.2
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
@@ -0,0 +1,179 @@
+package group
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark/bn128"
+)
+
+// BN128G1 is the G1 group of the BN128 pairing curve, y^2 = x^3 + 3, of
+// github.com/arnaucube/go-snark/bn128 (the one used by the bls package). The
+// elements are [3]*big.Int points in Jacobian coordinates, normalized to Z = 1
+// (or Z = 0 for the point at infinity)
+type BN128G1 struct {
+	G1 bn128.G1
+	Q  *big.Int // modulus of the base field
+	R  *big.Int // order of the group
+}
+
+// NewBN128G1 defines the G1 group of the BN128 pairing curve
+func NewBN128G1() (BN128G1, error) {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		return BN128G1{}, err
+	}
+	return BN128G1{G1: bn.G1, Q: bn.Q, R: bn.R}, nil
+}
+
+func (g BN128G1) toPoint(a Element) ([3]*big.Int, error) {
+	p, ok := a.([3]*big.Int)
+	if !ok || p[0] == nil || p[1] == nil || p[2] == nil {
+		return p, errors.New("invalid element: not a [3]*big.Int")
+	}
+	return p, nil
+}
+
+// normalize returns the point with Z = 1, or the identity
+func (g BN128G1) normalize(p [3]*big.Int) [3]*big.Int {
+	if g.G1.IsZero(p) {
+		return g.Identity().([3]*big.Int)
+	}
+	a := g.G1.Affine(p)
+	return [3]*big.Int{a[0], a[1], big.NewInt(int64(1))}
+}
+
+// Identity returns the point at infinity (0, 1, 0)
+func (g BN128G1) Identity() Element {
+	return [3]*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), big.NewInt(int64(0))}
+}
+
+// Generator returns the generator (1, 2) of G1
+func (g BN128G1) Generator() Element {
+	return g.G1.G
+}
+
+// Order returns R
+func (g BN128G1) Order() *big.Int {
+	return g.R
+}
+
+// Add returns a + b
+func (g BN128G1) Add(a, b Element) (Element, error) {
+	p1, err := g.toPoint(a)
+	if err != nil {
+		return nil, err
+	}
+	p2, err := g.toPoint(b)
+	if err != nil {
+		return nil, err
+	}
+	// the addition formulas of G1 do not handle the doubling
+	if g.G1.Equal(p1, p2) {
+		return g.normalize(g.G1.Double(p1)), nil
+	}
+	return g.normalize(g.G1.Add(p1, p2)), nil
+}
+
+// Neg returns -a
+func (g BN128G1) Neg(a Element) (Element, error) {
+	p, err := g.toPoint(a)
+	if err != nil {
+		return nil, err
+	}
+	return g.normalize(g.G1.Neg(p)), nil
+}
+
+// Mul returns k x a
+func (g BN128G1) Mul(a Element, k *big.Int) (Element, error) {
+	p, err := g.toPoint(a)
+	if err != nil {
+		return nil, err
+	}
+	return g.normalize(g.G1.MulScalar(p, new(big.Int).Mod(k, g.R))), nil
+}
+
+// Equal returns true if a and b are the same point
+func (g BN128G1) Equal(a, b Element) bool {
+	p1, err := g.toPoint(a)
+	if err != nil {
+		return false
+	}
+	p2, err := g.toPoint(b)
+	if err != nil {
+		return false
+	}
+	return g.G1.Equal(p1, p2)
+}
+
+// Validate checks that a is a point of the curve. As the cofactor of G1 is 1,
+// all the points of the curve are in the group
+func (g BN128G1) Validate(a Element) error {
+	p, err := g.toPoint(a)
+	if err != nil {
+		return err
+	}
+	if g.G1.IsZero(p) {
+		return nil
+	}
+	aff := g.G1.Affine(p)
+	// y^2 == x^3 + 3
+	y2 := new(big.Int).Mul(aff[1], aff[1])
+	y2.Mod(y2, g.Q)
+	f := new(big.Int).Exp(aff[0], big.NewInt(int64(3)), g.Q)
+	f.Add(f, big.NewInt(int64(3)))
+	f.Mod(f, g.Q)
+	if y2.Cmp(f) != 0 {
+		return errors.New("invalid element: not on the curve")
+	}
+	return nil
+}
+
+// Int returns the affine x coordinate of a
+func (g BN128G1) Int(a Element) (*big.Int, error) {
+	p, err := g.toPoint(a)
+	if err != nil {
+		return nil, err
+	}
+	if g.G1.IsZero(p) {
+		return nil, errors.New("the point at infinity has no x coordinate")
+	}
+	return g.G1.Affine(p)[0], nil
+}
+
+// Marshal returns the encoding x || y of the affine coordinates, with 32 bytes
+// each, where the point at infinity is encoded as 64 zero bytes
+func (g BN128G1) Marshal(a Element) ([]byte, error) {
+	if err := g.Validate(a); err != nil {
+		return nil, err
+	}
+	b := make([]byte, 64)
+	p := a.([3]*big.Int)
+	if g.G1.IsZero(p) {
+		return b, nil
+	}
+	aff := g.G1.Affine(p)
+	aff[0].FillBytes(b[:32])
+	aff[1].FillBytes(b[32:])
+	return b, nil
+}
+
+// Unmarshal decodes and validates a point encoded with Marshal
+func (g BN128G1) Unmarshal(b []byte) (Element, error) {
+	if len(b) != 64 {
+		return nil, errors.New("invalid element encoding: length")
+	}
+	x := new(big.Int).SetBytes(b[:32])
+	y := new(big.Int).SetBytes(b[32:])
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return g.Identity(), nil
+	}
+	if x.Cmp(g.Q) >= 0 || y.Cmp(g.Q) >= 0 {
+		return nil, errors.New("invalid element encoding: coordinate out of the field")
+	}
+	p := [3]*big.Int{x, y, big.NewInt(int64(1))}
+	if err := g.Validate(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
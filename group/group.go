@@ -0,0 +1,128 @@
+package group
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Element is an element of a Group, its concrete type depends on the Group
+// implementation (ecc.Point for the elliptic curves, *big.Int for Z_p*, and
+// [3]*big.Int for the bn128 G1)
+type Element interface{}
+
+// Group is a cyclic group of prime order, generated by Generator. The group
+// operation is written additively (Add, Mul), also for the multiplicative groups
+// like Z_p*, where Add is the product and Mul the exponentiation
+type Group interface {
+	// Identity returns the neutral element of the group
+	Identity() Element
+	// Generator returns the generator of the group
+	Generator() Element
+	// Order returns the order of the generator
+	Order() *big.Int
+	// Add returns a + b
+	Add(a, b Element) (Element, error)
+	// Neg returns -a
+	Neg(a Element) (Element, error)
+	// Mul returns k x a
+	Mul(a Element, k *big.Int) (Element, error)
+	// Equal returns true if a and b are the same element
+	Equal(a, b Element) bool
+	// Validate returns an error if a is not an element of the group generated
+	// by Generator
+	Validate(a Element) error
+	// Int converts the element to an integer, used by the DSA signatures: the
+	// x coordinate for the elliptic curves, the element itself for Z_p*
+	Int(a Element) (*big.Int, error)
+	// Marshal returns the byte encoding of the element
+	Marshal(a Element) ([]byte, error)
+	// Unmarshal decodes and validates an element
+	Unmarshal(b []byte) (Element, error)
+}
+
+// ElementValidator is implemented by the groups where Validate checks the
+// membership to the subgroup generated by Generator, and the elements of the
+// whole group (like the points of a curve with cofactor) are also valid in some
+// uses, like the messages of ElGamal
+type ElementValidator interface {
+	// ValidateElement returns an error if a is not an element of the whole
+	// group, without the subgroup check
+	ValidateElement(a Element) error
+}
+
+// ValidateElement checks that a is an element of the whole group g, using the
+// ValidateElement of g when it implements ElementValidator, and Validate
+// otherwise
+func ValidateElement(g Group, a Element) error {
+	if v, ok := g.(ElementValidator); ok {
+		return v.ValidateElement(a)
+	}
+	return g.Validate(a)
+}
+
+// MultiMuler is implemented by the groups that have a specific multi-scalar
+// multiplication, faster than computing each scalar multiplication
+type MultiMuler interface {
+	// MultiMul returns the sum of scalars[i] x elements[i]
+	MultiMul(elements []Element, scalars []*big.Int) (Element, error)
+}
+
+// MultiMul returns the sum of scalars[i] x elements[i] over the group g, using
+// the MultiMul of g when it implements MultiMuler, and computing each scalar
+// multiplication otherwise
+func MultiMul(g Group, elements []Element, scalars []*big.Int) (Element, error) {
+	if m, ok := g.(MultiMuler); ok {
+		return m.MultiMul(elements, scalars)
+	}
+	if len(elements) != len(scalars) {
+		return nil, errors.New("the number of elements and scalars must be the same")
+	}
+	r := g.Identity()
+	for i := range elements {
+		e, err := g.Mul(elements[i], scalars[i])
+		if err != nil {
+			return nil, err
+		}
+		r, err = g.Add(r, e)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// FixedBase is a precomputed table for the multiplication of a fixed element
+type FixedBase interface {
+	// Mul returns k x the fixed element
+	Mul(k *big.Int) (Element, error)
+}
+
+// FixedBaser is implemented by the groups that can precompute a table for the
+// multiplication of a fixed element, like the generator
+type FixedBaser interface {
+	NewFixedBase(base Element) (FixedBase, error)
+}
+
+// genericFixedBase is the FixedBase of the groups that do not implement
+// FixedBaser, which uses Group.Mul
+type genericFixedBase struct {
+	g    Group
+	base Element
+}
+
+func (fb genericFixedBase) Mul(k *big.Int) (Element, error) {
+	return fb.g.Mul(fb.base, k)
+}
+
+// NewFixedBase returns the precomputed table of the group g for the
+// multiplication of base when g implements FixedBaser, or a FixedBase that uses
+// g.Mul otherwise
+func NewFixedBase(g Group, base Element) (FixedBase, error) {
+	if f, ok := g.(FixedBaser); ok {
+		return f.NewFixedBase(base)
+	}
+	if err := g.Validate(base); err != nil {
+		return nil, err
+	}
+	return genericFixedBase{g, base}, nil
+}
@@ -0,0 +1,141 @@
+package group
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testGroup checks the group laws and the encoding of a Group implementation
+func testGroup(t *testing.T, g Group) {
+	gen := g.Generator()
+	assert.Nil(t, g.Validate(gen))
+	assert.Nil(t, g.Validate(g.Identity()))
+	assert.NotNil(t, g.Validate("not an element"))
+
+	// N x G == identity
+	nG, err := g.Mul(gen, g.Order())
+	assert.Nil(t, err)
+	assert.True(t, g.Equal(nG, g.Identity()))
+
+	// 3G + 5G == 8G
+	a, err := g.Mul(gen, big.NewInt(int64(3)))
+	assert.Nil(t, err)
+	b, err := g.Mul(gen, big.NewInt(int64(5)))
+	assert.Nil(t, err)
+	ab, err := g.Add(a, b)
+	assert.Nil(t, err)
+	c, err := g.Mul(gen, big.NewInt(int64(8)))
+	assert.Nil(t, err)
+	assert.True(t, g.Equal(ab, c))
+	assert.False(t, g.Equal(a, b))
+
+	// G + G == 2G
+	gg, err := g.Add(gen, gen)
+	assert.Nil(t, err)
+	g2, err := g.Mul(gen, big.NewInt(int64(2)))
+	assert.Nil(t, err)
+	assert.True(t, g.Equal(gg, g2))
+
+	// a + (-a) == identity, a + identity == a
+	aNeg, err := g.Neg(a)
+	assert.Nil(t, err)
+	id, err := g.Add(a, aNeg)
+	assert.Nil(t, err)
+	assert.True(t, g.Equal(id, g.Identity()))
+	a2, err := g.Add(a, g.Identity())
+	assert.Nil(t, err)
+	assert.True(t, g.Equal(a, a2))
+
+	// (N-1) x G == -G
+	nMinusOne := new(big.Int).Sub(g.Order(), big.NewInt(int64(1)))
+	gNeg, err := g.Mul(gen, nMinusOne)
+	assert.Nil(t, err)
+	gNeg2, err := g.Neg(gen)
+	assert.Nil(t, err)
+	assert.True(t, g.Equal(gNeg, gNeg2))
+
+	_, err = g.Int(a)
+	assert.Nil(t, err)
+
+	for _, e := range []Element{a, gen, g.Identity()} {
+		b, err := g.Marshal(e)
+		assert.Nil(t, err)
+		e2, err := g.Unmarshal(b)
+		assert.Nil(t, err)
+		assert.True(t, g.Equal(e, e2))
+	}
+	_, err = g.Unmarshal([]byte{1, 2, 3})
+	assert.NotNil(t, err)
+}
+
+func TestZpGroup(t *testing.T) {
+	// 2 has order 11 mod 23
+	z, err := NewZpGroup(big.NewInt(int64(23)), big.NewInt(int64(11)), big.NewInt(int64(2)))
+	assert.Nil(t, err)
+	testGroup(t, z)
+
+	// 5 is a generator of Z_23*, of order 22, so it is not in the subgroup
+	assert.NotNil(t, z.Validate(big.NewInt(int64(5))))
+	assert.NotNil(t, z.Validate(big.NewInt(int64(0))))
+	assert.NotNil(t, z.Validate(big.NewInt(int64(23))))
+
+	_, err = NewZpGroup(big.NewInt(int64(23)), big.NewInt(int64(11)), big.NewInt(int64(5)))
+	assert.NotNil(t, err)
+	_, err = NewZpGroup(big.NewInt(int64(23)), big.NewInt(int64(7)), big.NewInt(int64(2)))
+	assert.NotNil(t, err)
+}
+
+func TestGenerateZpGroup(t *testing.T) {
+	z, err := GenerateZpGroup(512, 160)
+	assert.Nil(t, err)
+	assert.Equal(t, 512, z.P.BitLen())
+	assert.Equal(t, 160, z.Q.BitLen())
+	_, err = NewZpGroup(z.P, z.Q, z.G)
+	assert.Nil(t, err)
+	testGroup(t, z)
+}
+
+func TestBN128G1(t *testing.T) {
+	g, err := NewBN128G1()
+	assert.Nil(t, err)
+	testGroup(t, g)
+
+	// (1, 3) is not on the curve
+	p := [3]*big.Int{big.NewInt(int64(1)), big.NewInt(int64(3)), big.NewInt(int64(1))}
+	assert.NotNil(t, g.Validate(p))
+}
+
+func TestMultiMul(t *testing.T) {
+	// ZpGroup does not implement MultiMuler, so the generic MultiMul is used
+	z, err := NewZpGroup(big.NewInt(int64(23)), big.NewInt(int64(11)), big.NewInt(int64(2)))
+	assert.Nil(t, err)
+	a, err := z.Mul(z.G, big.NewInt(int64(3)))
+	assert.Nil(t, err)
+	// 2 x G + 4 x 3G == 14 x G
+	r, err := MultiMul(z, []Element{z.G, a}, []*big.Int{big.NewInt(int64(2)), big.NewInt(int64(4))})
+	assert.Nil(t, err)
+	r2, err := z.Mul(z.G, big.NewInt(int64(14)))
+	assert.Nil(t, err)
+	assert.True(t, z.Equal(r, r2))
+
+	_, err = MultiMul(z, []Element{z.G}, []*big.Int{})
+	assert.NotNil(t, err)
+}
+
+func TestNewFixedBase(t *testing.T) {
+	// ZpGroup does not implement FixedBaser, so the generic FixedBase is used
+	z, err := NewZpGroup(big.NewInt(int64(23)), big.NewInt(int64(11)), big.NewInt(int64(2)))
+	assert.Nil(t, err)
+	fb, err := NewFixedBase(z, z.G)
+	assert.Nil(t, err)
+	r, err := fb.Mul(big.NewInt(int64(7)))
+	assert.Nil(t, err)
+	r2, err := z.Mul(z.G, big.NewInt(int64(7)))
+	assert.Nil(t, err)
+	assert.True(t, z.Equal(r, r2))
+
+	_, err = NewFixedBase(z, big.NewInt(int64(5)))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,209 @@
+package group
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+var (
+	bigZero = big.NewInt(int64(0))
+	bigOne  = big.NewInt(int64(1))
+)
+
+// ZpGroup is a Schnorr group, the subgroup of order Q of the multiplicative
+// group Z_p*, where Q is a prime that divides P-1 and G a generator of the
+// subgroup
+type ZpGroup struct {
+	P *big.Int
+	Q *big.Int
+	G *big.Int
+}
+
+// NewZpGroup defines a new ZpGroup, checking that the parameters define a
+// Schnorr group
+func NewZpGroup(p, q, g *big.Int) (ZpGroup, error) {
+	if !p.ProbablyPrime(20) || !q.ProbablyPrime(20) {
+		return ZpGroup{}, errors.New("p and q must be prime")
+	}
+	pMinusOne := new(big.Int).Sub(p, bigOne)
+	if new(big.Int).Mod(pMinusOne, q).Sign() != 0 {
+		return ZpGroup{}, errors.New("q does not divide p-1")
+	}
+	if g.Cmp(bigOne) <= 0 || g.Cmp(p) >= 0 || new(big.Int).Exp(g, q, p).Cmp(bigOne) != 0 {
+		return ZpGroup{}, errors.New("g is not a generator of the subgroup of order q")
+	}
+	return ZpGroup{P: p, Q: q, G: g}, nil
+}
+
+// GenerateZpGroup generates a new random Schnorr group, with a prime P of pBits
+// bits and a prime order Q of qBits bits
+func GenerateZpGroup(pBits, qBits int) (ZpGroup, error) {
+	if qBits < 2 || pBits <= qBits {
+		return ZpGroup{}, errors.New("pBits must be bigger than qBits")
+	}
+	q, err := rand.Prime(rand.Reader, qBits)
+	if err != nil {
+		return ZpGroup{}, err
+	}
+	// p = k*q + 1, with k even
+	kMax := new(big.Int).Lsh(bigOne, uint(pBits-qBits))
+	p := new(big.Int)
+	for {
+		k, err := rand.Int(rand.Reader, kMax)
+		if err != nil {
+			return ZpGroup{}, err
+		}
+		k.SetBit(k, 0, 0)
+		if k.Sign() == 0 {
+			continue
+		}
+		p.Mul(k, q)
+		p.Add(p, bigOne)
+		if p.BitLen() == pBits && p.ProbablyPrime(20) {
+			break
+		}
+	}
+	// g = h^((p-1)/q) mod p, for a random h, until g != 1
+	e := new(big.Int).Sub(p, bigOne)
+	e.Div(e, q)
+	for {
+		h, err := rand.Int(rand.Reader, p)
+		if err != nil {
+			return ZpGroup{}, err
+		}
+		g := new(big.Int).Exp(h, e, p)
+		if g.Cmp(bigOne) > 0 {
+			return ZpGroup{P: p, Q: q, G: g}, nil
+		}
+	}
+}
+
+func (z ZpGroup) toInt(a Element) (*big.Int, error) {
+	n, ok := a.(*big.Int)
+	if !ok || n == nil {
+		return nil, errors.New("invalid element: not a *big.Int")
+	}
+	return n, nil
+}
+
+// Identity returns 1
+func (z ZpGroup) Identity() Element {
+	return big.NewInt(int64(1))
+}
+
+// Generator returns G
+func (z ZpGroup) Generator() Element {
+	return z.G
+}
+
+// Order returns Q
+func (z ZpGroup) Order() *big.Int {
+	return z.Q
+}
+
+// Add returns a * b mod P
+func (z ZpGroup) Add(a, b Element) (Element, error) {
+	x, err := z.toInt(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := z.toInt(b)
+	if err != nil {
+		return nil, err
+	}
+	r := new(big.Int).Mul(x, y)
+	return r.Mod(r, z.P), nil
+}
+
+// Neg returns the inverse of a mod P
+func (z ZpGroup) Neg(a Element) (Element, error) {
+	x, err := z.toInt(a)
+	if err != nil {
+		return nil, err
+	}
+	r := new(big.Int).ModInverse(x, z.P)
+	if r == nil {
+		return nil, errors.New("invalid element: not invertible")
+	}
+	return r, nil
+}
+
+// Mul returns a^k mod P
+func (z ZpGroup) Mul(a Element, k *big.Int) (Element, error) {
+	x, err := z.toInt(a)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Exp(x, new(big.Int).Mod(k, z.Q), z.P), nil
+}
+
+// Equal returns true if a and b are the same element
+func (z ZpGroup) Equal(a, b Element) bool {
+	x, err := z.toInt(a)
+	if err != nil {
+		return false
+	}
+	y, err := z.toInt(b)
+	if err != nil {
+		return false
+	}
+	return x.Cmp(y) == 0
+}
+
+// Validate checks that a is in [1, P-1] and a^Q = 1 mod P
+func (z ZpGroup) Validate(a Element) error {
+	x, err := z.toInt(a)
+	if err != nil {
+		return err
+	}
+	if x.Cmp(bigZero) <= 0 || x.Cmp(z.P) >= 0 {
+		return errors.New("invalid element: out of range")
+	}
+	if new(big.Int).Exp(x, z.Q, z.P).Cmp(bigOne) != 0 {
+		return errors.New("invalid element: not in the subgroup")
+	}
+	return nil
+}
+
+// ValidateElement checks that a is in [1, P-1], that is an element of Z_p*
+func (z ZpGroup) ValidateElement(a Element) error {
+	x, err := z.toInt(a)
+	if err != nil {
+		return err
+	}
+	if x.Cmp(bigZero) <= 0 || x.Cmp(z.P) >= 0 {
+		return errors.New("invalid element: out of range")
+	}
+	return nil
+}
+
+// Int returns the element itself
+func (z ZpGroup) Int(a Element) (*big.Int, error) {
+	x, err := z.toInt(a)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(x), nil
+}
+
+// Marshal returns the big endian encoding of a, with the byte length of P
+func (z ZpGroup) Marshal(a Element) ([]byte, error) {
+	if err := z.Validate(a); err != nil {
+		return nil, err
+	}
+	b := make([]byte, (z.P.BitLen()+7)/8)
+	return a.(*big.Int).FillBytes(b), nil
+}
+
+// Unmarshal decodes and validates an element encoded with Marshal
+func (z ZpGroup) Unmarshal(b []byte) (Element, error) {
+	if len(b) != (z.P.BitLen()+7)/8 {
+		return nil, errors.New("invalid element encoding: length")
+	}
+	x := new(big.Int).SetBytes(b)
+	if err := z.Validate(x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
@@ -1,6 +1,11 @@
 package prime
 
-import "math/rand"
+import (
+	"iter"
+	"math/big"
+	"math/rand"
+	"sort"
+)
 
 const (
 	// MaxPrime is to get a prime value below this number
@@ -37,6 +42,56 @@ func SieveOfEratosthenes(N int) (primes []int) {
 	return
 }
 
+// segmentSize is the number of integers sieved at a time by Primes
+const segmentSize = 1 << 16
+
+// Primes returns an iterator over the primes in [from, to) in ascending order.
+// It is a segmented sieve of Eratosthenes: the primes up to sqrt(to) are sieved
+// first, and then they sieve the interval in segments of segmentSize integers,
+// so the memory used is O(sqrt(to)) and not O(to) as with SieveOfEratosthenes
+func Primes(from, to uint64) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		if from < 2 {
+			from = 2
+		}
+		if from >= to {
+			return
+		}
+		// the primes up to sqrt(to - 1)
+		limit := new(big.Int).Sqrt(new(big.Int).SetUint64(to - 1)).Uint64()
+		base := SieveOfEratosthenes(int(limit) + 1)
+		composite := make([]bool, segmentSize)
+		for lo := from; lo < to; lo += segmentSize {
+			hi := lo + segmentSize
+			if hi > to || hi < lo {
+				hi = to
+			}
+			for i := range composite {
+				composite[i] = false
+			}
+			for _, bp := range base {
+				p := uint64(bp)
+				if p*p >= hi {
+					break
+				}
+				// the first multiple of p in the segment, not lower than p^2
+				k := (lo + p - 1) / p * p
+				if k < p*p {
+					k = p * p
+				}
+				for ; k < hi; k += p {
+					composite[k-lo] = true
+				}
+			}
+			for k := lo; k < hi; k++ {
+				if !composite[k-lo] && !yield(k) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // Gcd returns the greatest common divisor
 func Gcd(a, b int) int {
 	var bgcd func(a, b, res int) int
@@ -58,3 +113,134 @@ func Gcd(a, b int) int {
 	}
 	return bgcd(a, b, 1)
 }
+
+var (
+	bigZero = big.NewInt(int64(0))
+	bigOne  = big.NewInt(int64(1))
+)
+
+// smallPrimes are the primes used for trial division in Factor
+var smallPrimes = SieveOfEratosthenes(1 << 12)
+
+// Factor returns the prime factors of n in ascending order, each one repeated
+// as many times as it divides n. It uses trial division by the small primes
+// followed by the Pollard-Brent rho method, so it is fast when n is smooth or
+// has at most one big prime factor. With two or more big prime factors it can
+// take very long, use FactorLimit to bound the work
+func Factor(n *big.Int) []*big.Int {
+	factors, _ := FactorLimit(n, 0)
+	return factors
+}
+
+// FactorLimit is Factor with at most steps iterations of the rho method for
+// each composite part (0 for no limit). It returns the prime factors found in
+// ascending order and the product of the composite parts that could not be
+// factored, which is 1 when n is fully factored
+func FactorLimit(n *big.Int, steps int) ([]*big.Int, *big.Int) {
+	var factors []*big.Int
+	rest := big.NewInt(int64(1))
+	if n.Sign() <= 0 {
+		return factors, rest
+	}
+	m := new(big.Int).Set(n)
+	r := new(big.Int)
+	for _, p := range smallPrimes {
+		bp := big.NewInt(int64(p))
+		for {
+			q, rem := new(big.Int).QuoRem(m, bp, r)
+			if rem.Sign() != 0 {
+				break
+			}
+			factors = append(factors, bp)
+			m = q
+		}
+	}
+	if m.Cmp(bigOne) != 0 {
+		primes, composites := factorRho(m, steps)
+		factors = append(factors, primes...)
+		for _, c := range composites {
+			rest.Mul(rest, c)
+		}
+	}
+	sort.Slice(factors, func(i, j int) bool {
+		return factors[i].Cmp(factors[j]) < 0
+	})
+	return factors, rest
+}
+
+// factorRho returns the prime factors of n, where n has no small factors, and
+// the composite parts not factored within the steps limit
+func factorRho(n *big.Int, steps int) ([]*big.Int, []*big.Int) {
+	if n.Cmp(bigOne) == 0 {
+		return nil, nil
+	}
+	if n.ProbablyPrime(20) {
+		return []*big.Int{n}, nil
+	}
+	for c := int64(1); ; c++ {
+		d, exhausted := pollardBrent(n, big.NewInt(c), steps)
+		if exhausted {
+			return nil, []*big.Int{n}
+		}
+		if d != nil {
+			p1, c1 := factorRho(d, steps)
+			p2, c2 := factorRho(new(big.Int).Quo(n, d), steps)
+			return append(p1, p2...), append(c1, c2...)
+		}
+	}
+}
+
+// pollardBrent searches a non trivial divisor of the composite n using the
+// Brent variant of the Pollard rho method with the polynomial x^2 + c, returns
+// nil if the cycle is found without a divisor, and true if the steps limit is
+// reached (when steps > 0)
+func pollardBrent(n, c *big.Int, steps int) (*big.Int, bool) {
+	y := big.NewInt(int64(2))
+	x := new(big.Int)
+	ys := new(big.Int)
+	d := big.NewInt(int64(1))
+	q := big.NewInt(int64(1))
+	diff := new(big.Int)
+	f := func(v *big.Int) {
+		v.Mul(v, v)
+		v.Add(v, c)
+		v.Mod(v, n)
+	}
+	const m = 128
+	for r := 1; d.Cmp(bigOne) == 0; r *= 2 {
+		if steps > 0 && r > steps {
+			return nil, true
+		}
+		x.Set(y)
+		for i := 0; i < r; i++ {
+			f(y)
+		}
+		for k := 0; k < r && d.Cmp(bigOne) == 0; k += m {
+			ys.Set(y)
+			for i := 0; i < m && i < r-k; i++ {
+				f(y)
+				diff.Sub(x, y)
+				diff.Abs(diff)
+				q.Mul(q, diff)
+				q.Mod(q, n)
+			}
+			d.GCD(nil, nil, q, n)
+		}
+	}
+	if d.Cmp(n) == 0 {
+		// the batched gcd overshoot, backtrack one step at a time
+		for {
+			f(ys)
+			diff.Sub(x, ys)
+			diff.Abs(diff)
+			d.GCD(nil, nil, diff, n)
+			if d.Cmp(bigOne) != 0 {
+				break
+			}
+		}
+	}
+	if d.Cmp(n) == 0 || d.Cmp(bigZero) == 0 {
+		return nil, false
+	}
+	return d, false
+}
@@ -0,0 +1,131 @@
+package prime
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// product returns the product of the factors
+func product(factors []*big.Int) *big.Int {
+	r := big.NewInt(int64(1))
+	for _, f := range factors {
+		r.Mul(r, f)
+	}
+	return r
+}
+
+// randPrime returns a random prime of the given bit length
+func randPrime(t *testing.T, bits int) *big.Int {
+	p, err := rand.Prime(rand.Reader, bits)
+	assert.Nil(t, err)
+	return p
+}
+
+func TestSieveAndPrimes(t *testing.T) {
+	primes := SieveOfEratosthenes(100)
+	assert.Equal(t, []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73, 79, 83, 89, 97}, primes)
+
+	// the segmented sieve gives the same primes, over several segments
+	all := SieveOfEratosthenes(3*segmentSize + 123)
+	var seg []int
+	for p := range Primes(0, 3*segmentSize+123) {
+		seg = append(seg, int(p))
+	}
+	assert.Equal(t, all, seg)
+
+	// intervals not starting at 2
+	var r []uint64
+	for p := range Primes(90, 110) {
+		r = append(r, p)
+	}
+	assert.Equal(t, []uint64{97, 101, 103, 107, 109}, r)
+	r = nil
+	for p := range Primes(1000000000, 1000000100) {
+		assert.True(t, new(big.Int).SetUint64(p).ProbablyPrime(20))
+		r = append(r, p)
+	}
+	assert.Equal(t, 7, len(r))
+	for range Primes(20, 20) {
+		t.Error("empty interval")
+	}
+
+	// break
+	r = nil
+	for p := range Primes(2, 1000) {
+		if p > 10 {
+			break
+		}
+		r = append(r, p)
+	}
+	assert.Equal(t, []uint64{2, 3, 5, 7}, r)
+}
+
+func TestFactor(t *testing.T) {
+	n := big.NewInt(int64(2 * 2 * 3 * 5 * 5 * 4093))
+	factors := Factor(n)
+	assert.Equal(t, 6, len(factors))
+	for i, f := range []int64{2, 2, 3, 5, 5, 4093} {
+		assert.Equal(t, f, factors[i].Int64())
+	}
+
+	// small factors and two factors for the rho method
+	p, q := randPrime(t, 32), randPrime(t, 40)
+	n = new(big.Int).Mul(p, q)
+	n.Mul(n, big.NewInt(int64(12)))
+	factors = Factor(n)
+	assert.Equal(t, 0, product(factors).Cmp(n))
+	for _, f := range factors {
+		assert.True(t, f.ProbablyPrime(20))
+	}
+	for i := 1; i < len(factors); i++ {
+		assert.True(t, factors[i-1].Cmp(factors[i]) <= 0)
+	}
+
+	// a prime, 1 and the invalid inputs
+	assert.Equal(t, []*big.Int{p}, Factor(p))
+	assert.Equal(t, 0, len(Factor(big.NewInt(int64(1)))))
+	assert.Equal(t, 0, len(Factor(big.NewInt(int64(0)))))
+	assert.Equal(t, 0, len(Factor(big.NewInt(int64(-6)))))
+}
+
+func TestFactorLimit(t *testing.T) {
+	// with the limit, the product of two 64 bit primes is not factored
+	p, q := randPrime(t, 64), randPrime(t, 64)
+	n := new(big.Int).Mul(p, q)
+	n.Mul(n, big.NewInt(int64(6)))
+	factors, rest := FactorLimit(n, 1<<10)
+	assert.Equal(t, 2, len(factors))
+	assert.Equal(t, int64(2), factors[0].Int64())
+	assert.Equal(t, int64(3), factors[1].Int64())
+	assert.Equal(t, 0, rest.Cmp(new(big.Int).Mul(p, q)))
+
+	// fully factored within the limit
+	p = randPrime(t, 24)
+	n = new(big.Int).Mul(p, randPrime(t, 128))
+	factors, rest = FactorLimit(n, 1<<20)
+	assert.Equal(t, int64(1), rest.Int64())
+	assert.Equal(t, 2, len(factors))
+	assert.Equal(t, 0, product(factors).Cmp(n))
+	assert.Equal(t, 0, factors[0].Cmp(p))
+}
+
+func TestPollardBrent(t *testing.T) {
+	p, q := randPrime(t, 28), randPrime(t, 30)
+	n := new(big.Int).Mul(p, q)
+	var d *big.Int
+	for c := int64(1); d == nil; c++ {
+		var exhausted bool
+		d, exhausted = pollardBrent(n, big.NewInt(c), 0)
+		assert.False(t, exhausted)
+	}
+	assert.True(t, d.Cmp(p) == 0 || d.Cmp(q) == 0)
+
+	// the steps limit is reached before finding a 64 bit factor
+	n = new(big.Int).Mul(randPrime(t, 64), randPrime(t, 64))
+	d, exhausted := pollardBrent(n, big.NewInt(int64(1)), 16)
+	assert.Nil(t, d)
+	assert.True(t, exhausted)
+}
@@ -0,0 +1,276 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	stdrsa "crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// hashPrefixes are the DER encodings of the DigestInfo prefixes of the PKCS #1
+// v1.5 signatures (RFC 8017, section 9.2), the ones of crypto/rsa
+var hashPrefixes = map[crypto.Hash][]byte{
+	crypto.MD5:       {0x30, 0x20, 0x30, 0x0c, 0x06, 0x08, 0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x02, 0x05, 0x05, 0x00, 0x04, 0x10},
+	crypto.SHA1:      {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA224:    {0x30, 0x2d, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x04, 0x05, 0x00, 0x04, 0x1c},
+	crypto.SHA256:    {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384:    {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512:    {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+	crypto.MD5SHA1:   {}, // the TLS 1.0 signatures, without prefix
+	crypto.RIPEMD160: {0x30, 0x20, 0x30, 0x08, 0x06, 0x06, 0x28, 0xcf, 0x06, 0x03, 0x00, 0x31, 0x04, 0x14},
+}
+
+// Signer implements crypto.Signer with an RSA key, for crypto/tls, crypto/x509
+// and the other users of the standard interfaces. The signatures are the PKCS #1
+// v1.5 signatures, or the PSS signatures when opts is a *crypto/rsa.PSSOptions,
+// the same encodings as crypto/rsa
+type Signer struct {
+	Key Key
+}
+
+// NewSigner returns the Signer of the key
+func NewSigner(key Key) (*Signer, error) {
+	if key.PubK.N == nil || key.PubK.E == nil || key.PrivK.D == nil || key.PrivK.N == nil ||
+		key.PubK.N.Cmp(key.PrivK.N) != 0 {
+		return nil, errors.New("invalid key")
+	}
+	if !key.PubK.E.IsInt64() || key.PubK.E.Int64() < 3 || key.PubK.E.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid key: the public exponent must be in [3, 2^31)")
+	}
+	// m^(e d) == m
+	m := big.NewInt(int64(2))
+	if new(big.Int).Exp(Encrypt(m, key.PubK), key.PrivK.D, key.PrivK.N).Cmp(m) != 0 {
+		return nil, errors.New("invalid key: d is not the inverse of e")
+	}
+	return &Signer{Key: key}, nil
+}
+
+// Public returns the public key, a *crypto/rsa.PublicKey
+func (s *Signer) Public() crypto.PublicKey {
+	return &stdrsa.PublicKey{N: new(big.Int).Set(s.Key.PubK.N), E: int(s.Key.PubK.E.Int64())}
+}
+
+// Sign signs the digest, of the hash function of opts (for the PKCS #1 v1.5
+// signatures a zero hash function signs the digest without DigestInfo prefix).
+// The PSS signatures read the salt from random
+func (s *Signer) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	k := (s.Key.PrivK.N.BitLen() + 7) / 8
+	var em []byte
+	var err error
+	if pssOpts, ok := opts.(*stdrsa.PSSOptions); ok {
+		if random == nil {
+			return nil, errors.New("the PSS signatures need a random source")
+		}
+		em, err = emsaPSSEncode(random, digest, s.Key.PrivK.N.BitLen()-1, pssOpts)
+	} else {
+		var h crypto.Hash
+		if opts != nil {
+			h = opts.HashFunc()
+		}
+		em, err = emsaPKCS1v15Encode(h, digest, k)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sig := BlindSign(new(big.Int).SetBytes(em), s.Key.PrivK)
+	return sig.FillBytes(make([]byte, k)), nil
+}
+
+// VerifySignature validates the PKCS #1 v1.5 signature, or the PSS signature
+// when opts is a *crypto/rsa.PSSOptions, of the digest with the public key
+// returned by Signer.Public. Unlike crypto/rsa, it accepts the keys of less
+// than 1024 bits (as the ones of GenerateKeyPair)
+func VerifySignature(pub crypto.PublicKey, digest, sig []byte, opts crypto.SignerOpts) (bool, error) {
+	pk, ok := pub.(*stdrsa.PublicKey)
+	if !ok {
+		return false, errors.New("not an RSA public key")
+	}
+	k := (pk.N.BitLen() + 7) / 8
+	if len(sig) != k {
+		return false, nil
+	}
+	c := new(big.Int).SetBytes(sig)
+	if c.Cmp(pk.N) >= 0 {
+		return false, nil
+	}
+	m := Encrypt(c, PublicKey{E: big.NewInt(int64(pk.E)), N: pk.N})
+	if pssOpts, ok := opts.(*stdrsa.PSSOptions); ok {
+		emBits := pk.N.BitLen() - 1
+		emLen := (emBits + 7) / 8
+		if m.BitLen() > 8*emLen {
+			return false, nil
+		}
+		return emsaPSSVerify(digest, m.FillBytes(make([]byte, emLen)), emBits, pssOpts)
+	}
+	var h crypto.Hash
+	if opts != nil {
+		h = opts.HashFunc()
+	}
+	em, err := emsaPKCS1v15Encode(h, digest, k)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(em, m.FillBytes(make([]byte, k))) == 1, nil
+}
+
+// emsaPKCS1v15Encode returns the PKCS #1 v1.5 encoding of the digest (RFC 8017,
+// section 9.2), 0x00 || 0x01 || 0xff... || 0x00 || DigestInfo prefix || digest
+func emsaPKCS1v15Encode(h crypto.Hash, digest []byte, k int) ([]byte, error) {
+	var prefix []byte
+	if h != 0 {
+		var ok bool
+		if prefix, ok = hashPrefixes[h]; !ok {
+			return nil, errors.New("unsupported hash function")
+		}
+		if len(digest) != h.Size() {
+			return nil, errors.New("the digest length does not match the hash function")
+		}
+	}
+	tLen := len(prefix) + len(digest)
+	if k < tLen+11 {
+		return nil, errors.New("the key is too short for the digest")
+	}
+	em := make([]byte, k)
+	em[1] = 0x01
+	for i := 2; i < k-tLen-1; i++ {
+		em[i] = 0xff
+	}
+	copy(em[k-tLen:], prefix)
+	copy(em[k-len(digest):], digest)
+	return em, nil
+}
+
+// pssSaltLength returns the salt length of the PSS options for the encoding,
+// where the automatic length is the maximum
+func pssSaltLength(opts *stdrsa.PSSOptions, h crypto.Hash, emLen int) (int, error) {
+	switch opts.SaltLength {
+	case stdrsa.PSSSaltLengthAuto:
+		return emLen - h.Size() - 2, nil
+	case stdrsa.PSSSaltLengthEqualsHash:
+		return h.Size(), nil
+	}
+	if opts.SaltLength < 0 {
+		return 0, errors.New("invalid PSS salt length")
+	}
+	return opts.SaltLength, nil
+}
+
+// pssHash returns the hash function of the PSS options, and checks the length
+// of the digest
+func pssHash(opts *stdrsa.PSSOptions, digest []byte) (crypto.Hash, error) {
+	h := opts.HashFunc()
+	if !h.Available() {
+		return 0, errors.New("hash function not available")
+	}
+	if len(digest) != h.Size() {
+		return 0, errors.New("the digest length does not match the hash function")
+	}
+	return h, nil
+}
+
+// emsaPSSEncode returns the PSS encoding of the digest (RFC 8017, section
+// 9.1.1), maskedDB || H || 0xbc, with a random salt
+func emsaPSSEncode(random io.Reader, digest []byte, emBits int, opts *stdrsa.PSSOptions) ([]byte, error) {
+	h, err := pssHash(opts, digest)
+	if err != nil {
+		return nil, err
+	}
+	hLen := h.Size()
+	emLen := (emBits + 7) / 8
+	sLen, err := pssSaltLength(opts, h, emLen)
+	if err != nil {
+		return nil, err
+	}
+	if emLen < hLen+sLen+2 {
+		return nil, errors.New("the key is too short for the PSS parameters")
+	}
+	salt := make([]byte, sLen)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, err
+	}
+	// H = Hash(0x00 x 8 || digest || salt)
+	hh := h.New()
+	hh.Write(make([]byte, 8))
+	hh.Write(digest)
+	hh.Write(salt)
+	hash := hh.Sum(nil)
+
+	// DB = PS || 0x01 || salt, masked with MGF1(H)
+	em := make([]byte, emLen)
+	db := em[:emLen-hLen-1]
+	db[emLen-sLen-hLen-2] = 0x01
+	copy(db[emLen-sLen-hLen-1:], salt)
+	mgf1XOR(db, h, hash)
+	db[0] &= 0xff >> uint(8*emLen-emBits)
+	copy(em[emLen-hLen-1:], hash)
+	em[emLen-1] = 0xbc
+	return em, nil
+}
+
+// emsaPSSVerify checks the PSS encoding em of the digest (RFC 8017, section
+// 9.1.2). With the automatic salt length, the salt length is detected
+func emsaPSSVerify(digest, em []byte, emBits int, opts *stdrsa.PSSOptions) (bool, error) {
+	h, err := pssHash(opts, digest)
+	if err != nil {
+		return false, err
+	}
+	hLen := h.Size()
+	emLen := len(em)
+	if emLen < hLen+2 || em[emLen-1] != 0xbc {
+		return false, nil
+	}
+	db := append([]byte{}, em[:emLen-hLen-1]...)
+	hash := em[emLen-hLen-1 : emLen-1]
+	if db[0]&^(0xff>>uint(8*emLen-emBits)) != 0 {
+		return false, nil
+	}
+	mgf1XOR(db, h, hash)
+	db[0] &= 0xff >> uint(8*emLen-emBits)
+
+	// DB = 0x00... || 0x01 || salt
+	sLen := -1
+	if opts.SaltLength != stdrsa.PSSSaltLengthAuto {
+		if sLen, err = pssSaltLength(opts, h, emLen); err != nil {
+			return false, err
+		}
+	}
+	i := bytes.IndexByte(db, 0x01)
+	if i < 0 || !bytes.Equal(db[:i], make([]byte, i)) {
+		return false, nil
+	}
+	if sLen >= 0 && len(db)-i-1 != sLen {
+		return false, nil
+	}
+	salt := db[i+1:]
+	hh := h.New()
+	hh.Write(make([]byte, 8))
+	hh.Write(digest)
+	hh.Write(salt)
+	return subtle.ConstantTimeCompare(hh.Sum(nil), hash) == 1, nil
+}
+
+// mgf1XOR xors out with the mask of MGF1 (RFC 8017, appendix B.2.1) of the seed
+func mgf1XOR(out []byte, h crypto.Hash, seed []byte) {
+	counter := make([]byte, 4)
+	var done int
+	for done < len(out) {
+		hh := h.New()
+		hh.Write(seed)
+		hh.Write(counter)
+		mask := hh.Sum(nil)
+		for i := 0; i < len(mask) && done < len(out); i++ {
+			out[done] ^= mask[i]
+			done++
+		}
+		// increment the 32 bit big endian counter
+		for i := 3; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+	}
+}
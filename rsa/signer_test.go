@@ -0,0 +1,175 @@
+package rsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stdKey returns a key of crypto/rsa, and the same key as a Key
+func stdKey(t *testing.T) (*stdrsa.PrivateKey, Key) {
+	std, err := stdrsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	key := Key{
+		PubK:  PublicKey{E: big.NewInt(int64(std.E)), N: std.N},
+		PrivK: PrivateKey{D: std.D, N: std.N},
+	}
+	return std, key
+}
+
+func TestSignerPKCS1v15(t *testing.T) {
+	std, key := stdKey(t)
+	signer, err := NewSigner(key)
+	assert.Nil(t, err)
+	var _ crypto.Signer = signer
+	assert.True(t, std.PublicKey.Equal(signer.Public()))
+
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	stdSig, err := stdrsa.SignPKCS1v15(nil, std, crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+	assert.Equal(t, stdSig, sig)
+	assert.Nil(t, stdrsa.VerifyPKCS1v15(&std.PublicKey, crypto.SHA256, digest[:], sig))
+	verified, err := VerifySignature(signer.Public(), digest[:], sig, crypto.SHA256)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	digest2 := sha256.Sum256([]byte("another message"))
+	verified, err = VerifySignature(signer.Public(), digest2[:], sig, crypto.SHA256)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+	verified, err = VerifySignature(signer.Public(), digest[:], sig[1:], crypto.SHA256)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	// without hash function the digest is signed without prefix
+	sig, err = signer.Sign(nil, []byte("raw"), crypto.Hash(0))
+	assert.Nil(t, err)
+	stdSig, err = stdrsa.SignPKCS1v15(nil, std, crypto.Hash(0), []byte("raw"))
+	assert.Nil(t, err)
+	assert.Equal(t, stdSig, sig)
+}
+
+func TestSignerPSS(t *testing.T) {
+	std, key := stdKey(t)
+	signer, err := NewSigner(key)
+	assert.Nil(t, err)
+	digest := sha256.Sum256([]byte("message"))
+	for _, saltLength := range []int{stdrsa.PSSSaltLengthAuto, stdrsa.PSSSaltLengthEqualsHash, 20} {
+		opts := &stdrsa.PSSOptions{SaltLength: saltLength, Hash: crypto.SHA256}
+		sig, err := signer.Sign(rand.Reader, digest[:], opts)
+		assert.Nil(t, err)
+		assert.Nil(t, stdrsa.VerifyPSS(&std.PublicKey, crypto.SHA256, digest[:], sig, opts))
+		verified, err := VerifySignature(signer.Public(), digest[:], sig, opts)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+		verified, err = VerifySignature(signer.Public(), digest[:], sig, &stdrsa.PSSOptions{Hash: crypto.SHA256})
+		assert.Nil(t, err)
+		assert.True(t, verified)
+
+		stdSig, err := stdrsa.SignPSS(rand.Reader, std, crypto.SHA256, digest[:], opts)
+		assert.Nil(t, err)
+		verified, err = VerifySignature(signer.Public(), digest[:], stdSig, opts)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+
+		digest2 := sha256.Sum256([]byte("another message"))
+		verified, err = VerifySignature(signer.Public(), digest2[:], sig, opts)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+		// a PSS signature is not a PKCS #1 v1.5 signature
+		verified, err = VerifySignature(signer.Public(), digest[:], sig, crypto.SHA256)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+	}
+	// the salt length is checked when it is not automatic
+	sig, err := signer.Sign(rand.Reader, digest[:], &stdrsa.PSSOptions{SaltLength: 20, Hash: crypto.SHA256})
+	assert.Nil(t, err)
+	verified, err := VerifySignature(signer.Public(), digest[:], sig, &stdrsa.PSSOptions{SaltLength: 32, Hash: crypto.SHA256})
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	_, err = signer.Sign(nil, digest[:], &stdrsa.PSSOptions{Hash: crypto.SHA256})
+	assert.NotNil(t, err)
+}
+
+func TestSignerSmallKeys(t *testing.T) {
+	// the keys of GenerateKeyPair, rejected by crypto/rsa
+	key, err := GenerateKeyPair()
+	assert.Nil(t, err)
+	signer, err := NewSigner(key)
+	assert.Nil(t, err)
+	digest := sha256.Sum256([]byte("message"))
+	for _, opts := range []crypto.SignerOpts{crypto.SHA256, &stdrsa.PSSOptions{Hash: crypto.SHA256}} {
+		sig, err := signer.Sign(rand.Reader, digest[:], opts)
+		assert.Nil(t, err)
+		assert.Equal(t, 64, len(sig))
+		verified, err := VerifySignature(signer.Public(), digest[:], sig, opts)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+	_, err = signer.Sign(nil, digest[:], crypto.SHA512)
+	assert.NotNil(t, err)
+	_, err = signer.Sign(nil, digest[:], crypto.SHA3_256)
+	assert.NotNil(t, err)
+	_, err = signer.Sign(rand.Reader, digest[:], &stdrsa.PSSOptions{SaltLength: stdrsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+	assert.NotNil(t, err)
+
+	_, err = NewSigner(Key{PubK: key.PubK, PrivK: PrivateKey{D: big.NewInt(int64(3)), N: key.PrivK.N}})
+	assert.NotNil(t, err)
+	_, err = VerifySignature(key.PubK, digest[:], nil, crypto.SHA256)
+	assert.NotNil(t, err)
+}
+
+func TestSignerTLS(t *testing.T) {
+	// a self-signed certificate signed by the Signer, used by a TLS 1.3 server
+	// (with PSS signatures)
+	_, key := stdKey(t)
+	signer, err := NewSigner(key)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(1)),
+		Subject:      pkix.Name{CommonName: "cryptofun"},
+		DNSNames:     []string{"cryptofun"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	assert.Nil(t, cert.CheckSignatureFrom(cert))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	serverConn, clientConn := net.Pipe()
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: signer}},
+		MinVersion:   tls.VersionTLS13,
+	})
+	client := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "cryptofun"})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Handshake()
+	}()
+	assert.Nil(t, client.Handshake())
+	assert.Nil(t, <-errs)
+	_ = serverConn.Close()
+	_ = clientConn.Close()
+}
@@ -3,9 +3,12 @@ package schnorr
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"errors"
+	"io"
 	"math/big"
 
 	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
 )
 
 const (
@@ -14,8 +17,8 @@ const (
 
 // PubK is the public key of the Schnorr scheme
 type PubK struct {
-	P ecc.Point
-	Q ecc.Point
+	P group.Element
+	Q group.Element
 }
 
 // PrivK is the private key of the Schnorr scheme
@@ -24,13 +27,12 @@ type PrivK struct {
 	A    *big.Int
 }
 
-// Schnorr is the data structure for the Schnorr scheme
+// Schnorr is the data structure for the Schnorr scheme. It works over any
+// group.Group, where P is the generator of the group
 type Schnorr struct {
-	EC ecc.EC
-	D  *big.Int // K
-	G  ecc.Point
-	Q  ecc.Point // P
-	N  int       // order of curve
+	Group group.Group
+	G     group.Element
+	N     *big.Int // order of P
 }
 
 // Hash calculates a hash concatenating a given message bytes with a given EC Point. H(M||R)
@@ -48,59 +50,110 @@ func Hash(m []byte, c ecc.Point) *big.Int {
 	return r
 }
 
+// HashElement calculates a hash concatenating a given message bytes with the
+// encoding of a given group element. H(M||R)
+func HashElement(g group.Group, m []byte, e group.Element) (*big.Int, error) {
+	eBytes, err := g.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(m)
+	h.Write(eBytes)
+	return new(big.Int).SetBytes(h.Sum(nil)), nil
+}
+
 // Gen generates the Schnorr scheme
 func Gen(ec ecc.EC, g ecc.Point, r *big.Int) (Schnorr, PrivK, error) {
 	var err error
 	var schnorr Schnorr
 	var sk PrivK
-	schnorr.EC = ec
-	schnorr.G = g
 
-	sk.PubK.P, _, err = ec.At(r)
+	p, _, err := ec.At(r)
 	if err != nil {
 		return schnorr, sk, err
 	}
 
-	orderP, err := ec.Order(sk.PubK.P)
+	orderP, err := ec.Order(p)
 	if err != nil {
 		return schnorr, sk, err
 	}
+	schnorr.Group = ecc.Curve{EC: ec, G: p, N: orderP}
+	schnorr.G = g
+	schnorr.N = orderP
+	sk.PubK.P = p
 
-	// rand int between 1 and oerder of P
-	sk.A, err = rand.Int(rand.Reader, orderP)
+	// rand int between 1 and order of P
+	sk.A, err = rand.Int(rand.Reader, new(big.Int).Sub(orderP, big.NewInt(int64(1))))
 	if err != nil {
 		return schnorr, sk, err
 	}
-	sk.A = big.NewInt(int64(7))
-	skACopy := new(big.Int).SetBytes(sk.A.Bytes())
+	sk.A.Add(sk.A, big.NewInt(int64(1)))
 	// pk.Q = k x P
-	sk.PubK.Q, err = ec.Mul(sk.PubK.P, skACopy)
+	sk.PubK.Q, err = schnorr.Group.Mul(sk.PubK.P, sk.A)
 	if err != nil {
 		return schnorr, sk, err
 	}
 	return schnorr, sk, nil
 }
 
-// Sign performs the signature of the message m with the given private key
-func (schnorr Schnorr) Sign(sk PrivK, m []byte) (*big.Int, ecc.Point, error) {
-	var e *big.Int
-	orderP, err := schnorr.EC.Order(sk.PubK.P)
+// GenFromCurve generates the Schnorr scheme over a named curve, using its
+// generator as P and the known order of the generator
+func GenFromCurve(c ecc.Curve) (Schnorr, PrivK, error) {
+	return GenFromGroup(c)
+}
+
+// GenFromGroup generates the Schnorr scheme over the given group, using its
+// generator as P
+func GenFromGroup(g group.Group) (Schnorr, PrivK, error) {
+	var err error
+	var schnorr Schnorr
+	var sk PrivK
+	schnorr.Group = g
+	schnorr.G = g.Generator()
+	schnorr.N = g.Order()
+	sk.PubK.P = schnorr.G
+
+	// rand int between 1 and order of P
+	nMinusOne := new(big.Int).Sub(schnorr.N, big.NewInt(int64(1)))
+	sk.A, err = rand.Int(rand.Reader, nMinusOne)
 	if err != nil {
-		return e, ecc.Point{}, err
+		return schnorr, sk, err
+	}
+	sk.A.Add(sk.A, big.NewInt(int64(1)))
+	// pk.Q = k x P
+	sk.PubK.Q, err = g.Mul(sk.PubK.P, sk.A)
+	if err != nil {
+		return schnorr, sk, err
 	}
-	// rand k <-[1,r]
-	k, err := rand.Int(rand.Reader, orderP)
+	return schnorr, sk, nil
+}
+
+// Sign performs the signature of the message m with the given private key
+func (schnorr Schnorr) Sign(sk PrivK, m []byte) (*big.Int, group.Element, error) {
+	return schnorr.sign(rand.Reader, sk, m)
+}
+
+// sign performs the signature of the message m, with the nonce read from random
+func (schnorr Schnorr) sign(random io.Reader, sk PrivK, m []byte) (*big.Int, group.Element, error) {
+	orderP := schnorr.N
+	// rand k <-[1,r)
+	k, err := rand.Int(random, new(big.Int).Sub(orderP, big.NewInt(int64(1))))
 	if err != nil {
-		return e, ecc.Point{}, err
+		return nil, nil, err
 	}
+	k.Add(k, big.NewInt(int64(1)))
 
 	// R = k x P
-	rPoint, err := schnorr.EC.Mul(sk.PubK.P, k)
+	rPoint, err := schnorr.Group.Mul(sk.PubK.P, k)
 	if err != nil {
-		return e, ecc.Point{}, err
+		return nil, nil, err
 	}
 	// e = H(M||R)
-	e = Hash(m, rPoint)
+	e, err := HashElement(schnorr.Group, m, rPoint)
+	if err != nil {
+		return nil, nil, err
+	}
 	// a*e
 	ae := new(big.Int).Mul(sk.A, e)
 	// k + a*e
@@ -110,30 +163,73 @@ func (schnorr Schnorr) Sign(sk PrivK, m []byte) (*big.Int, ecc.Point, error) {
 	return s, rPoint, nil
 }
 
-// Verify checks if the given public key matches with the given signature of the message m, in the given EC
-func Verify(ec ecc.EC, pk PubK, m []byte, s *big.Int, rPoint ecc.Point) (bool, error) {
+// Verify checks if the given public key matches with the given signature of the message m, in the given group
+func Verify(g group.Group, pk PubK, m []byte, s *big.Int, rPoint group.Element) (bool, error) {
+	for _, p := range []group.Element{pk.P, pk.Q, rPoint} {
+		if err := g.Validate(p); err != nil {
+			return false, err
+		}
+	}
+	if g.Equal(pk.P, g.Identity()) || g.Equal(pk.Q, g.Identity()) {
+		return false, errors.New("invalid public key")
+	}
 	// e = H(M||R)
-	e := Hash(m, rPoint)
-	eCopy := new(big.Int).SetBytes(e.Bytes())
-
-	// e x Q
-	eQ, err := ec.Mul(pk.Q, eCopy)
+	e, err := HashElement(g, m, rPoint)
 	if err != nil {
 		return false, err
 	}
 
-	// R + e x Q
-	// reQ, err := schnorr.EC.Add(rPoint, eQ)
-	// if err != nil {
-	// 	return false, err
-	// }
-
-	// s x P
-	sp, err := ec.Mul(pk.P, s)
+	// s x P - e x Q == R
+	n := g.Order()
+	eNeg := new(big.Int).Neg(e)
+	sPeQ, err := group.MultiMul(g, []group.Element{pk.P, pk.Q}, []*big.Int{s, eNeg.Mod(eNeg, n)})
 	if err != nil {
 		return false, err
 	}
+	return g.Equal(sPeQ, rPoint), nil
+}
 
-	// return reQ.Equal(sp), nil
-	return eQ.Equal(sp), nil
+// BatchVerify checks at once a batch of signatures, where the i-th signature
+// (ss[i], rPoints[i]) is of the message msgs[i] with the public key pks[i]. It
+// checks that sum a_i (s_i x P_i - e_i x Q_i - R_i) == 0 with random a_i, using a
+// single multi-scalar multiplication. If it returns false, at least one of the
+// signatures is not valid
+func BatchVerify(g group.Group, pks []PubK, msgs [][]byte, ss []*big.Int, rPoints []group.Element) (bool, error) {
+	if len(pks) != len(msgs) || len(pks) != len(ss) || len(pks) != len(rPoints) {
+		return false, errors.New("the number of public keys, messages and signatures must be the same")
+	}
+	n := g.Order()
+	var elements []group.Element
+	var scalars []*big.Int
+	aMax := new(big.Int).Lsh(big.NewInt(int64(1)), 128)
+	for i := range pks {
+		for _, p := range []group.Element{pks[i].P, pks[i].Q, rPoints[i]} {
+			if err := g.Validate(p); err != nil {
+				return false, err
+			}
+		}
+		if g.Equal(pks[i].P, g.Identity()) || g.Equal(pks[i].Q, g.Identity()) {
+			return false, errors.New("invalid public key")
+		}
+		e, err := HashElement(g, msgs[i], rPoints[i])
+		if err != nil {
+			return false, err
+		}
+		a, err := rand.Int(rand.Reader, aMax)
+		if err != nil {
+			return false, err
+		}
+		// a s, -a e, -a
+		as := new(big.Int).Mul(a, ss[i])
+		ae := new(big.Int).Mul(a, e)
+		ae.Neg(ae)
+		aNeg := new(big.Int).Neg(a)
+		elements = append(elements, pks[i].P, pks[i].Q, rPoints[i])
+		scalars = append(scalars, as.Mod(as, n), ae.Mod(ae, n), aNeg.Mod(aNeg, n))
+	}
+	r, err := group.MultiMul(g, elements, scalars)
+	if err != nil {
+		return false, err
+	}
+	return g.Equal(r, g.Identity()), nil
 }
@@ -5,19 +5,20 @@ import (
 	"testing"
 
 	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestHash(t *testing.T) {
-	c := ecc.Point{big.NewInt(int64(7)), big.NewInt(int64(8))} // Generator
+	c := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(8))} // Generator
 	h := Hash([]byte("hola"), c)
 	assert.Equal(t, h.String(), "34719153732582497359642109898768696927847420320548121616059449972754491425079")
 }
 
 func TestSign(t *testing.T) {
 	ec := ecc.NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(11)))
-	g := ecc.Point{big.NewInt(int64(7)), big.NewInt(int64(8))} // Generator
-	r := big.NewInt(int64(7))                                  // random r
+	g := ecc.Point{X: big.NewInt(int64(7)), Y: big.NewInt(int64(8))} // Generator
+	r := big.NewInt(int64(7))                                        // random r
 	schnorr, sk, err := Gen(ec, g, r)
 	assert.Nil(t, err)
 
@@ -26,7 +27,7 @@ func TestSign(t *testing.T) {
 	s, rPoint, err := schnorr.Sign(sk, m)
 	assert.Nil(t, err)
 
-	verified, err := Verify(schnorr.EC, sk.PubK, m, s, rPoint)
+	verified, err := Verify(schnorr.Group, sk.PubK, m, s, rPoint)
 	assert.Nil(t, err)
 
 	assert.True(t, verified)
@@ -34,8 +35,8 @@ func TestSign(t *testing.T) {
 
 func TestSign2(t *testing.T) {
 	ec := ecc.NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), big.NewInt(int64(29)))
-	g := ecc.Point{big.NewInt(int64(11)), big.NewInt(int64(27))} // Generator
-	r := big.NewInt(int64(23))                                   // random r
+	g := ecc.Point{X: big.NewInt(int64(11)), Y: big.NewInt(int64(27))} // Generator
+	r := big.NewInt(int64(23))                                         // random r
 	schnorr, sk, err := Gen(ec, g, r)
 	assert.Nil(t, err)
 
@@ -44,8 +45,116 @@ func TestSign2(t *testing.T) {
 	s, rPoint, err := schnorr.Sign(sk, m)
 	assert.Nil(t, err)
 
-	verified, err := Verify(schnorr.EC, sk.PubK, m, s, rPoint)
+	verified, err := Verify(schnorr.Group, sk.PubK, m, s, rPoint)
 	assert.Nil(t, err)
 
 	assert.True(t, verified)
 }
+
+func TestGenRandomKeys(t *testing.T) {
+	// y^2 = x^3 + 7 mod 2^61 - 1
+	q := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(int64(1)), 61), big.NewInt(int64(1)))
+	ec := ecc.NewEC(big.NewInt(int64(0)), big.NewInt(int64(7)), q)
+	r := big.NewInt(int64(1))
+	g, _, err := ec.At(r)
+	assert.Nil(t, err)
+	_, sk1, err := Gen(ec, g, r)
+	assert.Nil(t, err)
+	_, sk2, err := Gen(ec, g, r)
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, sk1.A.Cmp(sk2.A))
+	assert.False(t, sk1.PubK.Q.(ecc.Point).Equal(sk2.PubK.Q.(ecc.Point)))
+}
+
+func TestSignNamedCurve(t *testing.T) {
+	schnorr, sk, err := GenFromCurve(ecc.Secp256k1())
+	assert.Nil(t, err)
+
+	m := []byte("hola")
+
+	s, rPoint, err := schnorr.Sign(sk, m)
+	assert.Nil(t, err)
+
+	verified, err := Verify(schnorr.Group, sk.PubK, m, s, rPoint)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	verified, err = Verify(schnorr.Group, sk.PubK, []byte("adeu"), s, rPoint)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+}
+
+func TestSignOverGroups(t *testing.T) {
+	zp, err := group.GenerateZpGroup(512, 160)
+	assert.Nil(t, err)
+	bn, err := group.NewBN128G1()
+	assert.Nil(t, err)
+
+	for _, g := range []group.Group{zp, bn} {
+		schnorr, sk, err := GenFromGroup(g)
+		assert.Nil(t, err)
+
+		m := []byte("hola")
+		s, rPoint, err := schnorr.Sign(sk, m)
+		assert.Nil(t, err)
+
+		verified, err := Verify(schnorr.Group, sk.PubK, m, s, rPoint)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+
+		verified, err = Verify(schnorr.Group, sk.PubK, []byte("adeu"), s, rPoint)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	c := ecc.Secp256k1()
+	var pks []PubK
+	var msgs [][]byte
+	var ss []*big.Int
+	var rPoints []group.Element
+	for i := 0; i < 8; i++ {
+		schnorr, sk, err := GenFromCurve(c)
+		assert.Nil(t, err)
+		m := []byte{byte(i)}
+		s, rPoint, err := schnorr.Sign(sk, m)
+		assert.Nil(t, err)
+		pks = append(pks, sk.PubK)
+		msgs = append(msgs, m)
+		ss = append(ss, s)
+		rPoints = append(rPoints, rPoint)
+	}
+	verified, err := BatchVerify(c, pks, msgs, ss, rPoints)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	// one invalid signature makes the batch fail
+	msgs[3] = []byte("adeu")
+	verified, err = BatchVerify(c, pks, msgs, ss, rPoints)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	_, err = BatchVerify(c, pks, msgs, ss[1:], rPoints)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyInvalidPoint(t *testing.T) {
+	schnorr, sk, err := GenFromCurve(ecc.Secp256k1())
+	assert.Nil(t, err)
+	m := []byte("hola")
+	s, rPoint, err := schnorr.Sign(sk, m)
+	assert.Nil(t, err)
+
+	r := rPoint.(ecc.Point)
+	offCurve := ecc.Point{X: r.X, Y: new(big.Int).Add(r.Y, big.NewInt(int64(1)))}
+	verified, err := Verify(schnorr.Group, sk.PubK, m, s, offCurve)
+	assert.NotNil(t, err)
+	assert.False(t, verified)
+
+	pk := sk.PubK
+	pk.Q = ecc.ZeroPoint
+	verified, err = Verify(schnorr.Group, pk, m, s, rPoint)
+	assert.NotNil(t, err)
+	assert.False(t, verified)
+}
@@ -0,0 +1,102 @@
+package schnorr
+
+import (
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// PublicKey is the public key returned by Signer.Public
+type PublicKey struct {
+	Schnorr Schnorr
+	PubK    PubK
+}
+
+// Equal returns true if x is the same public key, over the same group
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	if !ok || pk.Schnorr.N.Cmp(other.Schnorr.N) != 0 {
+		return false
+	}
+	g := pk.Schnorr.Group
+	return g.Equal(pk.PubK.P, other.PubK.P) && g.Equal(pk.PubK.Q, other.PubK.Q)
+}
+
+// Signer implements crypto.Signer with a private key of the Schnorr scheme.
+// As the Schnorr signatures hash the message together with R, the digest is
+// signed as the message m. The signatures are encoded as s || R, with s of the
+// byte length of N and R encoded with Group.Marshal
+type Signer struct {
+	Schnorr Schnorr
+	PrivK   PrivK
+}
+
+// NewSigner returns the Signer of the private key, checking that its public key
+// Q is a x P
+func NewSigner(schnorr Schnorr, sk PrivK) (*Signer, error) {
+	if sk.A == nil || sk.A.Sign() <= 0 || sk.A.Cmp(schnorr.N) >= 0 {
+		return nil, errors.New("the private key must be in [1, N)")
+	}
+	q, err := schnorr.Group.Mul(sk.PubK.P, sk.A)
+	if err != nil {
+		return nil, err
+	}
+	if !schnorr.Group.Equal(q, sk.PubK.Q) {
+		return nil, errors.New("the public key is not the one of the private key")
+	}
+	return &Signer{Schnorr: schnorr, PrivK: sk}, nil
+}
+
+// Public returns the public key, a *PublicKey
+func (s *Signer) Public() crypto.PublicKey {
+	return &PublicKey{Schnorr: s.Schnorr, PubK: s.PrivK.PubK}
+}
+
+// Sign signs the digest as the message m, with the nonce read from random (or
+// crypto/rand when it is nil), returning s || R. When opts has a hash function,
+// the length of the digest must be its size
+func (s *Signer) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 && len(digest) != opts.HashFunc().Size() {
+		return nil, errors.New("the digest length does not match the hash function")
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	sig, rPoint, err := s.Schnorr.sign(random, s.PrivK, digest)
+	if err != nil {
+		return nil, err
+	}
+	rBytes, err := s.Schnorr.Group.Marshal(rPoint)
+	if err != nil {
+		return nil, err
+	}
+	size := (s.Schnorr.N.BitLen() + 7) / 8
+	return append(sig.FillBytes(make([]byte, size)), rBytes...), nil
+}
+
+// VerifySignature validates the signature s || R of the digest with the public
+// key returned by Signer.Public
+func VerifySignature(pub crypto.PublicKey, digest, sig []byte, opts crypto.SignerOpts) (bool, error) {
+	pk, ok := pub.(*PublicKey)
+	if !ok {
+		return false, errors.New("not a Schnorr public key")
+	}
+	if opts != nil && opts.HashFunc() != 0 && len(digest) != opts.HashFunc().Size() {
+		return false, errors.New("the digest length does not match the hash function")
+	}
+	size := (pk.Schnorr.N.BitLen() + 7) / 8
+	if len(sig) <= size {
+		return false, errors.New("invalid signature encoding: length")
+	}
+	s := new(big.Int).SetBytes(sig[:size])
+	if s.Cmp(pk.Schnorr.N) >= 0 {
+		return false, errors.New("invalid signature: s must be in [0, N)")
+	}
+	rPoint, err := pk.Schnorr.Group.Unmarshal(sig[size:])
+	if err != nil {
+		return false, err
+	}
+	return Verify(pk.Schnorr.Group, pk.PubK, digest, s, rPoint)
+}
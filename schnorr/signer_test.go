@@ -0,0 +1,92 @@
+package schnorr
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/cryptofun/ecc"
+	"github.com/arnaucube/cryptofun/group"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	zp, err := group.GenerateZpGroup(512, 160)
+	assert.Nil(t, err)
+	bn, err := group.NewBN128G1()
+	assert.Nil(t, err)
+	digest := sha256.Sum256([]byte("message"))
+	for _, g := range []group.Group{ecc.Secp256k1(), zp, bn} {
+		schnorr, sk, err := GenFromGroup(g)
+		assert.Nil(t, err)
+		signer, err := NewSigner(schnorr, sk)
+		assert.Nil(t, err)
+		var _ crypto.Signer = signer
+
+		for _, random := range []io.Reader{nil, rand.Reader} {
+			sig, err := signer.Sign(random, digest[:], crypto.SHA256)
+			assert.Nil(t, err)
+			verified, err := VerifySignature(signer.Public(), digest[:], sig, crypto.SHA256)
+			assert.Nil(t, err)
+			assert.True(t, verified)
+			verified, err = VerifySignature(signer.Public(), []byte("another message"), sig, nil)
+			assert.Nil(t, err)
+			assert.False(t, verified)
+
+			// the signature is s || R, of the message digest
+			size := (schnorr.N.BitLen() + 7) / 8
+			rPoint, err := g.Unmarshal(sig[size:])
+			assert.Nil(t, err)
+			verified, err = Verify(g, sk.PubK, digest[:], new(big.Int).SetBytes(sig[:size]), rPoint)
+			assert.Nil(t, err)
+			assert.True(t, verified)
+		}
+
+		// the messages are signed without hash function
+		sig, err := signer.Sign(nil, []byte("message"), crypto.Hash(0))
+		assert.Nil(t, err)
+		verified, err := VerifySignature(signer.Public(), []byte("message"), sig, crypto.Hash(0))
+		assert.Nil(t, err)
+		assert.True(t, verified)
+
+		_, other, err := GenFromGroup(g)
+		assert.Nil(t, err)
+		otherSigner, err := NewSigner(schnorr, other)
+		assert.Nil(t, err)
+		assert.True(t, signer.Public().(*PublicKey).Equal(signer.Public()))
+		assert.False(t, signer.Public().(*PublicKey).Equal(otherSigner.Public()))
+		verified, err = VerifySignature(otherSigner.Public(), []byte("message"), sig, nil)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+	}
+}
+
+func TestSignerErrors(t *testing.T) {
+	schnorr, sk, err := GenFromCurve(ecc.Secp256k1())
+	assert.Nil(t, err)
+	signer, err := NewSigner(schnorr, sk)
+	assert.Nil(t, err)
+	_, err = signer.Sign(nil, []byte("message"), crypto.SHA256)
+	assert.NotNil(t, err)
+
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	assert.Nil(t, err)
+	_, err = VerifySignature(signer.Public(), digest[:], sig[:32], crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = VerifySignature(signer.Public(), digest[:], sig[:len(sig)-1], crypto.SHA256)
+	assert.NotNil(t, err)
+	_, err = VerifySignature(sk.PubK, digest[:], sig, crypto.SHA256)
+	assert.NotNil(t, err)
+
+	// a public key which is not the one of the private key
+	_, other, err := GenFromCurve(ecc.Secp256k1())
+	assert.Nil(t, err)
+	_, err = NewSigner(schnorr, PrivK{PubK: other.PubK, A: sk.A})
+	assert.NotNil(t, err)
+	_, err = NewSigner(schnorr, PrivK{PubK: sk.PubK, A: big.NewInt(int64(0))})
+	assert.NotNil(t, err)
+}